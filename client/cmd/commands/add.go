@@ -3,11 +3,17 @@ package commands
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/dvkhr/gophkeeper/client/internal/catalog"
 	"github.com/dvkhr/gophkeeper/client/internal/client"
 	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
 	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // NewAddCommand создаёт команду add
@@ -15,18 +21,14 @@ func NewAddCommand(factory *client.Factory) *cli.Command {
 	return &cli.Command{
 		Name:  "add",
 		Usage: "Добавить данные c метаинформацией",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{Name: "id", Required: true},
-			&cli.StringFlag{Name: "type", Aliases: []string{"t"}, Required: true},
-			&cli.StringFlag{Name: "login"},
-			&cli.StringFlag{Name: "password"},
-			&cli.StringFlag{Name: "number"},
-			&cli.StringFlag{Name: "expiry"},
-			&cli.StringFlag{Name: "cvv"},
-			&cli.StringFlag{Name: "content"},
-			&cli.StringFlag{Name: "file", Usage: "Путь к файлу для загрузки"},
+			&cli.StringFlag{Name: "type", Aliases: []string{"t"}, Required: true, Usage: typesUsage()},
 			&cli.StringSliceFlag{Name: "meta", Aliases: []string{"m"}},
-		},
+			&cli.StringFlag{Name: "folder", Usage: "Папка записи, например team/prod"},
+			&cli.StringSliceFlag{Name: "tag", Usage: "Метка записи; можно указать несколько"},
+			&cli.BoolFlag{Name: "generate", Aliases: []string{"g"}, Usage: "Сгенерировать пароль (loginpass); параметры — как у команды generate"},
+		}, append(fieldFlags(), generatorFlags()...)...),
 		Action: func(cCtx *cli.Context) error {
 			client, err := factory.NewAuthenticatedClient()
 			if err != nil {
@@ -34,36 +36,95 @@ func NewAddCommand(factory *client.Factory) *cli.Command {
 			}
 			defer client.Close()
 
-			record, err := buildDataRecord(cCtx)
+			record, entropy, err := buildDataRecord(cCtx)
 			if err != nil {
 				return err
 			}
 
-			err = client.DoWithRetry(func() error {
-				resp, err := client.StoreData(record)
-				if err != nil {
-					return err
-				}
-				fmt.Printf("Данные сохранены: %s\n", resp.Message)
-				return nil
-			})
+			if record.Type == payload.TypeBinary {
+				return uploadBinary(client, record, cCtx.String("file"))
+			}
+
+			if err := client.PutLocal(record); err != nil {
+				return err
+			}
+			if cCtx.Bool("generate") {
+				// Подсказка выводится только после сохранения: иначе пароль, о котором она говорит, может потеряться.
+				// Сам пароль не выводится: stdout попадает в историю терминала и логи
+				fmt.Fprintf(os.Stderr, "Сгенерирован пароль (энтропия ~%.0f бит, %s) — скопируйте его командой copy --id=%s\n",
+					entropy, entropyRating(entropy), record.Id)
+			}
+
+			online, err := syncVault(client)
+			if err != nil {
+				return err
+			}
 
-			return err
+			if online {
+				fmt.Printf("Данные сохранены: %s\n", record.Id)
+			} else {
+				fmt.Printf("Данные сохранены локально: %s — будут отправлены при следующей синхронизации\n", record.Id)
+			}
+			return nil
 		},
 	}
 }
 
-// buildDataRecord — вспомогательная функция
-func buildDataRecord(cCtx *cli.Context) (*pb.DataRecord, error) {
+// uploadBinary загружает файл на сервер потоком.
+// Содержимое бинарных записей не хранится в локальном хранилище, поэтому нужно подключение к серверу.
+func uploadBinary(c *client.Client, record *pb.DataRecord, path string) error {
+	online, err := syncVault(c)
+	if err != nil {
+		return err
+	}
+	if !online {
+		return fmt.Errorf("для загрузки файла нужно подключение к серверу")
+	}
+
+	if existing, err := c.Vault().Get(record.Id); err == nil {
+		record.Revision = existing.Revision
+	}
+
+	var resp *pb.UploadBinaryResponse
+	err = c.DoWithRetry(func() error {
+		resp, err = c.UploadBinary(record, path)
+		return err
+	})
+	if status.Code(err) == codes.Aborted {
+		return fmt.Errorf("запись %s изменена на сервере, выполните sync и повторите загрузку", record.Id)
+	}
+	if client.IsOffline(err) {
+		return fmt.Errorf("загрузка %s прервана, повторите команду — она продолжится с принятой сервером части: %w", record.Id, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := syncVault(c); err != nil {
+		return err
+	}
+
+	fmt.Printf("Файл загружен: %s (%d байт, ревизия %d)\n", record.Id, resp.Received, resp.Revision)
+	return nil
+}
+
+// buildDataRecord — вспомогательная функция.
+// Возвращает также энтропию пароля, сгенерированного по --generate
+func buildDataRecord(cCtx *cli.Context) (*pb.DataRecord, float64, error) {
 	if err := validateFlags(cCtx); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	metadata := buildMetadata(cCtx)
 
-	data, err := readData(cCtx)
+	data, entropy, err := readData(cCtx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	folder, err := catalog.CleanPath(cCtx.String("folder"))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	return &pb.DataRecord{
@@ -71,10 +132,12 @@ func buildDataRecord(cCtx *cli.Context) (*pb.DataRecord, error) {
 		Type:          cCtx.String("type"),
 		EncryptedData: data,
 		Metadata:      metadata,
-	}, nil
+		Folder:        folder,
+		Tags:          catalog.CleanTags(cCtx.StringSlice("tag")),
+	}, entropy, nil
 }
 
-// validateFlags проверяет, что для типа данных переданы нужные флаги
+// validateFlags проверяет, что для типа данных переданы обязательные поля из реестра типов
 func validateFlags(cCtx *cli.Context) error {
 	dataType := cCtx.String("type")
 	id := cCtx.String("id")
@@ -83,30 +146,93 @@ func validateFlags(cCtx *cli.Context) error {
 		return fmt.Errorf("требуется --id")
 	}
 
-	switch dataType {
-	case "loginpass":
-		if cCtx.String("login") == "" || cCtx.String("password") == "" {
-			return fmt.Errorf("для loginpass нужны --login и --password")
+	spec, ok := payload.Lookup(dataType)
+	if !ok || spec.Internal {
+		return fmt.Errorf("неизвестный тип: %s", dataType)
+	}
+
+	if cCtx.Bool("generate") {
+		if !hasField(spec, generatedField) {
+			return fmt.Errorf("--generate поддерживается только для типов с полем %s", generatedField)
 		}
-	case "card":
-		if cCtx.String("number") == "" || cCtx.String("expiry") == "" {
-			return fmt.Errorf("для card нужны --number и --expiry")
+		if cCtx.String(generatedField) != "" {
+			return fmt.Errorf("укажите либо --%s, либо --generate", generatedField)
 		}
-	case "text":
-		if cCtx.String("content") == "" {
-			return fmt.Errorf("для text нужен --content")
+	}
+
+	var missing []string
+	for _, field := range spec.Fields {
+		if !field.Required || field.ReadOnly || cCtx.String(field.Name) != "" {
+			continue
 		}
-	case "binary":
-		if cCtx.String("file") == "" {
-			return fmt.Errorf("для binary нужен --file")
+		if field.Name == generatedField && cCtx.Bool("generate") {
+			continue
 		}
-	default:
-		return fmt.Errorf("неизвестный тип: %s", dataType)
+		if field.FileFlag != "" {
+			if cCtx.String(field.FileFlag) == "" {
+				missing = append(missing, fmt.Sprintf("--%s или --%s", field.Name, field.FileFlag))
+			}
+			continue
+		}
+		missing = append(missing, "--"+field.Name)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("для %s нужны %s", dataType, strings.Join(missing, ", "))
 	}
 
 	return nil
 }
 
+// generatedField — поле, которое заполняет add --generate
+const generatedField = "password"
+
+// hasField сообщает, есть ли у типа поле name
+func hasField(spec payload.TypeSpec, name string) bool {
+	for _, field := range spec.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldFlags возвращает флаги полей всех типов из реестра.
+// Поле с тем же именем у разных типов задаётся одним флагом.
+func fieldFlags() []cli.Flag {
+	var flags []cli.Flag
+	seen := make(map[string]bool)
+	addFlag := func(name, usage string) {
+		if !seen[name] {
+			seen[name] = true
+			flags = append(flags, &cli.StringFlag{Name: name, Usage: usage})
+		}
+	}
+
+	for _, spec := range payload.Types() {
+		for _, field := range spec.Fields {
+			if field.ReadOnly {
+				continue
+			}
+			addFlag(field.Name, fmt.Sprintf("%s (%s)", field.Usage, spec.Name))
+			if field.FileFlag != "" {
+				addFlag(field.FileFlag, fmt.Sprintf("Файл, из которого читается поле %s (%s)", field.Name, spec.Name))
+			}
+		}
+	}
+	return flags
+}
+
+// typesUsage перечисляет типы записей из реестра для справки по флагу --type
+func typesUsage() string {
+	var types []string
+	for _, spec := range payload.Types() {
+		if !spec.Internal {
+			types = append(types, fmt.Sprintf("%s — %s", spec.Name, spec.Usage))
+		}
+	}
+	return "Тип данных: " + strings.Join(types, "; ")
+}
+
 // buildMetadata парсит флаг --meta в map[string]string
 func buildMetadata(cCtx *cli.Context) map[string]string {
 	metadata := make(map[string]string)
@@ -121,28 +247,66 @@ func buildMetadata(cCtx *cli.Context) map[string]string {
 	return metadata
 }
 
-// readData читает данные в зависимости от типа
-func readData(cCtx *cli.Context) ([]byte, error) {
+// readData собирает содержимое записи из флагов, проверяет и сериализует его
+func readData(cCtx *cli.Context) ([]byte, float64, error) {
+	p, entropy, err := buildPayload(cCtx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := payload.Validate(p); err != nil {
+		return nil, 0, fmt.Errorf("неверные данные %s: %w", cCtx.String("type"), err)
+	}
+	data, err := payload.Marshal(p)
+	return data, entropy, err
+}
+
+// buildPayload собирает содержимое записи из флагов полей её типа.
+// С --generate заполняет поле пароля и возвращает энтропию сгенерированного пароля
+func buildPayload(cCtx *cli.Context) (*pb.Payload, float64, error) {
 	dataType := cCtx.String("type")
+	spec, ok := payload.Lookup(dataType)
+	if !ok {
+		return nil, 0, fmt.Errorf("неожиданный тип: %s", dataType)
+	}
+
+	values := make(map[string]string)
+	for _, field := range spec.Fields {
+		if field.ReadOnly {
+			continue
+		}
+		values[field.Name] = cCtx.String(field.Name)
+		if path := cCtx.String(field.FileFlag); field.FileFlag != "" && path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, 0, fmt.Errorf("не удалось прочитать файл: %w", err)
+			}
+			values[field.Name] = string(data)
+		}
+	}
 
-	if cCtx.String("file") != "" {
-		data, err := os.ReadFile(cCtx.String("file"))
+	var entropy float64
+	if cCtx.Bool("generate") {
+		secret, generated, err := generateSecret(cCtx)
 		if err != nil {
-			return nil, fmt.Errorf("не удалось прочитать файл: %w", err)
+			return nil, 0, err
 		}
-		return data, nil
+		values[generatedField], entropy = secret, generated
 	}
 
-	switch dataType {
-	case "loginpass":
-		return []byte(fmt.Sprintf("login:%s\npassword:%s",
-			cCtx.String("login"), cCtx.String("password"))), nil
-	case "card":
-		return []byte(fmt.Sprintf("number:%s\nexpiry:%s\ncvv:%s",
-			cCtx.String("number"), cCtx.String("expiry"), cCtx.String("cvv"))), nil
-	case "text":
-		return []byte(cCtx.String("content")), nil
+	if dataType == payload.TypeBinary {
+		// Содержимое файла передаётся потоком через UploadBinary, в записи хранится только его описание
+		info, err := os.Stat(values["file"])
+		if err != nil {
+			return nil, 0, fmt.Errorf("не удалось прочитать файл: %w", err)
+		}
+		values["file"] = filepath.Base(values["file"])
+		values["size"] = strconv.FormatInt(info.Size(), 10)
 	}
 
-	return nil, fmt.Errorf("неожиданный тип: %s", dataType)
+	p, err := payload.Encode(dataType, values)
+	if err != nil {
+		return nil, 0, fmt.Errorf("неверные данные %s: %w", dataType, err)
+	}
+	return p, entropy, nil
 }
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/urfave/cli/v2"
+)
+
+// NewCodeCommand создаёт команду code
+func NewCodeCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "code",
+		Usage: "Показать текущий одноразовый код TOTP",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "id", Required: true, Usage: "ID записи типа totp"},
+		},
+		Action: func(cCtx *cli.Context) error {
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			record, err := findTOTPRecord(client, cCtx.String("id"))
+			if err != nil {
+				return err
+			}
+
+			p, err := payload.Unmarshal(record.Type, record.EncryptedData)
+			if err != nil {
+				return fmt.Errorf("не удалось разобрать запись %s: %w", record.Id, err)
+			}
+
+			code, remaining, err := payload.TOTPCode(payload.Values(p), time.Now())
+			if err != nil {
+				return fmt.Errorf("не удалось вычислить код для %s: %w", record.Id, err)
+			}
+
+			fmt.Printf("%s (действует ещё %d с)\n", code, int(remaining.Seconds()))
+			return nil
+		},
+	}
+}
+
+// findTOTPRecord возвращает расшифрованную запись id типа totp.
+func findTOTPRecord(c *client.Client, id string) (*pb.DataRecord, error) {
+	record, err := fetchRecord(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Type != payload.TypeTOTP {
+		return nil, fmt.Errorf("запись %s имеет тип %s, а не %s", id, record.Type, payload.TypeTOTP)
+	}
+	return record, nil
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/client/internal/clipboard"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/urfave/cli/v2"
+)
+
+// NewCopyCommand создаёт команду copy
+func NewCopyCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "copy",
+		Usage: "Скопировать поле записи в буфер обмена и очистить его по таймауту",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "id", Required: true, Usage: "ID записи"},
+			&cli.StringFlag{Name: "field", Aliases: []string{"f"}, Value: "password", Usage: "Поле записи"},
+			&cli.DurationFlag{
+				Name:    "timeout",
+				Value:   45 * time.Second,
+				Usage:   "Через сколько очистить буфер обмена",
+				EnvVars: []string{"GK_CLIPBOARD_TIMEOUT"},
+			},
+		},
+		Action: func(cCtx *cli.Context) error {
+			cb, err := clipboard.Detect()
+			if err != nil {
+				return err
+			}
+
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			id, field := cCtx.String("id"), cCtx.String("field")
+			record, err := fetchRecord(client, id)
+			if err != nil {
+				return err
+			}
+
+			p, err := payload.Unmarshal(record.Type, record.EncryptedData)
+			if err != nil {
+				return fmt.Errorf("не удалось разобрать запись %s: %w", id, err)
+			}
+			values := payload.Values(p)
+			secret, ok := values[field]
+			if !ok || secret == "" {
+				return fmt.Errorf("у записи %s нет поля %s; доступные поля: %s", id, field, fieldNames(values))
+			}
+
+			timeout := cCtx.Duration("timeout")
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			fmt.Printf("Поле %s записи %s скопировано в буфер обмена, он будет очищен через %s (Ctrl+C — очистить сейчас)\n", field, id, timeout)
+			cleared, err := clipboard.CopyWithClear(ctx, cb, secret, timeout)
+			if err != nil {
+				return err
+			}
+			if cleared {
+				fmt.Println("Буфер обмена очищен")
+			} else {
+				fmt.Println("Содержимое буфера обмена изменилось — очистка не нужна")
+			}
+			return nil
+		},
+	}
+}
+
+// fieldNames перечисляет непустые поля записи через запятую
+func fieldNames(values map[string]string) string {
+	var names []string
+	for name, value := range values {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
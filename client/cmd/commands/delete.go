@@ -29,16 +29,28 @@ func NewDeleteCommand(factory *client.Factory) *cli.Command {
 			}
 			defer client.Close()
 
-			err = client.DoWithRetry(func() error {
-				resp, err := client.DeleteData(id)
-				if err != nil {
+			// Загружаем свежие данные, чтобы удаление ушло с актуальной ревизией
+			online, err := syncVault(client)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteLocal(id); err != nil {
+				return err
+			}
+
+			if online {
+				if online, err = syncVault(client); err != nil {
 					return err
 				}
-				fmt.Printf("%s\n", resp.Message)
-				return nil
-			})
+			}
 
-			return err
+			if online {
+				fmt.Printf("Запись удалена: %s — её можно восстановить командой undelete --id=%s\n", id, id)
+			} else {
+				fmt.Printf("Запись удалена локально: %s — удаление будет отправлено при следующей синхронизации\n", id)
+			}
+			return nil
 		},
 	}
 }
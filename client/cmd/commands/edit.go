@@ -0,0 +1,323 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+
+	"github.com/dvkhr/gophkeeper/client/internal/catalog"
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/client/internal/utils"
+	"github.com/dvkhr/gophkeeper/client/storage/vault"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/urfave/cli/v2"
+)
+
+// editDocument — представление записи, которое редактируется в $EDITOR
+type editDocument struct {
+	ID       string            `json:"id"`
+	Type     string            `json:"type"`
+	Fields   map[string]string `json:"fields"`
+	Metadata map[string]string `json:"metadata"`
+	Folder   string            `json:"folder"`
+	Tags     []string          `json:"tags"`
+}
+
+// NewEditCommand создаёт команду edit
+func NewEditCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:    "edit",
+		Aliases: []string{"update"},
+		Usage:   "Изменить поля и метаданные существующей записи",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{Name: "id", Required: true},
+			&cli.StringSliceFlag{Name: "meta", Aliases: []string{"m"}, Usage: "Установить метаданные ключ=значение"},
+			&cli.StringSliceFlag{Name: "unset-meta", Usage: "Удалить ключ метаданных"},
+			&cli.BoolFlag{Name: "editor", Aliases: []string{"e"}, Usage: "Открыть запись в $EDITOR"},
+		}, fieldFlags()...),
+		Action: func(cCtx *cli.Context) error {
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if _, err := syncVault(client); err != nil {
+				return err
+			}
+
+			id := cCtx.String("id")
+			record, err := client.Vault().Get(id)
+			if err != nil {
+				return fmt.Errorf("запись с ID %s не найдена", id)
+			}
+
+			doc, err := newEditDocument(record)
+			if err != nil {
+				return err
+			}
+
+			edited := cloneDocument(doc)
+			if cCtx.Bool("editor") {
+				edited, err = editInEditor(doc)
+			} else {
+				err = applyEditFlags(cCtx, edited)
+			}
+			if err != nil {
+				return err
+			}
+
+			if maps.Equal(doc.Fields, edited.Fields) && maps.Equal(doc.Metadata, edited.Metadata) &&
+				doc.Folder == edited.Folder && slices.Equal(doc.Tags, edited.Tags) {
+				fmt.Println("Изменений нет")
+				return nil
+			}
+
+			updated, err := buildEditedRecord(record, edited)
+			if err != nil {
+				return err
+			}
+			if err := client.PutLocal(updated); err != nil {
+				return err
+			}
+
+			return saveEdit(client, id)
+		},
+	}
+}
+
+// newEditDocument разбирает содержимое записи в редактируемое представление
+func newEditDocument(record *pb.DataRecord) (*editDocument, error) {
+	doc := &editDocument{
+		ID:       record.Id,
+		Type:     record.Type,
+		Fields:   make(map[string]string),
+		Metadata: maps.Clone(record.Metadata),
+		Folder:   record.Folder,
+		Tags:     slices.Clone(record.Tags),
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]string)
+	}
+
+	if record.BlobSize > 0 && len(record.EncryptedData) == 0 {
+		// Запись загружена до появления описания содержимого — меняются только метаданные
+		return doc, nil
+	}
+
+	p, err := payload.Unmarshal(record.Type, record.EncryptedData)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать запись %s: %w", record.Id, err)
+	}
+	for k, v := range payload.Values(p) {
+		if v != "" {
+			doc.Fields[k] = v
+		}
+	}
+	return doc, nil
+}
+
+// cloneDocument возвращает копию представления записи
+func cloneDocument(doc *editDocument) *editDocument {
+	return &editDocument{
+		ID:       doc.ID,
+		Type:     doc.Type,
+		Fields:   maps.Clone(doc.Fields),
+		Metadata: maps.Clone(doc.Metadata),
+		Folder:   doc.Folder,
+		Tags:     slices.Clone(doc.Tags),
+	}
+}
+
+// applyEditFlags меняет в представлении записи поля и метаданные, переданные флагами
+func applyEditFlags(cCtx *cli.Context, doc *editDocument) error {
+	spec, ok := payload.Lookup(doc.Type)
+	if !ok {
+		return fmt.Errorf("неизвестный тип: %s", doc.Type)
+	}
+
+	for _, field := range spec.Fields {
+		value, set := cCtx.String(field.Name), cCtx.IsSet(field.Name)
+		if field.FileFlag != "" && cCtx.IsSet(field.FileFlag) {
+			data, err := os.ReadFile(cCtx.String(field.FileFlag))
+			if err != nil {
+				return fmt.Errorf("не удалось прочитать файл: %w", err)
+			}
+			value, set = string(data), true
+		}
+		if !set {
+			continue
+		}
+		if field.ReadOnly || doc.Type == payload.TypeBinary {
+			return fmt.Errorf("поле %s нельзя изменить, загрузите файл заново командой add", field.Name)
+		}
+		setValue(doc.Fields, field.Name, value)
+	}
+
+	maps.Copy(doc.Metadata, buildMetadata(cCtx))
+	for _, key := range cCtx.StringSlice("unset-meta") {
+		delete(doc.Metadata, key)
+	}
+	return nil
+}
+
+// setValue записывает значение поля; пустое значение удаляет поле
+func setValue(values map[string]string, name, value string) {
+	if value == "" {
+		delete(values, name)
+		return
+	}
+	values[name] = value
+}
+
+// editInEditor открывает представление записи в $EDITOR и возвращает изменённое.
+// Временный файл создаётся в каталоге в памяти и удаляется сразу после редактирования.
+func editInEditor(doc *editDocument) (*editDocument, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		return nil, fmt.Errorf("не задана переменная окружения EDITOR")
+	}
+
+	dir, err := utils.MemoryTempDir("gophkeeper-edit-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// ID записи приходит с сервера, поэтому в имени файла не используется
+	path := filepath.Join(dir, "record.json")
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return nil, fmt.Errorf("не удалось создать временный файл: %w", err)
+	}
+
+	// EDITOR может содержать аргументы, например "code --wait"
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("редактор завершился с ошибкой: %w", err)
+	}
+
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать временный файл: %w", err)
+	}
+	defer utils.ZeroBytes(data)
+
+	var edited editDocument
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&edited); err != nil {
+		return nil, fmt.Errorf("неверный формат записи: %w", err)
+	}
+	if edited.ID != doc.ID || edited.Type != doc.Type {
+		return nil, fmt.Errorf("id и type записи менять нельзя")
+	}
+	if doc.Type == payload.TypeBinary && !maps.Equal(doc.Fields, edited.Fields) {
+		return nil, fmt.Errorf("поля бинарной записи менять нельзя, загрузите файл заново командой add")
+	}
+
+	for _, values := range []map[string]string{edited.Fields, edited.Metadata} {
+		for k, v := range values {
+			if v == "" {
+				delete(values, k)
+			}
+		}
+	}
+	if edited.Fields == nil {
+		edited.Fields = make(map[string]string)
+	}
+	if edited.Metadata == nil {
+		edited.Metadata = make(map[string]string)
+	}
+	if edited.Folder, err = catalog.CleanPath(edited.Folder); err != nil {
+		return nil, err
+	}
+	edited.Tags = catalog.CleanTags(edited.Tags)
+	return &edited, nil
+}
+
+// buildEditedRecord собирает изменённую запись, проверяя поля по реестру типов
+func buildEditedRecord(record *pb.DataRecord, doc *editDocument) (*pb.DataRecord, error) {
+	updated := &pb.DataRecord{
+		Id:            record.Id,
+		Type:          record.Type,
+		EncryptedData: record.EncryptedData,
+		Metadata:      doc.Metadata,
+		Folder:        doc.Folder,
+		Tags:          doc.Tags,
+		BlobSize:      record.BlobSize,
+	}
+	if record.Type == payload.TypeBinary {
+		return updated, nil
+	}
+
+	p, err := payload.Encode(doc.Type, doc.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("неверные данные %s: %w", doc.Type, err)
+	}
+	if err := payload.Validate(p); err != nil {
+		return nil, fmt.Errorf("неверные данные %s: %w", doc.Type, err)
+	}
+	updated.EncryptedData, err = payload.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// editSyncer — часть клиента, через которую saveEdit отправляет изменения
+type editSyncer interface {
+	DoWithRetry(fn func() error) error
+	Sync() (*client.SyncResult, error)
+	Vault() *vault.Vault
+}
+
+// saveEdit отправляет изменённую запись на сервер. Сервер принимает её, только если
+// запись не менялась с ревизии, с которой начато редактирование; иначе изменения
+// сохраняются в копии записи, а в хранилище загружается серверная версия.
+func saveEdit(c editSyncer, id string) error {
+	var resp *client.SyncResult
+	err := c.DoWithRetry(func() (err error) {
+		resp, err = c.Sync()
+		return err
+	})
+	if client.IsOffline(err) {
+		fmt.Printf("Запись изменена локально: %s — изменения будут отправлены при следующей синхронизации\n", id)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	printSyncIssues(resp, c.Vault())
+	for _, conflict := range resp.Conflicts {
+		if conflict.Id == id {
+			return fmt.Errorf("запись %s изменена на сервере во время редактирования, повторите edit", id)
+		}
+	}
+	for _, rejected := range resp.Rejected {
+		if rejected.Id != id {
+			continue
+		}
+		if rejected.Retryable {
+			return fmt.Errorf("сервер не сохранил запись %s, изменения останутся в локальном хранилище до следующей синхронизации", id)
+		}
+		return fmt.Errorf("сервер отклонил запись %s: %s", id, rejected.Reason)
+	}
+
+	fmt.Printf("Запись изменена: %s\n", id)
+	return nil
+}
@@ -0,0 +1,396 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/client/storage/vault"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func testRecord(t *testing.T, id, typ string, values map[string]string) *pb.DataRecord {
+	t.Helper()
+
+	p, err := payload.Encode(typ, values)
+	require.NoError(t, err)
+	data, err := payload.Marshal(p)
+	require.NoError(t, err)
+	return &pb.DataRecord{Id: id, Type: typ, EncryptedData: data, Metadata: map[string]string{"team": "backend"}}
+}
+
+func recordValues(t *testing.T, record *pb.DataRecord) map[string]string {
+	t.Helper()
+
+	p, err := payload.Unmarshal(record.Type, record.EncryptedData)
+	require.NoError(t, err)
+	return payload.Values(p)
+}
+
+// runEditFlags применяет флаги команды edit к представлению записи
+func runEditFlags(t *testing.T, doc *editDocument, args ...string) error {
+	t.Helper()
+
+	var result error
+	app := &cli.App{
+		Flags: NewEditCommand(nil).Flags,
+		Action: func(cCtx *cli.Context) error {
+			result = applyEditFlags(cCtx, doc)
+			return nil
+		},
+	}
+	require.NoError(t, app.Run(append([]string{"edit", "--id=" + doc.ID}, args...)))
+	return result
+}
+
+func TestApplyEditFlags(t *testing.T) {
+	noteFile := filepath.Join(t.TempDir(), "note.txt")
+	require.NoError(t, os.WriteFile(noteFile, []byte("из файла"), 0600))
+
+	tests := []struct {
+		name     string
+		typ      string
+		fields   map[string]string
+		args     []string
+		want     map[string]string
+		wantMeta map[string]string
+		wantErr  string
+	}{
+		{
+			name:     "новое значение поля",
+			typ:      payload.TypeLoginPass,
+			fields:   map[string]string{"login": "vasia", "password": "old"},
+			args:     []string{"--password=new"},
+			want:     map[string]string{"login": "vasia", "password": "new"},
+			wantMeta: map[string]string{"team": "backend"},
+		},
+		{
+			name:     "пустое значение удаляет поле",
+			typ:      payload.TypeCard,
+			fields:   map[string]string{"number": "4111111111111111", "expiry": "12/30", "holder": "VASILY"},
+			args:     []string{"--holder="},
+			want:     map[string]string{"number": "4111111111111111", "expiry": "12/30"},
+			wantMeta: map[string]string{"team": "backend"},
+		},
+		{
+			name:     "метаданные",
+			typ:      payload.TypeLoginPass,
+			fields:   map[string]string{"login": "vasia", "password": "secret"},
+			args:     []string{"--meta=url=https://github.com,env=prod", "--unset-meta=team"},
+			want:     map[string]string{"login": "vasia", "password": "secret"},
+			wantMeta: map[string]string{"url": "https://github.com", "env": "prod"},
+		},
+		{
+			name:     "поле из файла",
+			typ:      payload.TypeText,
+			fields:   map[string]string{"content": "старый текст"},
+			args:     []string{"--file=" + noteFile},
+			want:     map[string]string{"content": "из файла"},
+			wantMeta: map[string]string{"team": "backend"},
+		},
+		{
+			name:    "поля бинарной записи не меняются",
+			typ:     payload.TypeBinary,
+			fields:  map[string]string{"file": "report.pdf", "size": "42"},
+			args:    []string{"--file=other.pdf"},
+			wantErr: "нельзя изменить",
+		},
+		{
+			name:    "неизвестный тип",
+			typ:     "unknown",
+			wantErr: "неизвестный тип",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &editDocument{
+				ID:       "record",
+				Type:     tt.typ,
+				Fields:   tt.fields,
+				Metadata: map[string]string{"team": "backend"},
+			}
+			if doc.Fields == nil {
+				doc.Fields = make(map[string]string)
+			}
+
+			err := runEditFlags(t, doc, tt.args...)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, doc.Fields)
+			assert.Equal(t, tt.wantMeta, doc.Metadata)
+		})
+	}
+}
+
+func TestBuildEditedRecord(t *testing.T) {
+	login := testRecord(t, "github", payload.TypeLoginPass, map[string]string{"login": "vasia", "password": "old"})
+	card := testRecord(t, "visa", payload.TypeCard, map[string]string{"number": "4111111111111111", "expiry": "12/30", "holder": "VASILY"})
+	binary := testRecord(t, "report", payload.TypeBinary, map[string]string{"file": "report.pdf", "size": "42"})
+	binary.BlobSize = 58
+
+	tests := []struct {
+		name    string
+		record  *pb.DataRecord
+		fields  map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "изменённое поле",
+			record: login,
+			fields: map[string]string{"login": "vasia", "password": "new"},
+			want:   map[string]string{"login": "vasia", "password": "new"},
+		},
+		{
+			name:   "очищенное необязательное поле",
+			record: card,
+			fields: map[string]string{"number": "4111111111111111", "expiry": "12/30"},
+			want:   map[string]string{"number": "4111111111111111", "expiry": "12/30", "cvv": "", "holder": ""},
+		},
+		{
+			name:    "очищенное обязательное поле",
+			record:  login,
+			fields:  map[string]string{"login": "vasia"},
+			wantErr: "неверные данные",
+		},
+		{
+			name:    "неверное значение",
+			record:  card,
+			fields:  map[string]string{"number": "1234", "expiry": "12/30"},
+			wantErr: "неверные данные",
+		},
+		{
+			name:   "бинарная запись сохраняет описание содержимого",
+			record: binary,
+			fields: map[string]string{"file": "other.pdf", "size": "1"},
+			want:   map[string]string{"file": "report.pdf", "size": "42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &editDocument{
+				ID:       tt.record.Id,
+				Type:     tt.record.Type,
+				Fields:   tt.fields,
+				Metadata: map[string]string{"env": "prod"},
+				Folder:   "work",
+				Tags:     []string{"dev"},
+			}
+
+			updated, err := buildEditedRecord(tt.record, doc)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.record.Id, updated.Id)
+			assert.Equal(t, tt.record.BlobSize, updated.BlobSize)
+			assert.Equal(t, map[string]string{"env": "prod"}, updated.Metadata)
+			assert.Equal(t, "work", updated.Folder)
+			assert.Equal(t, []string{"dev"}, updated.Tags)
+			assert.Subset(t, recordValues(t, updated), tt.want)
+		})
+	}
+}
+
+// withEditor подменяет $EDITOR командой, которая записывает content в редактируемый файл
+func withEditor(t *testing.T, content string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "edited.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "cp "+path)
+}
+
+func editorJSON(t *testing.T, doc map[string]any) string {
+	t.Helper()
+
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestEditInEditor(t *testing.T) {
+	doc := &editDocument{
+		ID:       "github",
+		Type:     payload.TypeLoginPass,
+		Fields:   map[string]string{"login": "vasia", "password": "old"},
+		Metadata: map[string]string{"team": "backend"},
+	}
+
+	t.Run("изменения", func(t *testing.T) {
+		withEditor(t, editorJSON(t, map[string]any{
+			"id": "github", "type": payload.TypeLoginPass,
+			"fields":   map[string]string{"login": "vasia", "password": "new", "note": ""},
+			"metadata": map[string]string{"team": "", "env": "prod"},
+			"folder":   "/work//dev/",
+			"tags":     []string{"Dev", "dev", " "},
+		}))
+
+		edited, err := editInEditor(doc)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"login": "vasia", "password": "new"}, edited.Fields)
+		assert.Equal(t, map[string]string{"env": "prod"}, edited.Metadata)
+		assert.Equal(t, "work/dev", edited.Folder)
+		assert.Len(t, edited.Tags, 1)
+	})
+
+	errorCases := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "изменён id",
+			content: `{"id": "gitlab", "type": "loginpass", "fields": {"login": "vasia", "password": "old"}}`,
+			wantErr: "id и type",
+		},
+		{
+			name:    "изменён type",
+			content: `{"id": "github", "type": "text", "fields": {"content": "x"}}`,
+			wantErr: "id и type",
+		},
+		{
+			name:    "неизвестное поле",
+			content: `{"id": "github", "type": "loginpass", "fields": {}, "revision": 5}`,
+			wantErr: "неверный формат",
+		},
+		{
+			name:    "не JSON",
+			content: `login: vasia`,
+			wantErr: "неверный формат",
+		},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			withEditor(t, tt.content)
+			_, err := editInEditor(doc)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("ID с путём", func(t *testing.T) {
+		// временный каталог создаётся в runtime/a, запись не должна выйти из него
+		runtime := filepath.Join(t.TempDir(), "a")
+		require.NoError(t, os.MkdirAll(runtime, 0700))
+		t.Setenv("XDG_RUNTIME_DIR", runtime)
+
+		hostile := &editDocument{ID: "../gophkeeper-leak", Type: payload.TypeText, Fields: map[string]string{"content": "secret"}}
+		withEditor(t, `{"id": "../gophkeeper-leak", "type": "text", "fields": {"content": "new"}}`)
+
+		edited, err := editInEditor(hostile)
+		require.NoError(t, err)
+		assert.Equal(t, "new", edited.Fields["content"])
+		assert.NoFileExists(t, filepath.Join(runtime, "gophkeeper-leak.json"))
+
+		hostile.ID = "team/db"
+		withEditor(t, `{"id": "team/db", "type": "text", "fields": {"content": "new"}}`)
+		_, err = editInEditor(hostile)
+		require.NoError(t, err)
+	})
+
+	t.Run("поля бинарной записи", func(t *testing.T) {
+		binary := &editDocument{ID: "report", Type: payload.TypeBinary, Fields: map[string]string{"file": "report.pdf", "size": "42"}}
+		withEditor(t, `{"id": "report", "type": "binary", "fields": {"file": "other.pdf", "size": "42"}}`)
+		_, err := editInEditor(binary)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "бинарной записи")
+	})
+
+	t.Run("редактор завершился с ошибкой", func(t *testing.T) {
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", "false")
+		_, err := editInEditor(doc)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "редактор")
+	})
+
+	t.Run("редактор не задан", func(t *testing.T) {
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", "")
+		_, err := editInEditor(doc)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "EDITOR")
+	})
+}
+
+// fakeSyncer применяет к локальному хранилищу заранее заданный ответ сервера
+type fakeSyncer struct {
+	vault *vault.Vault
+	resp  *pb.SyncResponse
+}
+
+func (f *fakeSyncer) DoWithRetry(fn func() error) error { return fn() }
+
+func (f *fakeSyncer) Sync() (*client.SyncResult, error) {
+	f.vault.Apply(f.resp)
+	return &client.SyncResult{SyncResponse: f.resp}, nil
+}
+
+func (f *fakeSyncer) Vault() *vault.Vault { return f.vault }
+
+func TestSaveEdit(t *testing.T) {
+	newSyncer := func(t *testing.T, resp *pb.SyncResponse) *fakeSyncer {
+		v, err := vault.Open(filepath.Join(t.TempDir(), "test.vault"), []byte("this-is-32-byte-key-for-aes-256!"))
+		require.NoError(t, err)
+		v.Put(testRecord(t, "github", payload.TypeLoginPass, map[string]string{"login": "vasia", "password": "new"}))
+		return &fakeSyncer{vault: v, resp: resp}
+	}
+
+	t.Run("принято сервером", func(t *testing.T) {
+		s := newSyncer(t, &pb.SyncResponse{})
+		require.NoError(t, saveEdit(s, "github"))
+		assert.False(t, s.vault.HasPending())
+	})
+
+	t.Run("конфликт", func(t *testing.T) {
+		s := newSyncer(t, &pb.SyncResponse{
+			Conflicts: []*pb.SyncConflict{{Id: "github", BaseRevision: 0, ServerRevision: 3}},
+		})
+		err := saveEdit(s, "github")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "изменена на сервере")
+
+		// локальная версия не потеряна: она сохранена в копии и ждёт отправки
+		local, err := s.vault.Get("github" + vault.ConflictSuffix)
+		require.NoError(t, err)
+		assert.Equal(t, "new", recordValues(t, local)["password"])
+		assert.True(t, s.vault.HasPending())
+	})
+
+	t.Run("временный сбой на сервере", func(t *testing.T) {
+		s := newSyncer(t, &pb.SyncResponse{
+			Rejected: []*pb.SyncRejected{{Id: "github", Reason: "failed to save record", Retryable: true}},
+		})
+		err := saveEdit(s, "github")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "не сохранил")
+		assert.True(t, s.vault.HasPending())
+	})
+
+	t.Run("отклонено сервером", func(t *testing.T) {
+		s := newSyncer(t, &pb.SyncResponse{
+			Rejected: []*pb.SyncRejected{{Id: "github", Reason: "record ID is not available"}},
+		})
+		err := saveEdit(s, "github")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "отклонил")
+		assert.False(t, s.vault.HasPending())
+		assert.Contains(t, s.vault.Rejected(), "github")
+	})
+}
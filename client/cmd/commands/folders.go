@@ -0,0 +1,311 @@
+package commands
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dvkhr/gophkeeper/client/internal/catalog"
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// NewMkdirCommand создаёт команду mkdir
+func NewMkdirCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:      "mkdir",
+		Usage:     "Создать папку для записей",
+		ArgsUsage: "<папка>",
+		Action: func(cCtx *cli.Context) error {
+			folder, err := catalog.CleanPath(cCtx.Args().First())
+			if err != nil {
+				return err
+			}
+			if folder == "" {
+				return fmt.Errorf("укажите папку, например mkdir team/prod")
+			}
+
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if _, err := syncVault(client); err != nil {
+				return err
+			}
+
+			if slices.Contains(catalog.Folders(client.Vault().List()), folder) {
+				fmt.Printf("Папка %s уже существует\n", folder)
+				return nil
+			}
+
+			record, err := client.FolderRecord(folder)
+			if err != nil {
+				return err
+			}
+			if err := client.PutLocal(record); err != nil {
+				return err
+			}
+
+			online, err := syncVault(client)
+			if err != nil {
+				return err
+			}
+			if online {
+				fmt.Printf("Папка создана: %s\n", folder)
+			} else {
+				fmt.Printf("Папка создана локально: %s — будет отправлена при следующей синхронизации\n", folder)
+			}
+			return nil
+		},
+	}
+}
+
+// NewMvCommand создаёт команду mv
+func NewMvCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "mv",
+		Usage: "Переместить записи или папку в другую папку",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{Name: "id", Usage: "ID перемещаемой записи; можно указать несколько"},
+			&cli.StringFlag{Name: "folder", Usage: "Перемещаемая папка вместе с вложенными"},
+			&cli.StringFlag{
+				Name:     "to",
+				Required: true,
+				Usage:    "Папка назначения (/ — корень); существующая папка --folder переносится внутрь неё, иначе переименовывается",
+			},
+		},
+		Action: func(cCtx *cli.Context) error {
+			ids, from := cCtx.StringSlice("id"), cCtx.String("folder")
+			if (len(ids) == 0) == (from == "") {
+				return fmt.Errorf("укажите либо --id, либо --folder")
+			}
+			to, err := catalog.CleanPath(cCtx.String("to"))
+			if err != nil {
+				return err
+			}
+
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if _, err := syncVault(client); err != nil {
+				return err
+			}
+
+			var changed bool
+			if len(ids) > 0 {
+				changed, err = moveRecords(client, ids, to)
+			} else {
+				changed, err = moveFolder(client, from, to)
+			}
+			if err != nil {
+				return err
+			}
+			if !changed {
+				fmt.Println("Изменений нет")
+				return nil
+			}
+
+			online, err := syncVault(client)
+			if err != nil {
+				return err
+			}
+			if !online {
+				fmt.Println("Изменения будут отправлены на сервер при следующей синхронизации")
+			}
+			return nil
+		},
+	}
+}
+
+// moveRecords переносит записи ids в папку to. Сообщает, перенесена ли хотя бы одна запись.
+func moveRecords(c *client.Client, ids []string, to string) (bool, error) {
+	var records []*pb.DataRecord
+	for _, id := range ids {
+		record, err := c.Vault().Get(id)
+		if err != nil {
+			return false, fmt.Errorf("запись с ID %s не найдена", id)
+		}
+		if catalog.IsFolder(record) {
+			return false, fmt.Errorf("%s — запись папки, перемещайте папку через --folder", id)
+		}
+		records = append(records, record)
+	}
+
+	moved := 0
+	for _, record := range records {
+		if record.Folder == to {
+			continue
+		}
+		updated := proto.Clone(record).(*pb.DataRecord)
+		updated.Folder = to
+		if err := c.PutLocal(updated); err != nil {
+			return false, err
+		}
+		moved++
+	}
+
+	if moved > 0 {
+		fmt.Printf("Перемещено записей в %s: %d\n", catalog.Separator+to, moved)
+	}
+	return moved > 0, nil
+}
+
+// moveFolder переносит папку from со всеми записями и вложенными папками.
+// Если папка to уже существует, from переносится внутрь неё, иначе from переименовывается в to.
+// Записи пустых папок пересоздаются: их ID зависит от пути.
+func moveFolder(c *client.Client, from, to string) (bool, error) {
+	from, err := catalog.CleanPath(from)
+	if err != nil {
+		return false, err
+	}
+	if from == "" {
+		return false, fmt.Errorf("корневую папку перемещать нельзя, укажите записи через --id")
+	}
+
+	records := c.Vault().List()
+	folders := catalog.Folders(records)
+	if !slices.Contains(folders, from) {
+		return false, fmt.Errorf("папка %s не найдена", from)
+	}
+	if to == "" || slices.Contains(folders, to) {
+		to = strings.TrimPrefix(to+catalog.Separator+path.Base(from), catalog.Separator)
+	}
+	if to == from {
+		return false, nil
+	}
+	if catalog.Within(to, from) {
+		return false, fmt.Errorf("нельзя переместить папку %s в саму себя", from)
+	}
+	if slices.Contains(folders, to) {
+		return false, fmt.Errorf("папка %s уже существует", to)
+	}
+
+	moved := 0
+	for _, record := range records {
+		if record.Folder == "" || !catalog.Within(record.Folder, from) {
+			continue
+		}
+		folder := catalog.Rebase(record.Folder, from, to)
+
+		if catalog.IsFolder(record) {
+			if err := c.DeleteLocal(record.Id); err != nil {
+				return false, err
+			}
+			folderRecord, err := c.FolderRecord(folder)
+			if err != nil {
+				return false, err
+			}
+			if err := c.PutLocal(folderRecord); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		updated := proto.Clone(record).(*pb.DataRecord)
+		updated.Folder = folder
+		if err := c.PutLocal(updated); err != nil {
+			return false, err
+		}
+		moved++
+	}
+
+	fmt.Printf("Папка %s перемещена в %s, записей: %d\n", from, to, moved)
+	return true, nil
+}
+
+// NewTagCommand создаёт команду tag
+func NewTagCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "tag",
+		Usage: "Добавить или снять метки записи; без --id — показать все метки",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "id", Usage: "ID записи"},
+			&cli.StringSliceFlag{Name: "add", Aliases: []string{"a"}, Usage: "Добавить метку; можно указать несколько"},
+			&cli.StringSliceFlag{Name: "remove", Aliases: []string{"r"}, Usage: "Снять метку; можно указать несколько"},
+		},
+		Action: func(cCtx *cli.Context) error {
+			id, add, remove := cCtx.String("id"), cCtx.StringSlice("add"), cCtx.StringSlice("remove")
+			if id == "" && (len(add) > 0 || len(remove) > 0) {
+				return fmt.Errorf("укажите --id записи")
+			}
+			if id != "" && len(add) == 0 && len(remove) == 0 {
+				return fmt.Errorf("укажите --add или --remove")
+			}
+
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if _, err := syncVault(client); err != nil {
+				return err
+			}
+
+			if id == "" {
+				printTags(client.Vault().List())
+				return nil
+			}
+
+			record, err := client.Vault().Get(id)
+			if err != nil || catalog.IsFolder(record) {
+				return fmt.Errorf("запись с ID %s не найдена", id)
+			}
+
+			tags := retag(record.Tags, add, remove)
+			if slices.Equal(tags, record.Tags) {
+				fmt.Println("Изменений нет")
+				return nil
+			}
+
+			updated := proto.Clone(record).(*pb.DataRecord)
+			updated.Tags = tags
+			if err := client.PutLocal(updated); err != nil {
+				return err
+			}
+			return saveEdit(client, id)
+		},
+	}
+}
+
+// retag добавляет к меткам tags метки add и снимает метки remove (без учёта регистра).
+func retag(tags, add, remove []string) []string {
+	updated := catalog.CleanTags(append(slices.Clone(tags), add...))
+	return slices.DeleteFunc(updated, func(tag string) bool {
+		return slices.ContainsFunc(remove, func(r string) bool {
+			return strings.EqualFold(strings.TrimSpace(r), tag)
+		})
+	})
+}
+
+// printTags выводит метки записей с числом записей у каждой.
+func printTags(records []*pb.DataRecord) {
+	counts := make(map[string]int)
+	for _, record := range records {
+		for _, tag := range record.Tags {
+			counts[tag]++
+		}
+	}
+	if len(counts) == 0 {
+		fmt.Println("Меток нет")
+		return
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		fmt.Printf("%s (%d)\n", tag, counts[tag])
+	}
+}
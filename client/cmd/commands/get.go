@@ -1,12 +1,18 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
+	"github.com/dvkhr/gophkeeper/client/internal/catalog"
 	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/client/internal/search"
+	"github.com/dvkhr/gophkeeper/client/storage/vault"
 	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
 	"github.com/urfave/cli/v2"
 )
 
@@ -25,6 +31,31 @@ func NewGetCommand(factory *client.Factory) *cli.Command {
 				Aliases: []string{"o"},
 				Usage:   "Путь для сохранения данных (для бинарных данных)",
 			},
+			&cli.StringFlag{
+				Name:  "find",
+				Usage: "Только записи с метаданными ключ=значение или с ключом метаданных",
+			},
+			&cli.StringSliceFlag{
+				Name:    "type",
+				Aliases: []string{"t"},
+				Usage:   "Только записи указанных типов",
+			},
+			&cli.StringFlag{
+				Name:  "search",
+				Usage: "Только записи, содержащие текст в ID, метаданных или несекретных полях",
+			},
+			&cli.StringFlag{
+				Name:  "folder",
+				Usage: "Только записи папки и вложенных в неё папок",
+			},
+			&cli.StringSliceFlag{
+				Name:  "tag",
+				Usage: "Только записи с меткой; можно указать несколько",
+			},
+			&cli.BoolFlag{
+				Name:  "tree",
+				Usage: "Вывести записи деревом папок",
+			},
 		},
 		Action: func(cCtx *cli.Context) error {
 			client, err := factory.NewAuthenticatedClient()
@@ -33,44 +64,59 @@ func NewGetCommand(factory *client.Factory) *cli.Command {
 			}
 			defer client.Close()
 
-			resp, err := client.GetData()
+			// для одной конкретной записи: запрашивается только она, без синхронизации всего хранилища
+			if id := cCtx.String("id"); id != "" {
+				record, err := fetchRecord(client, id)
+				if err != nil {
+					return err
+				}
+				records, err := filterGetRecords(cCtx, client, []*pb.DataRecord{record}, false)
+				if err != nil {
+					return err
+				}
+				if len(records) == 0 {
+					return fmt.Errorf("запись с ID %s не найдена", id)
+				}
+				return printSingleRecord(cCtx, client, record)
+			}
+
+			online, err := syncVault(client)
 			if err != nil {
 				return err
 			}
 
-			// для одной конкретной записи
-			if cCtx.String("id") != "" {
-				return printSingleRecord(cCtx, resp.Records)
+			all := client.Vault().List()
+			records, err := filterGetRecords(cCtx, client, all, online)
+			if err != nil {
+				return err
 			}
+			if cCtx.Bool("tree") {
+				return printTree(cCtx.String("folder"), all, records)
+			}
+			records = slices.DeleteFunc(records, catalog.IsFolder)
 
 			// Вывод всех записей
-			if len(resp.Records) == 0 {
+			if len(records) == 0 {
 				fmt.Println("Нет сохранённых данных")
 				return nil
 			}
 
-			fmt.Printf("\n Найдено записей: %d\n", len(resp.Records))
+			fmt.Printf("\n Найдено записей: %d\n", len(records))
 			fmt.Println(strings.Repeat("─", 80))
 
-			for i, record := range resp.Records {
+			for i, record := range records {
 				if i > 0 {
 					fmt.Println(strings.Repeat("─", 80))
 				}
 				fmt.Printf("ID:       %s\n", record.Id)
 				fmt.Printf("Тип:      %s\n", record.Type)
 
-				if cCtx.String("output") != "" || record.Type == "binary" {
-					fmt.Printf("Данные:   (%d байт, тип %s) — используйте --output для сохранения\n", len(record.EncryptedData), record.Type)
+				if cCtx.String("output") != "" {
+					fmt.Printf("Данные:   (%d байт, тип %s) — используйте --output для сохранения\n", recordSize(record), record.Type)
 				} else {
-					fmt.Printf("Данные:   %s\n", record.EncryptedData)
-				}
-
-				if len(record.Metadata) > 0 {
-					fmt.Println("Метаданные:")
-					for k, v := range record.Metadata {
-						fmt.Printf("  %s: %s\n", k, v)
-					}
+					printPayload(record)
 				}
+				printAttributes(record)
 			}
 
 			fmt.Println(strings.Repeat("─", 80))
@@ -80,43 +126,248 @@ func NewGetCommand(factory *client.Factory) *cli.Command {
 }
 
 // printSingleRecord выводит одну запись, можно в файл для бинарных данных
-func printSingleRecord(cCtx *cli.Context, records []*pb.DataRecord) error {
-	var record *pb.DataRecord
-	for _, r := range records {
-		if r.Id == cCtx.String("id") {
-			record = r
-			break
-		}
-	}
-
-	if record == nil {
-		return fmt.Errorf("запись с ID %s не найдена", cCtx.String("id"))
-	}
-
+func printSingleRecord(cCtx *cli.Context, c *client.Client, record *pb.DataRecord) error {
 	outputPath := cCtx.String("output")
+	if outputPath != "" && record.BlobSize > 0 {
+		return downloadBinary(c, record.Id, outputPath)
+	}
 	if outputPath != "" {
-		err := os.WriteFile(outputPath, record.EncryptedData, 0600)
+		data := outputData(record)
+		err := os.WriteFile(outputPath, data, 0600)
 		if err != nil {
 			return fmt.Errorf("не удалось сохранить файл: %w", err)
 		}
-		fmt.Printf("Файл сохранён: %s (%d байт)\n", outputPath, len(record.EncryptedData))
+		fmt.Printf("Файл сохранён: %s (%d байт)\n", outputPath, len(data))
 		return nil
 	}
 
-	// Вывод в терминал (только для текстовых типов)
-	if record.Type == "text" || record.Type == "loginpass" || record.Type == "card" {
+	// Вывод в терминал (кроме бинарных данных)
+	if record.Type != payload.TypeBinary {
 		fmt.Printf("ID:       %s\n", record.Id)
 		fmt.Printf("Тип:      %s\n", record.Type)
+		printPayload(record)
+		printAttributes(record)
+	} else {
+		fmt.Printf("Тип: %s, размер: %d байт. Используйте --output для сохранения.\n", record.Type, recordSize(record))
+	}
+
+	return nil
+}
+
+// printPayload выводит содержимое записи по полям.
+// Содержимое, которое не удалось разобрать, выводится как есть.
+func printPayload(record *pb.DataRecord) {
+	if record.BlobSize > 0 && len(record.EncryptedData) == 0 {
+		// Запись загружена до появления описания содержимого
+		fmt.Printf("Данные:   (%d байт, тип %s) — используйте --output для сохранения\n", recordSize(record), record.Type)
+		return
+	}
+
+	p, err := payload.Unmarshal(record.Type, record.EncryptedData)
+	if err != nil {
 		fmt.Printf("Данные:   %s\n", record.EncryptedData)
-		if len(record.Metadata) > 0 {
-			fmt.Println("Метаданные:")
-			for k, v := range record.Metadata {
-				fmt.Printf("  %s: %s\n", k, v)
+		return
+	}
+
+	for _, field := range payload.Fields(p) {
+		fmt.Printf("%-10s%s\n", field.Name+":", field.Value)
+	}
+	if record.Type == payload.TypeBinary {
+		fmt.Println("Данные:   используйте --output для сохранения")
+	}
+}
+
+// outputData возвращает данные записи для сохранения в файл:
+// текст для text, содержимое старых бинарных записей как есть, для остальных — поля по строкам.
+func outputData(record *pb.DataRecord) []byte {
+	if payload.IsLegacy(record.EncryptedData) && record.Type == payload.TypeBinary {
+		return record.EncryptedData
+	}
+
+	p, err := payload.Unmarshal(record.Type, record.EncryptedData)
+	if err != nil {
+		return record.EncryptedData
+	}
+	if text := p.GetText(); text != nil {
+		return []byte(text.Content)
+	}
+
+	var buf strings.Builder
+	for _, field := range payload.Fields(p) {
+		fmt.Fprintf(&buf, "%s: %s\n", field.Name, field.Value)
+	}
+	return []byte(buf.String())
+}
+
+// filterGetRecords применяет к записям фильтры --find, --type и --search команды get.
+// online — доступен ли сервер для поиска по слепым индексам.
+func filterGetRecords(cCtx *cli.Context, c *client.Client, records []*pb.DataRecord, online bool) ([]*pb.DataRecord, error) {
+	if query := cCtx.String("find"); query != "" {
+		var err error
+		records, err = findRecords(c, query, records, online)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	folder, err := catalog.CleanPath(cCtx.String("folder"))
+	if err != nil {
+		return nil, err
+	}
+
+	q := search.Query{
+		Text:   cCtx.String("search"),
+		Types:  cCtx.StringSlice("type"),
+		Folder: folder,
+		Tags:   cCtx.StringSlice("tag"),
+	}
+	if q.Text != "" || len(q.Types) > 0 || q.Folder != "" || len(q.Tags) > 0 {
+		records = filterRecords(records, q)
+	}
+	return records, nil
+}
+
+// printAttributes выводит папку, метки и метаданные записи.
+func printAttributes(record *pb.DataRecord) {
+	if record.Folder != "" {
+		fmt.Printf("Папка:    %s\n", record.Folder)
+	}
+	if len(record.Tags) > 0 {
+		fmt.Printf("Метки:    %s\n", strings.Join(record.Tags, ", "))
+	}
+	if len(record.Metadata) > 0 {
+		fmt.Println("Метаданные:")
+		for k, v := range record.Metadata {
+			fmt.Printf("  %s: %s\n", k, v)
+		}
+	}
+}
+
+// printTree выводит записи records деревом папок, начиная с папки folder.
+// Папки берутся из всех записей all, чтобы в дереве были и пустые папки.
+func printTree(folder string, all, records []*pb.DataRecord) error {
+	folder, err := catalog.CleanPath(folder)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range all {
+		if catalog.IsFolder(record) {
+			records = append(records, record)
+		}
+	}
+
+	node := catalog.Tree(records).Subtree(folder)
+	if node == nil {
+		return fmt.Errorf("папка %s не найдена", folder)
+	}
+
+	catalog.Print(os.Stdout, node, func(record *pb.DataRecord) string {
+		label := fmt.Sprintf("%s [%s]", record.Id, record.Type)
+		for _, tag := range record.Tags {
+			label += " #" + tag
+		}
+		return label
+	})
+	return nil
+}
+
+// fetchRecord получает и расшифровывает с сервера одну запись id.
+// Без сети запись берётся из локального хранилища.
+func fetchRecord(c *client.Client, id string) (*pb.DataRecord, error) {
+	var record *pb.DataRecord
+	err := c.DoWithRetry(func() (err error) {
+		record, err = c.GetRecord(id)
+		return err
+	})
+	if client.IsOffline(err) {
+		fmt.Println("Сервер недоступен — используется локальное хранилище")
+		record, err = c.Vault().Get(id)
+	}
+	if errors.Is(err, client.ErrRecordNotFound) || errors.Is(err, vault.ErrNotFound) {
+		return nil, fmt.Errorf("запись с ID %s не найдена", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// findRecords отбирает записи, у которых метаданные key равны value (запрос вида key=value)
+// или в метаданных которых есть ключ key (запрос вида key).
+// Если по ключу разрешён поиск на сервере, записи ищет сервер по слепому индексу,
+// иначе и без сети — поиск идёт по локальному хранилищу.
+func findRecords(c *client.Client, query string, local []*pb.DataRecord, online bool) ([]*pb.DataRecord, error) {
+	key, value, withValue := strings.Cut(query, "=")
+	if key == "" {
+		return nil, fmt.Errorf("неверный формат --find: ожидается ключ=значение или ключ")
+	}
+
+	if online && c.Searchable(key) {
+		var resp *pb.DataResponse
+		err := c.DoWithRetry(func() (err error) {
+			if withValue {
+				resp, err = c.FindData(key, value)
+			} else {
+				resp, err = c.FindDataWithKey(key)
 			}
+			return err
+		})
+		if err == nil {
+			return resp.Records, nil
 		}
-	} else {
-		fmt.Printf("Тип: %s, размер: %d байт. Используйте --output для сохранения.\n", record.Type, len(record.EncryptedData))
+		if !client.IsOffline(err) {
+			return nil, err
+		}
+	}
+
+	var found []*pb.DataRecord
+	for _, record := range local {
+		if v, ok := record.Metadata[key]; ok && (!withValue || v == value) {
+			found = append(found, record)
+		}
+	}
+	return found, nil
+}
+
+// filterRecords оставляет записи, подходящие под запрос q, в порядке релевантности.
+func filterRecords(records []*pb.DataRecord, q search.Query) []*pb.DataRecord {
+	results := search.Search(records, q)
+	filtered := make([]*pb.DataRecord, 0, len(results))
+	for _, result := range results {
+		filtered = append(filtered, result.Record)
+	}
+	return filtered
+}
+
+// downloadBinary скачивает содержимое бинарной записи в файл outputPath.
+func downloadBinary(c *client.Client, id, outputPath string) error {
+	f, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл: %w", err)
+	}
+	defer f.Close()
+
+	var written int64
+	err = c.DoWithRetry(func() error {
+		written, err = c.DownloadBinary(id, f)
+		return err
+	})
+	if err != nil {
+		// Не оставляем частично расшифрованный файл
+		f.Close()
+		os.Remove(outputPath)
+		return err
 	}
 
+	fmt.Printf("Файл сохранён: %s (%d байт)\n", outputPath, written)
 	return nil
 }
+
+// recordSize возвращает размер данных записи: загруженного содержимого или поля EncryptedData.
+func recordSize(record *pb.DataRecord) int64 {
+	if record.BlobSize > 0 {
+		return record.BlobSize
+	}
+	return int64(len(record.EncryptedData))
+}
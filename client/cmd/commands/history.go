@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewHistoryCommand создаёт команду history
+func NewHistoryCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "Показать прежние версии записи",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "id", Required: true},
+			&cli.BoolFlag{Name: "show", Usage: "Вывести содержимое версий"},
+		},
+		Action: func(cCtx *cli.Context) error {
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			id := cCtx.String("id")
+			var versions []*pb.RecordVersion
+			err = client.DoWithRetry(func() (err error) {
+				versions, err = client.ListVersions(id)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(versions) == 0 {
+				fmt.Printf("У записи %s нет сохранённых версий\n", id)
+				return nil
+			}
+
+			fmt.Printf("Версии записи %s: %d\n", id, len(versions))
+			fmt.Println(strings.Repeat("─", 80))
+			for _, version := range versions {
+				record := version.Record
+				fmt.Printf("Версия %d: сохранена %s, заменена %s\n", record.Revision,
+					formatUnix(record.Timestamp), formatUnix(version.ReplacedAt))
+				if cCtx.Bool("show") {
+					printPayload(record)
+					fmt.Println(strings.Repeat("─", 80))
+				}
+			}
+			fmt.Printf("Восстановить версию: restore --id=%s --version=<номер>\n", id)
+			return nil
+		},
+	}
+}
+
+// NewRestoreCommand создаёт команду restore
+func NewRestoreCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "restore",
+		Usage: "Восстановить прежнюю версию записи",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "id", Required: true},
+			&cli.Int64Flag{Name: "version", Required: true, Usage: "Номер версии из команды history"},
+		},
+		Action: func(cCtx *cli.Context) error {
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			online, err := syncVault(client)
+			if err != nil {
+				return err
+			}
+			if !online {
+				return fmt.Errorf("для восстановления версии нужно подключение к серверу")
+			}
+
+			id, version := cCtx.String("id"), cCtx.Int64("version")
+			if client.Vault().HasPending() {
+				return fmt.Errorf("есть неотправленные изменения, выполните sync и повторите")
+			}
+
+			// Удалённую запись можно восстановить без проверки ревизии: локальной копии уже нет
+			var base int64
+			if record, err := client.Vault().Get(id); err == nil {
+				base = record.Revision
+			}
+
+			var revision int64
+			err = client.DoWithRetry(func() (err error) {
+				revision, err = client.RestoreVersion(id, version, base)
+				return err
+			})
+			switch status.Code(err) {
+			case codes.OK:
+			case codes.Aborted:
+				return fmt.Errorf("запись %s изменена на сервере, выполните sync и повторите", id)
+			case codes.NotFound:
+				return fmt.Errorf("у записи %s нет версии %d, список версий — команда history", id, version)
+			case codes.FailedPrecondition:
+				return fmt.Errorf("содержимое файла версии %d уже заменено новым и не хранится", version)
+			default:
+				return err
+			}
+
+			if _, err := syncVault(client); err != nil {
+				return err
+			}
+
+			fmt.Printf("Версия %d записи %s восстановлена (ревизия %d)\n", version, id, revision)
+			return nil
+		},
+	}
+}
+
+// formatUnix форматирует время Unix для вывода
+func formatUnix(ts int64) string {
+	if ts == 0 {
+		return "—"
+	}
+	return time.Unix(ts, 0).Format(time.DateTime)
+}
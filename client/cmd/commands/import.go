@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/client/internal/importer"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/urfave/cli/v2"
+)
+
+// defaultImportBatch — записей в одном вызове SyncData при импорте
+const defaultImportBatch = 100
+
+// NewImportCommand создаёт команду import
+func NewImportCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "import",
+		Usage: "Импортировать записи из экспорта Bitwarden, KeePass или 1Password",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "format",
+				Required: true,
+				Usage:    "Формат экспорта: " + strings.Join(importer.Formats(), ", "),
+			},
+			&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Required: true, Usage: "Файл экспорта"},
+			&cli.StringFlag{Name: "folder", Usage: "Папка, в которую поместить импортированные записи"},
+			&cli.BoolFlag{Name: "rename", Usage: "Сохранять записи с уже занятыми ID под новым ID вместо пропуска"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "Только показать, что будет импортировано"},
+			&cli.IntFlag{Name: "batch", Value: defaultImportBatch, Usage: "Записей в одной порции отправки на сервер"},
+		},
+		Action: func(cCtx *cli.Context) error {
+			if cCtx.Int("batch") <= 0 {
+				return fmt.Errorf("--batch должен быть положительным")
+			}
+
+			res, err := parseExport(cCtx.String("format"), cCtx.String("file"))
+			if err != nil {
+				return err
+			}
+
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			online, err := syncVault(client)
+			if err != nil {
+				return err
+			}
+
+			exists := func(id string) bool {
+				_, err := client.Vault().Get(id)
+				return err == nil
+			}
+			plan, err := importer.NewPlan(res, exists, importer.Options{
+				Folder: cCtx.String("folder"),
+				Rename: cCtx.Bool("rename"),
+			})
+			if err != nil {
+				return err
+			}
+
+			dryRun := cCtx.Bool("dry-run")
+			printImportPlan(plan, dryRun)
+			if dryRun {
+				fmt.Println("Пробный запуск: записи не сохранены")
+				return nil
+			}
+			if len(plan.Records) == 0 {
+				fmt.Println("Нечего импортировать")
+				return nil
+			}
+
+			return uploadImport(client, plan.Records, cCtx.Int("batch"), online)
+		},
+	}
+}
+
+// parseExport читает и разбирает файл экспорта
+func parseExport(format, path string) (*importer.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл экспорта: %w", err)
+	}
+	defer f.Close()
+
+	return importer.Parse(format, f)
+}
+
+// uploadImport отправляет импортированные записи на сервер порциями по batch записей.
+// Если сервер недоступен, записи сохраняются в локальное хранилище и уходят при следующей синхронизации.
+func uploadImport(c *client.Client, records []*pb.DataRecord, batch int, online bool) error {
+	sent := 0
+	if online {
+		var err error
+		sent, err = c.PutBatches(records, batch, func(sent int) {
+			fmt.Printf("Отправлено записей: %d из %d\n", sent, len(records))
+		})
+		if err == nil {
+			fmt.Printf("Импортировано записей: %d\n", sent)
+			return nil
+		}
+		if !client.IsOffline(err) {
+			return fmt.Errorf("импорт прерван после %d записей из %d: %w", sent, len(records), err)
+		}
+		fmt.Println("Сервер недоступен — используется локальное хранилище")
+	}
+
+	for _, record := range records[sent:] {
+		c.Vault().Put(record)
+	}
+	if err := c.Vault().Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Импортировано записей: %d, из них %d сохранены локально — будут отправлены при следующей синхронизации\n",
+		len(records), len(records)-sent)
+	return nil
+}
+
+// printImportPlan выводит сводку импорта: число записей по типам, пропущенные записи и причины.
+// При пробном запуске выводится и список записей — без содержимого
+func printImportPlan(plan *importer.Plan, verbose bool) {
+	counts := make(map[string]int)
+	for _, record := range plan.Records {
+		counts[record.Type]++
+	}
+	types := make([]string, 0, len(counts))
+	for t, n := range counts {
+		types = append(types, fmt.Sprintf("%s: %d", t, n))
+	}
+	sort.Strings(types)
+
+	if len(types) > 0 {
+		fmt.Printf("Записей к импорту: %d (%s)\n", len(plan.Records), strings.Join(types, ", "))
+	} else {
+		fmt.Println("Записей к импорту: 0")
+	}
+
+	if verbose {
+		for _, record := range plan.Records {
+			fmt.Printf("  + %s (%s)", record.Id, record.Type)
+			if record.Folder != "" {
+				fmt.Printf(", папка %s", record.Folder)
+			}
+			if len(record.Tags) > 0 {
+				fmt.Printf(", метки %s", strings.Join(record.Tags, ", "))
+			}
+			fmt.Println()
+		}
+	}
+
+	if len(plan.Duplicates) > 0 {
+		fmt.Printf("Уже есть в хранилище, пропущено: %d (--rename — сохранить под новым ID)\n", len(plan.Duplicates))
+		printSkipped(plan.Duplicates)
+	}
+	if len(plan.Skipped) > 0 {
+		fmt.Printf("Не импортируется: %d\n", len(plan.Skipped))
+		printSkipped(plan.Skipped)
+	}
+	if len(plan.Secrets) > 0 {
+		fmt.Printf("Скрытые поля перенесены в зашифрованные заметки: %d\n", len(plan.Secrets))
+		printSkipped(plan.Secrets)
+	}
+}
+
+// printSkipped выводит пропущенные записи экспорта с причинами
+func printSkipped(skipped []importer.Skipped) {
+	for _, s := range skipped {
+		name := s.Name
+		if name == "" {
+			name = "(без названия)"
+		}
+		fmt.Printf("  - %s: %s\n", name, s.Reason)
+	}
+}
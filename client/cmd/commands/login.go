@@ -5,6 +5,7 @@ import (
 
 	"github.com/dvkhr/gophkeeper/client/internal/client"
 	"github.com/dvkhr/gophkeeper/client/storage/file"
+	"github.com/dvkhr/gophkeeper/client/storage/vault"
 	"github.com/dvkhr/gophkeeper/pkg/crypto"
 	"github.com/dvkhr/gophkeeper/pkg/logger"
 	"github.com/urfave/cli/v2"
@@ -24,13 +25,13 @@ func NewLoginCommand(serverAddress string) *cli.Command {
 			password := cCtx.String("password")
 
 			tempKey := crypto.DeriveKey(password, []byte("temp-salt"))
-			client, err := client.NewClient(serverAddress, tempKey)
+			c, err := client.NewClient(serverAddress, tempKey)
 			if err != nil {
 				return err
 			}
-			defer client.Close()
+			defer c.Close()
 
-			resp, err := client.Login(login, []byte(password))
+			resp, err := c.Login(login, []byte(password))
 			if err != nil {
 				return err
 			}
@@ -42,6 +43,28 @@ func NewLoginCommand(serverAddress string) *cli.Command {
 
 			session.AccessToken = resp.AccessToken
 			session.RefreshToken = resp.RefreshToken
+			session.UserID = resp.UserId
+
+			// Ключ хранилища с сервера позволяет работать с данными, созданными на другом устройстве
+			vaultKey, err := c.GetVaultKey()
+			if err != nil {
+				return fmt.Errorf("не удалось получить ключ хранилища: %w", err)
+			}
+			if vaultKey != nil {
+				kek, err := client.OpenVaultKey(password, vaultKey)
+				if err != nil {
+					return err
+				}
+				client.ApplyVaultKey(session, vaultKey, kek)
+
+				key, err := crypto.UnwrapKey(kek, vaultKey.WrappedKey)
+				if err != nil {
+					return fmt.Errorf("не удалось расшифровать ключ хранилища: %w", err)
+				}
+				if err := reclaimVault(resp.UserId, key); err != nil {
+					return err
+				}
+			}
 
 			if err := file.Save(session); err != nil {
 				return fmt.Errorf("не удалось сохранить сессию: %w", err)
@@ -53,3 +76,17 @@ func NewLoginCommand(serverAddress string) *cli.Command {
 		},
 	}
 }
+
+// reclaimVault откладывает локальное хранилище пользователя, если оно зашифровано другим ключом,
+// чтобы команды не завершались ошибкой расшифровки; данные будут заново загружены с сервера.
+func reclaimVault(userID string, key []byte) error {
+	stale, err := vault.Reclaim(vault.PathFor(userID), key)
+	if err != nil {
+		return err
+	}
+	if stale != "" {
+		logger.Logg.Warn("Локальное хранилище зашифровано другим ключом", "moved_to", stale)
+		fmt.Printf("Локальное хранилище зашифровано другим ключом и перенесено в %s\n", stale)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/urfave/cli/v2"
+)
+
+// NewMigrateCommand создаёт команду migrate
+func NewMigrateCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Перевести записи старого текстового формата в структурированный",
+		Action: func(cCtx *cli.Context) error {
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if _, err := syncVault(client); err != nil {
+				return err
+			}
+
+			converted := 0
+			for _, record := range client.Vault().List() {
+				// Содержимое старых бинарных записей — сам файл, переводить его нечем
+				if record.Type == payload.TypeBinary || !payload.IsLegacy(record.EncryptedData) {
+					continue
+				}
+
+				p, err := payload.Unmarshal(record.Type, record.EncryptedData)
+				if err != nil {
+					fmt.Printf("Запись %s пропущена: %v\n", record.Id, err)
+					continue
+				}
+				data, err := payload.Marshal(p)
+				if err != nil {
+					return err
+				}
+
+				err = client.PutLocal(&pb.DataRecord{
+					Id:            record.Id,
+					Type:          record.Type,
+					EncryptedData: data,
+					Metadata:      record.Metadata,
+					Folder:        record.Folder,
+					Tags:          record.Tags,
+				})
+				if err != nil {
+					return err
+				}
+				converted++
+			}
+
+			if converted == 0 {
+				fmt.Println("Записей старого формата нет")
+				return nil
+			}
+
+			online, err := syncVault(client)
+			if err != nil {
+				return err
+			}
+
+			if online {
+				fmt.Printf("Переведено записей: %d\n", converted)
+			} else {
+				fmt.Printf("Переведено записей: %d — будут отправлены при следующей синхронизации\n", converted)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/client/internal/utils"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewPasswdCommand создаёт команду passwd
+func NewPasswdCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "passwd",
+		Usage: "Сменить мастер-пароль",
+		Action: func(cCtx *cli.Context) error {
+			oldPassword, err := utils.ReadMasterPassword("Текущий master-пароль: ")
+			if err != nil {
+				return err
+			}
+			defer utils.ZeroBytes(oldPassword)
+
+			newPassword, err := utils.ReadMasterPassword("Новый master-пароль: ")
+			if err != nil {
+				return err
+			}
+			defer utils.ZeroBytes(newPassword)
+
+			confirm, err := utils.ReadMasterPassword("Повторите новый master-пароль: ")
+			if err != nil {
+				return err
+			}
+			defer utils.ZeroBytes(confirm)
+
+			if len(newPassword) == 0 {
+				return fmt.Errorf("новый пароль не может быть пустым")
+			}
+			if !bytes.Equal(newPassword, confirm) {
+				return fmt.Errorf("пароли не совпадают")
+			}
+
+			err = factory.ChangeMasterPassword(oldPassword, newPassword)
+			switch {
+			case status.Code(err) == codes.Unavailable:
+				return fmt.Errorf("сервер недоступен — смена пароля требует подключения")
+			case status.Code(err) == codes.Aborted:
+				return client.ErrVaultKeyChanged
+			case err != nil:
+				return err
+			}
+
+			fmt.Println("Мастер-пароль изменён. На других устройствах нужно войти заново")
+			return nil
+		},
+	}
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/dvkhr/gophkeeper/client/internal/client"
 	"github.com/dvkhr/gophkeeper/client/storage/file"
-	"github.com/dvkhr/gophkeeper/pkg/crypto"
 	"github.com/dvkhr/gophkeeper/pkg/logger"
 	"github.com/urfave/cli/v2"
 )
@@ -25,45 +24,51 @@ func NewRegisterCommand(serverAddress string) *cli.Command {
 
 			logger.Logg.Debug("Начало регистрации", "login", login)
 
-			salt, err := crypto.GenerateSalt()
+			key, kek, vaultKey, err := client.NewVaultKey(masterPassword)
 			if err != nil {
-				logger.Logg.Error("Не удалось сгенерировать соль", "error", err)
+				logger.Logg.Error("Не удалось создать ключ хранилища", "error", err)
 				return err
 			}
-			logger.Logg.Debug("Соль сгенерирована", "length", len(salt))
+			logger.Logg.Debug("Ключ хранилища сгенерирован", "kdf", vaultKey.Kdf.Algorithm, "key_length", len(key))
 
-			key := crypto.DeriveKey(masterPassword, salt)
-			logger.Logg.Debug("Ключ шифрования сгенерирован", "key_length", len(key))
-
-			masterKeyHash := crypto.SHA256(key)
-
-			client, err := client.NewClient(serverAddress, key)
+			c, err := client.NewClient(serverAddress, key)
 			if err != nil {
 				logger.Logg.Error("Не удалось создать gRPC-клиент", "error", err)
 				return err
 			}
-			defer client.Close()
+			defer c.Close()
 
 			logger.Logg.Info("Отправка запроса на регистрацию", "server", serverAddress)
-			resp, err := client.Register(login, []byte(masterPassword))
+			resp, err := c.Register(login, []byte(masterPassword))
 			if err != nil {
 				logger.Logg.Error("Регистрация не удалась", "login", login, "error", err)
 				return err
 			}
 			logger.Logg.Debug("Регистрация успешна", "user_id", resp.UserId)
 
+			// Если ключ не удалось сохранить сейчас, он будет отправлен при следующем разблокировании
+			if stored, err := c.StoreVaultKey(vaultKey, 0); err != nil {
+				logger.Logg.Warn("Не удалось сохранить ключ хранилища на сервере", "error", err)
+			} else {
+				vaultKey = stored
+			}
+
 			session := &file.Data{
-				Salt:          salt,
-				MasterKeyHash: masterKeyHash,
-				AccessToken:   resp.AccessToken,
-				RefreshToken:  resp.RefreshToken,
+				AccessToken:  resp.AccessToken,
+				RefreshToken: resp.RefreshToken,
+				UserID:       resp.UserId,
+			}
+			client.ApplyVaultKey(session, vaultKey, kek)
+
+			if err := reclaimVault(resp.UserId, key); err != nil {
+				return err
 			}
 
 			if err := file.Save(session); err != nil {
 				logger.Logg.Error("Не удалось сохранить сессию", "error", err)
 				return fmt.Errorf("регистрация успешна, но не удалось сохранить сессию: %w", err)
 			}
-			logger.Logg.Debug("Полная сессия сохранена: salt, masterKeyHash, токены")
+			logger.Logg.Debug("Полная сессия сохранена: salt, kdf, ключ хранилища, токены")
 
 			fmt.Printf("Пользователь %s успешно зарегистрирован и авторизован\n", login)
 			return nil
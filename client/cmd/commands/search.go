@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/client/internal/catalog"
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/client/internal/search"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/urfave/cli/v2"
+)
+
+// NewSearchCommand создаёт команду search
+func NewSearchCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:      "search",
+		Usage:     "Найти записи по ID, типу, метаданным и несекретным полям",
+		ArgsUsage: "[текст]",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "type",
+				Aliases: []string{"t"},
+				Usage:   "Только записи указанных типов",
+			},
+			&cli.StringFlag{
+				Name:  "folder",
+				Usage: "Только записи папки и вложенных в неё папок",
+			},
+			&cli.StringSliceFlag{
+				Name:  "tag",
+				Usage: "Только записи с меткой; можно указать несколько",
+			},
+			&cli.StringSliceFlag{
+				Name:  "meta",
+				Usage: "Только записи с метаданными ключ=значение (или ключ — с любым значением)",
+			},
+			&cli.BoolFlag{
+				Name:    "fuzzy",
+				Aliases: []string{"f"},
+				Usage:   "Нечёткий поиск: допускать пропущенные символы и опечатки",
+			},
+			&cli.StringFlag{
+				Name:  "sort",
+				Value: search.SortRelevance,
+				Usage: "Порядок результатов: relevance или updated",
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "Максимум результатов (0 — без ограничения)",
+			},
+		},
+		Action: func(cCtx *cli.Context) error {
+			q, err := searchQuery(cCtx, strings.Join(cCtx.Args().Slice(), " "))
+			if err != nil {
+				return err
+			}
+			if q.Text == "" && len(q.Types) == 0 && len(q.Meta) == 0 && q.Folder == "" && len(q.Tags) == 0 {
+				return fmt.Errorf("укажите текст для поиска, --type, --meta, --folder или --tag")
+			}
+
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			records, err := fetchRecords(client)
+			if err != nil {
+				return err
+			}
+
+			results := search.Search(records, q)
+			if len(results) == 0 {
+				fmt.Println("Ничего не найдено")
+				return nil
+			}
+
+			fmt.Printf("Найдено записей: %d\n", len(results))
+			fmt.Println(strings.Repeat("─", 80))
+			for _, result := range results {
+				printSearchResult(result)
+				fmt.Println(strings.Repeat("─", 80))
+			}
+			return nil
+		},
+	}
+}
+
+// searchQuery собирает запрос из флагов команды и текста text.
+func searchQuery(cCtx *cli.Context, text string) (search.Query, error) {
+	folder, err := catalog.CleanPath(cCtx.String("folder"))
+	if err != nil {
+		return search.Query{}, err
+	}
+
+	q := search.Query{
+		Text:   text,
+		Fuzzy:  cCtx.Bool("fuzzy"),
+		Types:  cCtx.StringSlice("type"),
+		Folder: folder,
+		Tags:   cCtx.StringSlice("tag"),
+		Sort:   cCtx.String("sort"),
+		Limit:  cCtx.Int("limit"),
+	}
+
+	if q.Sort != "" && q.Sort != search.SortRelevance && q.Sort != search.SortUpdated {
+		return q, fmt.Errorf("неверное значение --sort: %s (ожидается %s или %s)", q.Sort, search.SortRelevance, search.SortUpdated)
+	}
+	if q.Limit < 0 {
+		return q, fmt.Errorf("--limit не может быть отрицательным")
+	}
+
+	for _, item := range cCtx.StringSlice("meta") {
+		key, value, _ := strings.Cut(item, "=")
+		if key == "" {
+			return q, fmt.Errorf("неверный формат --meta: ожидается ключ=значение")
+		}
+		if q.Meta == nil {
+			q.Meta = make(map[string]string)
+		}
+		q.Meta[key] = value
+	}
+	return q, nil
+}
+
+// printSearchResult выводит найденную запись без секретных полей.
+func printSearchResult(result search.Result) {
+	record := result.Record
+	fmt.Printf("ID:       %s\n", record.Id)
+	fmt.Printf("Тип:      %s\n", record.Type)
+	if record.Timestamp > 0 {
+		fmt.Printf("Изменена: %s\n", time.Unix(record.Timestamp, 0).Format("2006-01-02 15:04:05"))
+	}
+
+	spec, known := payload.Lookup(record.Type)
+	if p, err := payload.Unmarshal(record.Type, record.EncryptedData); err == nil && known {
+		public := payload.PublicValues(p)
+		for _, field := range spec.Fields {
+			if value, ok := public[field.Name]; ok {
+				if field.Format != nil {
+					value = field.Format(value)
+				}
+				fmt.Printf("%-10s%s\n", field.Label+":", value)
+			}
+		}
+	}
+
+	printAttributes(record)
+	if len(result.Matches) > 0 {
+		fmt.Printf("Совпадения: %s\n", strings.Join(result.Matches, ", "))
+	}
+}
+
+// fetchRecords получает и расшифровывает записи пользователя с сервера.
+// Без сети записи берутся из локального хранилища.
+func fetchRecords(c *client.Client) ([]*pb.DataRecord, error) {
+	var records []*pb.DataRecord
+	err := c.DoWithRetry(func() error {
+		resp, err := c.GetData()
+		if err != nil {
+			return err
+		}
+		records = resp.Records
+		return nil
+	})
+	if client.IsOffline(err) {
+		fmt.Println("Сервер недоступен — используется локальное хранилище")
+		return c.Vault().List(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
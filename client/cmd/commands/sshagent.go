@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/client/internal/sshagent"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// NewSSHAgentCommand создаёт команду ssh-agent
+func NewSSHAgentCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "ssh-agent",
+		Usage: "Запустить ssh-agent с SSH-ключами из хранилища",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "socket", Usage: "Путь к Unix-сокету агента (по умолчанию — во временном каталоге)"},
+		},
+		Action: func(cCtx *cli.Context) error {
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if _, err := syncVault(client); err != nil {
+				return err
+			}
+
+			keyring := agent.NewKeyring()
+			defer keyring.RemoveAll()
+
+			loaded, err := sshagent.LoadKeys(keyring, client.Vault().List())
+			if err != nil {
+				fmt.Printf("Часть ключей не загружена: %v\n", err)
+			}
+			if len(loaded) == 0 {
+				return fmt.Errorf("в хранилище нет SSH-ключей, добавьте их командой add --type=sshkey")
+			}
+
+			socket := cCtx.String("socket")
+			if socket == "" {
+				dir, err := os.MkdirTemp("", "gophkeeper-agent-*")
+				if err != nil {
+					return fmt.Errorf("не удалось создать каталог для сокета: %w", err)
+				}
+				defer os.RemoveAll(dir)
+				socket = filepath.Join(dir, "agent.sock")
+			}
+
+			fmt.Printf("Загружено ключей: %d\n", len(loaded))
+			fmt.Printf("SSH_AUTH_SOCK=%s; export SSH_AUTH_SOCK;\n", socket)
+			fmt.Println("Агент работает до нажатия Ctrl+C")
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return sshagent.Serve(ctx, socket, keyring)
+		},
+	}
+}
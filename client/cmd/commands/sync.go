@@ -2,9 +2,12 @@ package commands
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
 	"github.com/dvkhr/gophkeeper/client/internal/client"
-	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/client/storage/vault"
 	"github.com/urfave/cli/v2"
 )
 
@@ -20,15 +23,29 @@ func NewSyncCommand(factory *client.Factory) *cli.Command {
 			}
 			defer client.Close()
 
-			// Локальное хранилище???
-			var records []*pb.DataRecord
+			records, deletions := client.Vault().Pending()
+			if len(records)+len(deletions) > 0 {
+				fmt.Printf("Отправка локальных изменений: %d записей, %d удалений\n", len(records), len(deletions))
+			}
 
 			err = client.DoWithRetry(func() error {
-				resp, err := client.SyncData(records)
+				resp, err := client.Sync()
 				if err != nil {
 					return err
 				}
 
+				printSyncIssues(resp, client.Vault())
+				printRejected(resp, client.Vault())
+
+				if resp.FullResync {
+					fmt.Println("Курсор синхронизации устарел, получен полный снимок данных")
+				}
+
+				for _, tombstone := range resp.Tombstones {
+					fmt.Printf("Удалена на сервере: %s (%s)\n",
+						tombstone.Id, time.Unix(tombstone.DeletedAt, 0).Format(time.DateTime))
+				}
+
 				if len(resp.Records) == 0 {
 					fmt.Println("Сервер не вернул новых данных")
 					return nil
@@ -36,7 +53,7 @@ func NewSyncCommand(factory *client.Factory) *cli.Command {
 
 				fmt.Printf("Получено %d записей с сервера:\n", len(resp.Records))
 				for _, record := range resp.Records {
-					fmt.Printf("  - ID: %s, Тип: %s\n", record.Id, record.Type)
+					fmt.Printf("  - ID: %s, Тип: %s, Ревизия: %d\n", record.Id, record.Type, record.Revision)
 				}
 
 				return nil
@@ -46,3 +63,74 @@ func NewSyncCommand(factory *client.Factory) *cli.Command {
 		},
 	}
 }
+
+// syncVault синхронизирует локальное хранилище с сервером перед работой с записями.
+// Если сервер недоступен, сообщает об этом и возвращает false без ошибки —
+// команда продолжает работу с локальной копией.
+func syncVault(c *client.Client) (bool, error) {
+	err := c.DoWithRetry(func() error {
+		resp, err := c.Sync()
+		if err != nil {
+			return err
+		}
+		printSyncIssues(resp, c.Vault())
+		return nil
+	})
+
+	if client.IsOffline(err) {
+		fmt.Println("Сервер недоступен — используется локальное хранилище")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// printRejected напоминает об изменениях, отклонённых сервером при прежних синхронизациях
+// и ещё не исправленных пользователем.
+func printRejected(resp *client.SyncResult, v *vault.Vault) {
+	reported := make(map[string]bool, len(resp.Rejected))
+	for _, rejected := range resp.Rejected {
+		reported[rejected.Id] = true
+	}
+
+	rejected := v.Rejected()
+	ids := slices.Sorted(maps.Keys(rejected))
+	for _, id := range ids {
+		if !reported[id] {
+			fmt.Printf("Изменение записи %q отклонено сервером (%s) — исправьте запись командой edit или удалите её командой delete\n",
+				id, rejected[id])
+		}
+	}
+}
+
+// printSyncIssues выводит записи, которые не удалось отправить: конфликтующие с изменениями
+// на сервере и отклонённые сервером, — а также полученные записи, которые не удалось расшифровать.
+// Отклонённые из-за временного сбоя остаются в очереди и отправляются повторно,
+// остальные пользователь исправляет сам
+func printSyncIssues(resp *client.SyncResult, v *vault.Vault) {
+	for _, conflict := range resp.Conflicts {
+		fmt.Printf("Конфликт: запись %s изменена на сервере (ваша ревизия %d, на сервере %d)\n",
+			conflict.Id, conflict.BaseRevision, conflict.ServerRevision)
+
+		copyID := conflict.Id + vault.ConflictSuffix
+		if _, err := v.Get(copyID); err == nil {
+			fmt.Printf("  локальная версия сохранена как %s\n", copyID)
+		}
+	}
+
+	for _, rejected := range resp.Rejected {
+		if rejected.Retryable {
+			fmt.Printf("Сервер не сохранил изменение записи %s (%s) — оно будет отправлено при следующей синхронизации\n",
+				rejected.Id, rejected.Reason)
+			continue
+		}
+		fmt.Printf("Сервер отклонил изменение записи %q (%s) — оно не будет отправлено повторно, "+
+			"исправьте запись командой edit или удалите её командой delete\n", rejected.Id, rejected.Reason)
+	}
+
+	for _, id := range resp.Undecryptable {
+		fmt.Printf("Не удалось расшифровать запись %s с сервера — она не сохранена в локальном хранилище\n", id)
+	}
+}
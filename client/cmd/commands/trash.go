@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dvkhr/gophkeeper/client/internal/client"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewTrashCommand создаёт команду trash
+func NewTrashCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "trash",
+		Usage: "Показать удалённые записи, которые ещё можно восстановить",
+		Action: func(cCtx *cli.Context) error {
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if _, err := syncVault(client); err != nil {
+				return err
+			}
+
+			var records []*pb.DeletedRecord
+			err = client.DoWithRetry(func() (err error) {
+				records, err = client.ListDeleted()
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(records) == 0 {
+				fmt.Println("Корзина пуста")
+				return nil
+			}
+
+			fmt.Printf("Записей в корзине: %d\n", len(records))
+			fmt.Println(strings.Repeat("─", 80))
+			for _, deleted := range records {
+				fmt.Printf("ID:       %s\n", deleted.Record.Id)
+				fmt.Printf("Тип:      %s\n", deleted.Record.Type)
+				fmt.Printf("Удалена:  %s, будет удалена окончательно %s\n",
+					formatUnix(deleted.DeletedAt), formatUnix(deleted.PurgeAt))
+				fmt.Println(strings.Repeat("─", 80))
+			}
+			fmt.Println("Восстановить запись: undelete --id=<ID>")
+			return nil
+		},
+	}
+}
+
+// NewUndeleteCommand создаёт команду undelete
+func NewUndeleteCommand(factory *client.Factory) *cli.Command {
+	return &cli.Command{
+		Name:  "undelete",
+		Usage: "Восстановить удалённую запись из корзины",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "id", Required: true},
+		},
+		Action: func(cCtx *cli.Context) error {
+			client, err := factory.NewAuthenticatedClient()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			// Сначала отправляем локальные удаления: запись должна оказаться в корзине на сервере
+			online, err := syncVault(client)
+			if err != nil {
+				return err
+			}
+			if !online {
+				return fmt.Errorf("для восстановления записи нужно подключение к серверу")
+			}
+
+			id := cCtx.String("id")
+			var revision int64
+			err = client.DoWithRetry(func() (err error) {
+				revision, err = client.RestoreData(id)
+				return err
+			})
+			if status.Code(err) == codes.NotFound {
+				return fmt.Errorf("записи %s нет в корзине", id)
+			}
+			if err != nil {
+				return err
+			}
+
+			if _, err := syncVault(client); err != nil {
+				return err
+			}
+
+			fmt.Printf("Запись восстановлена: %s (ревизия %d)\n", id, revision)
+			return nil
+		},
+	}
+}
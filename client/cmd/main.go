@@ -25,7 +25,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	var flagServer string
+	var flagServer, flagSearchKeys string
 
 	app := &cli.App{
 		Name:    "gophkeeper-client",
@@ -46,14 +46,20 @@ func main() {
 				Destination: &flagServer,
 				EnvVars:     []string{"GK_SERVER"},
 			},
+			&cli.StringFlag{
+				Name:        "search-keys",
+				Usage:       "Ключи метаданных через запятую, по которым разрешён поиск на сервере",
+				Destination: &flagSearchKeys,
+				EnvVars:     []string{"GK_SEARCH_KEYS"},
+			},
 		},
 		Before: func(cCtx *cli.Context) error {
 			// Загружаем конфиг
-			cfg := config.Load(flagServer)
+			cfg := config.Load(flagServer, flagSearchKeys)
 			// Создаем компоненты
 			sessionManager := session.NewManager()
 			authenticator := client.NewAuthenticator(sessionManager)
-			factory := client.NewFactory(sessionManager, authenticator, cfg.Server.Address)
+			factory := client.NewFactory(sessionManager, authenticator, cfg.Server.Address, cfg.Search.Keys)
 
 			for i, cmd := range cCtx.App.Commands {
 				switch cmd.Name {
@@ -71,19 +77,65 @@ func main() {
 					cCtx.App.Commands[i] = commands.NewDeleteCommand(factory)
 				case "sync":
 					cCtx.App.Commands[i] = commands.NewSyncCommand(factory)
+				case "passwd":
+					cCtx.App.Commands[i] = commands.NewPasswdCommand(factory)
+				case "migrate":
+					cCtx.App.Commands[i] = commands.NewMigrateCommand(factory)
+				case "import":
+					cCtx.App.Commands[i] = commands.NewImportCommand(factory)
+				case "edit":
+					cCtx.App.Commands[i] = commands.NewEditCommand(factory)
+				case "trash":
+					cCtx.App.Commands[i] = commands.NewTrashCommand(factory)
+				case "undelete":
+					cCtx.App.Commands[i] = commands.NewUndeleteCommand(factory)
+				case "history":
+					cCtx.App.Commands[i] = commands.NewHistoryCommand(factory)
+				case "restore":
+					cCtx.App.Commands[i] = commands.NewRestoreCommand(factory)
+				case "copy":
+					cCtx.App.Commands[i] = commands.NewCopyCommand(factory)
+				case "mkdir":
+					cCtx.App.Commands[i] = commands.NewMkdirCommand(factory)
+				case "mv":
+					cCtx.App.Commands[i] = commands.NewMvCommand(factory)
+				case "tag":
+					cCtx.App.Commands[i] = commands.NewTagCommand(factory)
+				case "search":
+					cCtx.App.Commands[i] = commands.NewSearchCommand(factory)
+				case "code":
+					cCtx.App.Commands[i] = commands.NewCodeCommand(factory)
+				case "ssh-agent":
+					cCtx.App.Commands[i] = commands.NewSSHAgentCommand(factory)
 				}
 			}
 			return nil
 		},
 		Commands: []*cli.Command{
 			NewVersionCommand(),
+			commands.NewGenerateCommand(),
 			{Name: "register"},
 			{Name: "add"},
+			{Name: "edit"},
 			{Name: "login"},
 			{Name: "logout"},
 			{Name: "get"},
+			{Name: "search"},
+			{Name: "mkdir"},
+			{Name: "mv"},
+			{Name: "tag"},
 			{Name: "delete"},
 			{Name: "sync"},
+			{Name: "passwd"},
+			{Name: "migrate"},
+			{Name: "import"},
+			{Name: "ssh-agent"},
+			{Name: "code"},
+			{Name: "copy"},
+			{Name: "trash"},
+			{Name: "undelete"},
+			{Name: "history"},
+			{Name: "restore"},
 		},
 	}
 
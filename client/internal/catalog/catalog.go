@@ -0,0 +1,234 @@
+// Package catalog упорядочивает записи по папкам и меткам.
+//
+// Папка — путь из имён через "/", например team/prod; пустой путь — корень.
+// Папки существуют, пока в них есть записи; пустая папка хранится
+// отдельной записью типа folder (см. payload.TypeFolder).
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+)
+
+// Separator разделяет имена папок в пути.
+const Separator = "/"
+
+// ErrInvalidPath возвращается для пути с недопустимыми именами папок.
+var ErrInvalidPath = errors.New("недопустимый путь папки")
+
+// CleanPath приводит путь папки к каноническому виду: без пробелов по краям имён,
+// пустых имён и "/" в начале и в конце. Имена "." и ".." недопустимы.
+func CleanPath(path string) (string, error) {
+	var names []string
+	for _, name := range strings.Split(path, Separator) {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "":
+			continue
+		case ".", "..":
+			return "", fmt.Errorf("%w: %s", ErrInvalidPath, path)
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, Separator), nil
+}
+
+// Within сообщает, что папка path — это folder или вложена в неё. Корень содержит все папки.
+func Within(path, folder string) bool {
+	return folder == "" || path == folder || strings.HasPrefix(path, folder+Separator)
+}
+
+// Rebase переносит путь path из папки from в папку to, сохраняя вложенность.
+// Путь вне from возвращается без изменений.
+func Rebase(path, from, to string) string {
+	if !Within(path, from) {
+		return path
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(path, from), Separator)
+	switch {
+	case to == "":
+		return rest
+	case rest == "":
+		return to
+	default:
+		return to + Separator + rest
+	}
+}
+
+// CleanTags возвращает метки без пробелов по краям, пустых и повторяющихся, в порядке имён.
+// Метки сравниваются без учёта регистра; сохраняется первое написание.
+func CleanTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var cleaned []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, tag)
+	}
+	sort.Slice(cleaned, func(i, j int) bool { return strings.ToLower(cleaned[i]) < strings.ToLower(cleaned[j]) })
+	return cleaned
+}
+
+// HasTags сообщает, что у записи есть все метки tags (без учёта регистра).
+func HasTags(record *pb.DataRecord, tags []string) bool {
+	for _, want := range tags {
+		found := false
+		for _, tag := range record.Tags {
+			if strings.EqualFold(tag, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// IsFolder сообщает, что запись хранит пустую папку, а не данные пользователя.
+func IsFolder(record *pb.DataRecord) bool {
+	return record.Type == payload.TypeFolder
+}
+
+// Folders возвращает все папки записей вместе с родительскими, в порядке путей.
+func Folders(records []*pb.DataRecord) []string {
+	seen := make(map[string]bool)
+	for _, record := range records {
+		for path := record.Folder; path != ""; path = parent(path) {
+			seen[path] = true
+		}
+	}
+
+	folders := make([]string, 0, len(seen))
+	for path := range seen {
+		folders = append(folders, path)
+	}
+	sort.Strings(folders)
+	return folders
+}
+
+// Node — папка в дереве записей.
+type Node struct {
+	Name     string           // Имя папки; пустое у корня
+	Path     string           // Полный путь папки
+	Folders  []*Node          // Вложенные папки в порядке имён
+	Records  []*pb.DataRecord // Записи папки в порядке ID, без записей типа folder
+	children map[string]*Node
+}
+
+// Tree строит дерево папок из записей. Записи типа folder дают только папки.
+func Tree(records []*pb.DataRecord) *Node {
+	root := &Node{}
+	for _, record := range records {
+		node := root.folder(record.Folder)
+		if !IsFolder(record) {
+			node.Records = append(node.Records, record)
+		}
+	}
+	root.sort()
+	return root
+}
+
+// Count возвращает число записей в папке вместе с вложенными.
+func (n *Node) Count() int {
+	count := len(n.Records)
+	for _, child := range n.Folders {
+		count += child.Count()
+	}
+	return count
+}
+
+// folder возвращает узел папки path, создавая недостающие.
+func (n *Node) folder(path string) *Node {
+	node := n
+	if path == "" {
+		return node
+	}
+	for _, name := range strings.Split(path, Separator) {
+		child, ok := node.children[name]
+		if !ok {
+			child = &Node{Name: name, Path: joinPath(node.Path, name)}
+			if node.children == nil {
+				node.children = make(map[string]*Node)
+			}
+			node.children[name] = child
+			node.Folders = append(node.Folders, child)
+		}
+		node = child
+	}
+	return node
+}
+
+func (n *Node) sort() {
+	sort.Slice(n.Folders, func(i, j int) bool { return n.Folders[i].Name < n.Folders[j].Name })
+	sort.Slice(n.Records, func(i, j int) bool { return n.Records[i].Id < n.Records[j].Id })
+	for _, child := range n.Folders {
+		child.sort()
+	}
+}
+
+// Print выводит дерево в w: сначала вложенные папки, затем записи папки.
+// label возвращает строку записи.
+func Print(w io.Writer, root *Node, label func(*pb.DataRecord) string) {
+	fmt.Fprintf(w, "%s (%d)\n", Separator+root.Path, root.Count())
+	printChildren(w, root, "", label)
+}
+
+func printChildren(w io.Writer, node *Node, indent string, label func(*pb.DataRecord) string) {
+	total := len(node.Folders) + len(node.Records)
+	for i := 0; i < total; i++ {
+		branch, next := "├── ", "│   "
+		if i == total-1 {
+			branch, next = "└── ", "    "
+		}
+
+		if i < len(node.Folders) {
+			child := node.Folders[i]
+			fmt.Fprintf(w, "%s%s%s/ (%d)\n", indent, branch, child.Name, child.Count())
+			printChildren(w, child, indent+next, label)
+			continue
+		}
+		fmt.Fprintf(w, "%s%s%s\n", indent, branch, label(node.Records[i-len(node.Folders)]))
+	}
+}
+
+// Subtree возвращает узел папки path или nil, если такой папки нет.
+func (n *Node) Subtree(path string) *Node {
+	node := n
+	if path == "" {
+		return node
+	}
+	for _, name := range strings.Split(path, Separator) {
+		child, ok := node.children[name]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+	return node
+}
+
+func parent(path string) string {
+	if i := strings.LastIndex(path, Separator); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func joinPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + Separator + name
+}
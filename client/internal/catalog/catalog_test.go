@@ -0,0 +1,97 @@
+package catalog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", ""},
+		{"/", ""},
+		{"team", "team"},
+		{"/team/prod/", "team/prod"},
+		{" team // prod ", "team/prod"},
+	}
+	for _, tt := range tests {
+		got, err := CleanPath(tt.path)
+		require.NoError(t, err, tt.path)
+		assert.Equal(t, tt.want, got, tt.path)
+	}
+
+	_, err := CleanPath("team/../prod")
+	assert.ErrorIs(t, err, ErrInvalidPath)
+	_, err = CleanPath("./team")
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
+
+func TestWithinAndRebase(t *testing.T) {
+	assert.True(t, Within("team/prod", "team"))
+	assert.True(t, Within("team", "team"))
+	assert.True(t, Within("team", ""))
+	assert.False(t, Within("teamwork", "team"))
+	assert.False(t, Within("team", "team/prod"))
+
+	assert.Equal(t, "archive/prod", Rebase("team/prod", "team", "archive"))
+	assert.Equal(t, "archive", Rebase("team", "team", "archive"))
+	assert.Equal(t, "prod/db", Rebase("team/prod/db", "team", ""))
+	assert.Equal(t, "other", Rebase("other", "team", "archive"))
+}
+
+func TestTags(t *testing.T) {
+	assert.Equal(t, []string{"DB", "prod"}, CleanTags([]string{" prod", "DB", "", "db", "prod"}))
+	assert.Nil(t, CleanTags(nil))
+
+	record := &pb.DataRecord{Tags: []string{"prod", "DB"}}
+	assert.True(t, HasTags(record, []string{"db"}))
+	assert.True(t, HasTags(record, []string{"PROD", "db"}))
+	assert.True(t, HasTags(record, nil))
+	assert.False(t, HasTags(record, []string{"prod", "dev"}))
+}
+
+func testRecords() []*pb.DataRecord {
+	return []*pb.DataRecord{
+		{Id: "gmail", Type: payload.TypeLoginPass},
+		{Id: "db", Type: payload.TypeLoginPass, Folder: "team/prod", Tags: []string{"pg"}},
+		{Id: "api", Type: payload.TypeText, Folder: "team/prod"},
+		{Id: "wiki", Type: payload.TypeLoginPass, Folder: "team"},
+		{Id: "folder-1", Type: payload.TypeFolder, Folder: "team/dev"},
+	}
+}
+
+func TestFolders(t *testing.T) {
+	assert.Equal(t, []string{"team", "team/dev", "team/prod"}, Folders(testRecords()))
+}
+
+func TestTree(t *testing.T) {
+	root := Tree(testRecords())
+	assert.Equal(t, 4, root.Count())
+
+	team := root.Subtree("team")
+	require.NotNil(t, team)
+	assert.Equal(t, 3, team.Count())
+	require.Len(t, team.Folders, 2)
+	assert.Equal(t, "team/dev", team.Folders[0].Path)
+	assert.Empty(t, team.Folders[0].Records)
+	assert.Nil(t, root.Subtree("team/qa"))
+
+	var buf bytes.Buffer
+	Print(&buf, root, func(record *pb.DataRecord) string { return record.Id })
+	assert.Equal(t, `/ (4)
+├── team/ (3)
+│   ├── dev/ (0)
+│   ├── prod/ (2)
+│   │   ├── api
+│   │   └── db
+│   └── wiki
+└── gmail
+`, buf.String())
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/dvkhr/gophkeeper/client/internal/utils"
 	"github.com/dvkhr/gophkeeper/client/session"
 	"github.com/dvkhr/gophkeeper/pkg/crypto"
+	"github.com/dvkhr/gophkeeper/pkg/logger"
 )
 
 type Authenticator struct {
@@ -20,6 +21,17 @@ func NewAuthenticator(sessionMgr *session.Manager) *Authenticator {
 
 // Authenticate запрашивает мастер-пароль и проверяет его
 func (a *Authenticator) Authenticate() ([]byte, error) {
+	password, err := utils.ReadMasterPassword("Master-пароль: ")
+	if err != nil {
+		return nil, err
+	}
+	defer utils.ZeroBytes(password)
+
+	return a.Unlock(password)
+}
+
+// Unlock проверяет мастер-пароль по сессии и возвращает ключ хранилища
+func (a *Authenticator) Unlock(password []byte) ([]byte, error) {
 	sess, err := a.sessionMgr.Load()
 	if err != nil {
 		return nil, err
@@ -33,23 +45,69 @@ func (a *Authenticator) Authenticate() ([]byte, error) {
 		return nil, ErrNoMasterKeyHash
 	}
 
-	password, err := utils.ReadMasterPassword("Master-пароль: ")
+	kdf := crypto.LegacyKDFParams()
+	if sess.KDF != nil {
+		kdf = *sess.KDF
+	}
+
+	kek, err := kdf.Derive(string(password), sess.Salt)
 	if err != nil {
 		return nil, err
 	}
-	defer utils.ZeroBytes(password)
-
-	key := crypto.DeriveKey(string(password), sess.Salt)
-	hash := crypto.SHA256(key)
+	hash := crypto.SHA256(kek)
 
 	if !bytes.Equal(hash, sess.MasterKeyHash) {
-		utils.ZeroBytes(password)
 		return nil, ErrInvalidPassword
 	}
 
+	// Ключ хранилища зашифрован ключом из пароля; у старых сессий ключом хранилища служит сам ключ из пароля
+	key := kek
+	if len(sess.WrappedKey) > 0 {
+		key, err = crypto.UnwrapKey(kek, sess.WrappedKey)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось расшифровать ключ хранилища: %w", err)
+		}
+	}
+
+	if kdf.Outdated() || len(sess.WrappedKey) == 0 {
+		if err := a.upgradeKDF(sess, string(password), key); err != nil {
+			logger.Logg.Warn("Не удалось обновить параметры ключа", "error", err)
+		} else {
+			logger.Logg.Info("Параметры ключа обновлены", "kdf", crypto.KDFArgon2id)
+		}
+	}
+
 	return key, nil
 }
 
+// upgradeKDF заново получает ключ из пароля с параметрами по умолчанию и новой солью
+// и сохраняет в сессии ключ хранилища, зашифрованный этим ключом.
+// Сам ключ хранилища не меняется, поэтому записи не нужно перешифровывать.
+func (a *Authenticator) upgradeKDF(sess *session.Data, password string, key []byte) error {
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		return err
+	}
+
+	kdf := crypto.DefaultKDFParams()
+	kek, err := kdf.Derive(password, salt)
+	if err != nil {
+		return err
+	}
+
+	wrapped, err := crypto.WrapKey(kek, key)
+	if err != nil {
+		return err
+	}
+
+	sess.Salt = salt
+	sess.KDF = &kdf
+	sess.MasterKeyHash = crypto.SHA256(kek)
+	sess.WrappedKey = wrapped
+
+	return a.sessionMgr.Save(sess)
+}
+
 var (
 	ErrNoSalt          = fmt.Errorf("соль не найдена")
 	ErrNoMasterKeyHash = fmt.Errorf("master_key_hash не найден")
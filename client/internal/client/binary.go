@@ -0,0 +1,387 @@
+// Binary — передача содержимого бинарных записей потоками чанков
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/client/storage/file"
+	"github.com/dvkhr/gophkeeper/client/storage/vault"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/crypto"
+	"github.com/dvkhr/gophkeeper/pkg/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// binaryChunkSize — размер чанка шифротекста при загрузке.
+	binaryChunkSize = 1 << 20
+
+	// maxTransferAttempts — сколько раз продолжать прерванную передачу.
+	maxTransferAttempts = 3
+)
+
+// ErrBinaryCorrupted возвращается, если скачанный шифротекст не совпал с SHA-256 на сервере.
+var ErrBinaryCorrupted = errors.New("содержимое записи повреждено при передаче")
+
+// UploadBinary шифрует файл path потоком и загружает его на сервер как содержимое записи record.
+// Шифротекст сначала пишется во временный файл: так в памяти находится только один сегмент,
+// а прерванную загрузку можно продолжить с того же шифротекста.
+// Поле Revision записи — базовая ревизия (0 для новой записи).
+// При обрыве соединения загрузка продолжается с последнего принятого сервером байта.
+// Незавершённая загрузка запоминается в локальном хранилище, поэтому повторный вызов
+// для того же файла — в том числе после перезапуска клиента — продолжает её, а не начинает заново.
+func (c *Client) UploadBinary(record *pb.DataRecord, path string) (*pb.UploadBinaryResponse, error) {
+	source, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл: %w", err)
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл: %w", err)
+	}
+
+	upload, offset, err := c.resumeUpload(record.Id, source, info)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil {
+		upload, err = c.encryptUpload(record, source, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.vault == nil {
+		defer os.Remove(upload.Path)
+	}
+
+	tmp, err := os.Open(upload.Path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать временный файл: %w", err)
+	}
+	defer tmp.Close()
+
+	// В записи остаётся зашифрованное описание содержимого (имя файла, размер)
+	var encryptedData []byte
+	if len(record.EncryptedData) > 0 {
+		encryptedData, err = c.crypto.EncryptWithAAD(record.EncryptedData, crypto.RecordAAD(record.Id, record.Type, record.Metadata))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка шифрования записи %s: %w", record.Id, err)
+		}
+	}
+
+	encryptedMetadata, err := c.encryptMetadata(record)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка шифрования метаданных записи %s: %w", record.Id, err)
+	}
+
+	header := &pb.UploadHeader{
+		Record: &pb.DataRecord{
+			Id:                record.Id,
+			Type:              record.Type,
+			EncryptedData:     encryptedData,
+			EncryptedMetadata: encryptedMetadata,
+			BlindIndexes:      c.blindIndexes(record.Metadata),
+			Revision:          record.Revision,
+		},
+		UploadId: upload.ID,
+		Offset:   offset,
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.uploadFrom(header, tmp, upload.Size, upload.Sha256)
+		if err == nil {
+			c.forgetUpload(record.Id, upload)
+			return resp, nil
+		}
+		if status.Code(err) == codes.DataLoss {
+			// Сервер отбросил принятую часть — сохранённый шифротекст больше не пригоден
+			c.forgetUpload(record.Id, upload)
+			return nil, err
+		}
+		if !IsOffline(err) || attempt == maxTransferAttempts {
+			return nil, err
+		}
+
+		logger.Logg.Warn("Загрузка прервана, продолжаем", "id", record.Id, "attempt", attempt, "error", err)
+		time.Sleep(time.Duration(attempt) * time.Second)
+
+		offsetResp, err := c.service.GetUploadOffset(c.authContext(), &pb.UploadOffsetRequest{
+			Id:       record.Id,
+			UploadId: upload.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		header.Offset = offsetResp.Offset
+	}
+}
+
+// resumeUpload находит в локальном хранилище незавершённую загрузку файла source
+// и запрашивает у сервера, сколько байт уже принято. Возвращает nil, если продолжать нечего:
+// загрузки нет, исходный файл изменился или временный файл с шифротекстом потерян.
+func (c *Client) resumeUpload(id, source string, info os.FileInfo) (*vault.Upload, int64, error) {
+	if c.vault == nil {
+		return nil, 0, nil
+	}
+	upload, ok := c.vault.Upload(id)
+	if !ok {
+		return nil, 0, nil
+	}
+
+	if upload.Source != source || upload.SourceSize != info.Size() || upload.SourceModTime != info.ModTime().UnixNano() {
+		c.forgetUpload(id, &upload)
+		return nil, 0, nil
+	}
+	if tmpInfo, err := os.Stat(upload.Path); err != nil || tmpInfo.Size() != upload.Size {
+		c.forgetUpload(id, &upload)
+		return nil, 0, nil
+	}
+
+	resp, err := c.service.GetUploadOffset(c.authContext(), &pb.UploadOffsetRequest{Id: id, UploadId: upload.ID})
+	if status.Code(err) == codes.InvalidArgument {
+		c.forgetUpload(id, &upload)
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.Offset > upload.Size {
+		c.forgetUpload(id, &upload)
+		return nil, 0, nil
+	}
+
+	logger.Logg.Info("Продолжение загрузки", "id", id, "offset", resp.Offset, "size", upload.Size)
+	return &upload, resp.Offset, nil
+}
+
+// encryptUpload шифрует файл source во временный файл рядом с локальным хранилищем
+// и запоминает загрузку, чтобы её можно было продолжить после перезапуска.
+func (c *Client) encryptUpload(record *pb.DataRecord, source string, info os.FileInfo) (*vault.Upload, error) {
+	src, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл: %w", err)
+	}
+	defer src.Close()
+
+	dir := filepath.Join(file.Dir(), ".gophkeeper-uploads")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("не удалось создать каталог загрузок: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать временный файл: %w", err)
+	}
+	defer tmp.Close()
+
+	upload := &vault.Upload{
+		Path:          tmp.Name(),
+		Source:        source,
+		SourceSize:    info.Size(),
+		SourceModTime: info.ModTime().UnixNano(),
+	}
+
+	digest := sha256.New()
+	if err := c.encryptStreamTo(io.MultiWriter(tmp, digest), src, crypto.StreamAAD(record.Id)); err != nil {
+		os.Remove(upload.Path)
+		return nil, fmt.Errorf("ошибка шифрования записи %s: %w", record.Id, err)
+	}
+	tmpInfo, err := tmp.Stat()
+	if err != nil {
+		os.Remove(upload.Path)
+		return nil, err
+	}
+	upload.Size = tmpInfo.Size()
+	upload.Sha256 = digest.Sum(nil)
+
+	upload.ID, err = newUploadID()
+	if err != nil {
+		os.Remove(upload.Path)
+		return nil, err
+	}
+
+	if c.vault != nil {
+		c.vault.SetUpload(record.Id, *upload)
+		if err := c.vault.Save(); err != nil {
+			logger.Logg.Warn("Не удалось запомнить загрузку", "id", record.Id, "error", err)
+		}
+	}
+	return upload, nil
+}
+
+// encryptStreamTo шифрует src потоком в w с дополнительными данными aad.
+func (c *Client) encryptStreamTo(w io.Writer, src io.Reader, aad []byte) error {
+	enc, err := c.crypto.EncryptStream(w, aad)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(enc, src); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// forgetUpload удаляет временный файл загрузки и забывает её в локальном хранилище.
+func (c *Client) forgetUpload(id string, upload *vault.Upload) {
+	_ = os.Remove(upload.Path)
+	if c.vault == nil {
+		return
+	}
+	c.vault.DeleteUpload(id)
+	if err := c.vault.Save(); err != nil {
+		logger.Logg.Warn("Не удалось обновить локальное хранилище", "id", id, "error", err)
+	}
+}
+
+// uploadFrom отправляет шифротекст из src размера size, начиная со смещения header.Offset.
+func (c *Client) uploadFrom(header *pb.UploadHeader, src io.ReaderAt, size int64, sum []byte) (*pb.UploadBinaryResponse, error) {
+	stream, err := c.service.UploadBinary(c.authContext())
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binaryChunkSize)
+	for pos := header.Offset; ; {
+		n, err := src.ReadAt(buf[:min(int64(len(buf)), size-pos)], pos)
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("не удалось прочитать временный файл: %w", err)
+		}
+		end := pos + int64(n)
+
+		req := &pb.UploadBinaryRequest{Chunk: buf[:n]}
+		if pos == header.Offset {
+			req.Header = header
+		}
+		if end == size {
+			req.Sha256 = sum
+		}
+
+		if err := stream.Send(req); err != nil {
+			if err == io.EOF {
+				// Сервер закрыл поток — причину возвращает CloseAndRecv
+				_, err = stream.CloseAndRecv()
+			}
+			return nil, err
+		}
+
+		if end == size {
+			break
+		}
+		pos = end
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Completed {
+		return nil, fmt.Errorf("сервер не подтвердил загрузку: принято %d из %d байт", resp.Received, size)
+	}
+	return resp, nil
+}
+
+// DownloadBinary скачивает содержимое записи id, проверяет SHA-256, расшифровывает потоком и пишет в w.
+// Шифротекст накапливается во временном файле; в w попадают только данные, прошедшие проверку.
+// При обрыве соединения скачивание продолжается с последнего полученного байта.
+func (c *Client) DownloadBinary(id string, w io.Writer) (int64, error) {
+	tmp, err := os.CreateTemp("", "gophkeeper-download-*")
+	if err != nil {
+		return 0, fmt.Errorf("не удалось создать временный файл: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	d := &download{file: tmp, hash: sha256.New()}
+
+	for attempt := 1; ; attempt++ {
+		err := c.downloadFrom(id, d)
+		if err == nil {
+			break
+		}
+		if !IsOffline(err) || attempt == maxTransferAttempts {
+			return 0, err
+		}
+
+		logger.Logg.Warn("Скачивание прервано, продолжаем", "id", id, "attempt", attempt, "error", err)
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+
+	if d.received != d.size || !bytes.Equal(d.hash.Sum(nil), d.sha256) {
+		return 0, ErrBinaryCorrupted
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	plaintext, err := c.crypto.DecryptStream(tmp, crypto.StreamAAD(id))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка расшифрования записи %s: %w", id, err)
+	}
+
+	n, err := io.Copy(w, plaintext)
+	if err != nil {
+		return n, fmt.Errorf("ошибка расшифрования записи %s: %w", id, err)
+	}
+	return n, nil
+}
+
+// download — состояние скачивания между попытками.
+type download struct {
+	file     *os.File
+	hash     hash.Hash
+	received int64
+	size     int64
+	sha256   []byte
+}
+
+// downloadFrom дочитывает шифротекст записи, начиная с уже полученной части.
+func (c *Client) downloadFrom(id string, d *download) error {
+	stream, err := c.service.DownloadBinary(c.authContext(), &pb.DownloadBinaryRequest{
+		Id:     id,
+		Offset: d.received,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(msg.Sha256) > 0 {
+			d.size = msg.TotalSize
+			d.sha256 = msg.Sha256
+		}
+
+		if _, err := d.file.Write(msg.Chunk); err != nil {
+			return fmt.Errorf("не удалось записать временный файл: %w", err)
+		}
+		d.hash.Write(msg.Chunk)
+		d.received += int64(len(msg.Chunk))
+	}
+}
+
+// newUploadID генерирует случайный идентификатор загрузки.
+func newUploadID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать идентификатор загрузки: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
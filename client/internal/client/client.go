@@ -5,15 +5,18 @@
 //   - Шифрование и расшифровку данных с использованием AES-GCM.
 //   - Аутентификацию через JWT-токены.
 //   - Сохранение сессии (токенов и соли) в локальном хранилище.
+//   - Работу без сети через зашифрованное локальное хранилище записей.
 //
 // Все данные шифруются на клиенте, сервер хранит только зашифрованные данные.
 package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/dvkhr/gophkeeper/client/storage/file"
+	"github.com/dvkhr/gophkeeper/client/storage/vault"
 	"github.com/dvkhr/gophkeeper/pb"
 	"github.com/dvkhr/gophkeeper/pkg/crypto"
 	"github.com/dvkhr/gophkeeper/pkg/logger"
@@ -24,12 +27,18 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// ErrRecordNotFound возвращается, если запрошенной записи нет на сервере.
+var ErrRecordNotFound = errors.New("запись не найдена")
+
 // Client — gRPC-клиент для GophKeeper.
 type Client struct {
-	conn    *grpc.ClientConn
-	service pb.KeeperServiceClient
-	token   string
-	crypto  *crypto.Encryptor
+	conn       *grpc.ClientConn
+	service    pb.KeeperServiceClient
+	token      string
+	crypto     *crypto.Encryptor
+	index      *crypto.BlindIndexer
+	searchKeys map[string]bool
+	vault      *vault.Vault
 }
 
 // New создаёт новый gRPC-клиент и устанавливает соединение с сервером.
@@ -50,6 +59,7 @@ func NewClient(address string, encryptionKey []byte) (*Client, error) {
 		conn:    clientConn,
 		service: pb.NewKeeperServiceClient(clientConn),
 		crypto:  encryptor,
+		index:   crypto.NewBlindIndexer(encryptionKey),
 	}, nil
 }
 
@@ -109,26 +119,62 @@ func (c *Client) StoreData(record *pb.DataRecord) (*pb.StatusResponse, error) {
 
 // GetData запрашивает все неудалённые записи пользователя.
 func (c *Client) GetData() (*pb.DataResponse, error) {
+	return c.getData(&pb.GetDataRequest{})
+}
+
+// GetRecord запрашивает одну неудалённую запись id и расшифровывает её.
+// Возвращает ErrRecordNotFound, если такой записи на сервере нет.
+func (c *Client) GetRecord(id string) (*pb.DataRecord, error) {
+	resp, err := c.service.GetRecord(c.authContext(), &pb.GetRecordRequest{Id: id})
+	if status.Code(err) == codes.NotFound {
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record := resp.Record
+	if err := c.decryptRecord(record); err != nil {
+		return nil, fmt.Errorf("ошибка расшифрования записи %s: %w", id, err)
+	}
+	return record, nil
+}
+
+// GetDataPage запрашивает страницу записей типа recordType (пустой — всех типов).
+// pageToken — NextPageToken предыдущей страницы; пустой для первой.
+func (c *Client) GetDataPage(recordType string, pageSize int32, pageToken string) (*pb.DataResponse, error) {
+	return c.getData(&pb.GetDataRequest{Type: recordType, PageSize: pageSize, PageToken: pageToken})
+}
+
+// getData запрашивает записи пользователя и расшифровывает их.
+func (c *Client) getData(req *pb.GetDataRequest) (*pb.DataResponse, error) {
 	ctx := c.authContext()
-	resp, err := c.service.GetData(ctx, &pb.GetDataRequest{})
+	resp, err := c.service.GetData(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, record := range resp.Records {
-		plaintext, err := c.crypto.Decrypt(record.EncryptedData)
-		if err != nil {
+		if err := c.decryptRecord(record); err != nil {
 			logger.Logg.Warn("ошибка расшифрования записи ", record.Id)
-			continue
 		}
-		record.EncryptedData = plaintext
 	}
 
 	return resp, nil
 }
 
-// SyncData синхронизирует список записей с сервером.
-func (c *Client) SyncData(records []*pb.DataRecord) (*pb.SyncResponse, error) {
+// SyncResult — ответ сервера на синхронизацию с расшифрованными записями.
+type SyncResult struct {
+	*pb.SyncResponse
+
+	// Undecryptable — ID записей, которые не удалось расшифровать ключом хранилища.
+	// Их нет в Records, поэтому в локальное хранилище они не попадают
+	Undecryptable []string
+}
+
+// SyncData синхронизирует список записей и удалений с сервером.
+// cursor — курсор предыдущей синхронизации; сервер вернёт только изменения после него.
+func (c *Client) SyncData(records []*pb.DataRecord, deletions []*pb.Tombstone, cursor int64) (*SyncResult, error) {
 	var encryptedRecords []*pb.DataRecord
 	for _, record := range records {
 		encryptedRecord, err := c.encryptRecord(record)
@@ -141,21 +187,25 @@ func (c *Client) SyncData(records []*pb.DataRecord) (*pb.SyncResponse, error) {
 	}
 
 	ctx := c.authContext()
-	req := &pb.SyncRequest{Records: encryptedRecords}
+	req := &pb.SyncRequest{Records: encryptedRecords, Deletions: deletions, Cursor: cursor}
 	syncResp, err := c.service.SyncData(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
+	result := &SyncResult{SyncResponse: syncResp}
+	decrypted := syncResp.Records[:0]
 	for _, record := range syncResp.Records {
-		plaintext, err := c.crypto.Decrypt(record.EncryptedData)
-		if err != nil {
+		if err := c.decryptRecord(record); err != nil {
+			logger.Logg.Warn("ошибка расшифрования записи", "id", record.Id, "error", err)
+			result.Undecryptable = append(result.Undecryptable, record.Id)
 			continue
 		}
-		record.EncryptedData = plaintext
+		decrypted = append(decrypted, record)
 	}
+	syncResp.Records = decrypted
 
-	return syncResp, nil
+	return result, nil
 }
 
 // DeleteData удаляет запись по ID.
@@ -249,22 +299,59 @@ func (c *Client) Logout(refreshToken string) error {
 	return err
 }
 
-// encryptRecord шифрует данные одной записи
+// encryptRecord шифрует данные и метаданные одной записи.
+// ID, тип и метаданные записи входят в AAD данных: сервер не может незаметно
+// поменять их или переставить зашифрованные данные между записями.
+// Открытые метаданные на сервер не отправляются.
 func (c *Client) encryptRecord(record *pb.DataRecord) (*pb.DataRecord, error) {
 	if record == nil {
 		return nil, nil
 	}
 
-	encryptedData, err := c.crypto.Encrypt(record.EncryptedData)
+	encryptedData, err := c.crypto.EncryptWithAAD(record.EncryptedData, crypto.RecordAAD(record.Id, record.Type, record.Metadata))
 	if err != nil {
 		return nil, fmt.Errorf("ошибка шифрования записи %s: %w", record.Id, err)
 	}
 
+	encryptedMetadata, err := c.encryptMetadata(record)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка шифрования метаданных записи %s: %w", record.Id, err)
+	}
+
 	return &pb.DataRecord{
-		Id:            record.Id,
-		Type:          record.Type,
-		EncryptedData: encryptedData,
-		Metadata:      record.Metadata,
-		Timestamp:     record.Timestamp,
+		Id:                record.Id,
+		Type:              record.Type,
+		EncryptedData:     encryptedData,
+		EncryptedMetadata: encryptedMetadata,
+		BlindIndexes:      c.blindIndexes(record.Metadata),
+		Timestamp:         record.Timestamp,
+		Revision:          record.Revision,
 	}, nil
 }
+
+// decryptRecord расшифровывает метаданные и данные записи на месте, проверяя ID, тип и метаданные.
+// Содержимое, загруженное через UploadBinary, расшифровывается отдельно при скачивании;
+// в данных такой записи — только описание содержимого (его может не быть у старых записей).
+// При ошибке запись не меняется.
+func (c *Client) decryptRecord(record *pb.DataRecord) error {
+	attrs, err := c.decryptMetadata(record)
+	if err != nil {
+		return err
+	}
+	metadata := attrs.Metadata
+
+	plaintext := record.EncryptedData
+	if record.BlobSize == 0 || len(record.EncryptedData) > 0 {
+		plaintext, err = c.crypto.DecryptWithAAD(record.EncryptedData, crypto.RecordAAD(record.Id, record.Type, metadata))
+		if err != nil {
+			return err
+		}
+	}
+
+	record.EncryptedData = plaintext
+	record.Metadata = metadata
+	record.Folder = attrs.Folder
+	record.Tags = attrs.Tags
+	record.EncryptedMetadata = nil
+	return nil
+}
@@ -1,23 +1,30 @@
 // Factory создаёт клиент с проверкой мастер-пароля и восстановлением сессии
 package client
 
-import "github.com/dvkhr/gophkeeper/client/session"
+import (
+	"github.com/dvkhr/gophkeeper/client/session"
+	"github.com/dvkhr/gophkeeper/client/storage/vault"
+	"github.com/dvkhr/gophkeeper/pkg/logger"
+)
 
 type Factory struct {
 	sessionMgr    *session.Manager
 	authenticator *Authenticator
 	serverAddress string
+	searchKeys    []string
 }
 
-func NewFactory(sessionMgr *session.Manager, authenticator *Authenticator, serverAddress string) *Factory {
+func NewFactory(sessionMgr *session.Manager, authenticator *Authenticator, serverAddress string, searchKeys []string) *Factory {
 	return &Factory{
 		sessionMgr:    sessionMgr,
 		authenticator: authenticator,
 		serverAddress: serverAddress,
+		searchKeys:    searchKeys,
 	}
 }
 
-// NewAuthenticatedClient создаёт клиент с проверкой пароля и восстановлением токенов
+// NewAuthenticatedClient создаёт клиент с проверкой пароля и восстановлением токенов.
+// Клиент открывает локальное хранилище записей, зашифрованное тем же ключом.
 func (f *Factory) NewAuthenticatedClient() (*Client, error) {
 	if ok, _ := f.sessionMgr.IsAuthenticated(); !ok {
 		return nil, ErrUnauthorized
@@ -28,15 +35,53 @@ func (f *Factory) NewAuthenticatedClient() (*Client, error) {
 		return nil, err
 	}
 
+	return f.openClient(key)
+}
+
+// openClient создаёт клиент с ключом хранилища key, открывает локальное хранилище и восстанавливает токены.
+func (f *Factory) openClient(key []byte) (*Client, error) {
 	client, err := NewClient(f.serverAddress, key)
 	if err != nil {
 		return nil, err
 	}
+	client.SetSearchKeys(f.searchKeys)
+
+	sess, err := f.sessionMgr.Load()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	client.vault, err = vault.Open(vault.PathFor(sess.UserID), key)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
 
-	sess, _ := f.sessionMgr.Load()
 	if sess.AccessToken != "" && sess.RefreshToken != "" {
 		_ = client.SetToken(sess.AccessToken, sess.RefreshToken)
 	}
 
+	// Сессии, созданные до появления серверного ключа хранилища, отправляют свой ключ на сервер
+	if sess.VaultKeyVersion == 0 && len(sess.WrappedKey) > 0 {
+		version, err := client.PublishVaultKey(sess)
+		if err != nil {
+			logger.Logg.Warn("Не удалось сохранить ключ хранилища на сервере", "error", err)
+		} else if err := f.saveVaultKeyVersion(version); err != nil {
+			logger.Logg.Warn("Не удалось сохранить сессию", "error", err)
+		}
+	}
+
 	return client, nil
 }
+
+// saveVaultKeyVersion сохраняет в сессии версию ключа хранилища на сервере.
+// Сессия перечитывается: токены в ней могли обновиться во время запроса.
+func (f *Factory) saveVaultKeyVersion(version int64) error {
+	sess, err := f.sessionMgr.Load()
+	if err != nil {
+		return err
+	}
+	sess.VaultKeyVersion = version
+	return f.sessionMgr.Save(sess)
+}
@@ -0,0 +1,33 @@
+// Folders — служебные записи пустых папок
+package client
+
+import (
+	"encoding/hex"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+)
+
+// folderIDPrefix начинает ID служебных записей папок.
+const folderIDPrefix = "folder-"
+
+// FolderRecord возвращает служебную запись папки path, которая хранит папку, пока в ней нет записей.
+// ID записи вычисляется из пути ключом хранилища: сервер не видит имя папки,
+// а mkdir одной папки на разных устройствах создаёт одну и ту же запись.
+func (c *Client) FolderRecord(path string) (*pb.DataRecord, error) {
+	p, err := payload.Encode(payload.TypeFolder, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := payload.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.DataRecord{
+		Id:            folderIDPrefix + hex.EncodeToString(c.index.FolderID(path)[:16]),
+		Type:          payload.TypeFolder,
+		EncryptedData: data,
+		Folder:        path,
+	}, nil
+}
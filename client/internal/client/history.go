@@ -0,0 +1,35 @@
+package client
+
+import (
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/logger"
+)
+
+// ListVersions запрашивает прежние версии записи и расшифровывает их.
+func (c *Client) ListVersions(id string) ([]*pb.RecordVersion, error) {
+	resp, err := c.service.ListVersions(c.authContext(), &pb.ListVersionsRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, version := range resp.Versions {
+		if err := c.decryptRecord(version.Record); err != nil {
+			logger.Logg.Warn("ошибка расшифрования версии записи", "id", id, "revision", version.Record.Revision)
+		}
+	}
+	return resp.Versions, nil
+}
+
+// RestoreVersion делает версию revision записи id текущей.
+// baseRevision — ревизия записи, которую знает клиент (0 — без проверки).
+func (c *Client) RestoreVersion(id string, revision, baseRevision int64) (int64, error) {
+	resp, err := c.service.RestoreVersion(c.authContext(), &pb.RestoreVersionRequest{
+		Id:           id,
+		Revision:     revision,
+		BaseRevision: baseRevision,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Revision, nil
+}
@@ -0,0 +1,110 @@
+// Metadata — шифрование метаданных, папок и меток записей и поиск по слепым индексам
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/crypto"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotSearchable возвращается при поиске по ключу метаданных, для которого не строятся слепые индексы.
+var ErrNotSearchable = errors.New("поиск на сервере по этому ключу метаданных не включён")
+
+// SetSearchKeys задаёт ключи метаданных, по которым разрешён поиск на сервере.
+// Для значений этих ключей в запись добавляются слепые индексы; остальные метаданные сервер не видит совсем.
+func (c *Client) SetSearchKeys(keys []string) {
+	c.searchKeys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		c.searchKeys[key] = true
+	}
+}
+
+// Searchable сообщает, строятся ли слепые индексы для ключа метаданных key.
+func (c *Client) Searchable(key string) bool {
+	return c.searchKeys[key]
+}
+
+// FindData запрашивает у сервера записи, у которых метаданные key равны value.
+// Сервер сравнивает только слепые индексы, поэтому key должен быть в списке SetSearchKeys.
+func (c *Client) FindData(key, value string) (*pb.DataResponse, error) {
+	if !c.Searchable(key) {
+		return nil, fmt.Errorf("%w: %s", ErrNotSearchable, key)
+	}
+	return c.getData(&pb.GetDataRequest{BlindIndexes: [][]byte{c.index.Index(key, value)}})
+}
+
+// FindDataWithKey запрашивает у сервера записи, в метаданных которых есть ключ key с любым значением.
+// Находятся только записи, сохранённые после включения поиска по key.
+func (c *Client) FindDataWithKey(key string) (*pb.DataResponse, error) {
+	if !c.Searchable(key) {
+		return nil, fmt.Errorf("%w: %s", ErrNotSearchable, key)
+	}
+	return c.getData(&pb.GetDataRequest{BlindIndexes: [][]byte{c.index.KeyIndex(key)}})
+}
+
+// encryptMetadata шифрует метаданные, папку и метки записи. AAD — ID и тип записи.
+// Записи без папки и меток хранят метаданные JSON-объектом, как до их появления,
+// остальные — сообщением RecordAttributes. Для записи без всего этого возвращает nil.
+func (c *Client) encryptMetadata(record *pb.DataRecord) ([]byte, error) {
+	var (
+		plaintext []byte
+		err       error
+	)
+	switch {
+	case record.Folder != "" || len(record.Tags) > 0:
+		plaintext, err = proto.Marshal(&pb.RecordAttributes{
+			Metadata: record.Metadata,
+			Folder:   record.Folder,
+			Tags:     record.Tags,
+		})
+	case len(record.Metadata) > 0:
+		plaintext, err = json.Marshal(record.Metadata)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.crypto.EncryptWithAAD(plaintext, crypto.MetadataAAD(record.Id, record.Type))
+}
+
+// decryptMetadata возвращает метаданные, папку и метки записи.
+// Записи, сохранённые до шифрования метаданных, хранят их открыто в поле Metadata.
+func (c *Client) decryptMetadata(record *pb.DataRecord) (*pb.RecordAttributes, error) {
+	if len(record.EncryptedMetadata) == 0 {
+		return &pb.RecordAttributes{Metadata: record.Metadata}, nil
+	}
+
+	plaintext, err := c.crypto.DecryptWithAAD(record.EncryptedMetadata, crypto.MetadataAAD(record.Id, record.Type))
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON-объект начинается с '{'; сериализованное RecordAttributes так начаться не может
+	attrs := &pb.RecordAttributes{}
+	if len(plaintext) > 0 && plaintext[0] == '{' {
+		err = json.Unmarshal(plaintext, &attrs.Metadata)
+	} else {
+		err = proto.Unmarshal(plaintext, attrs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("повреждены метаданные записи %s: %w", record.Id, err)
+	}
+	return attrs, nil
+}
+
+// blindIndexes возвращает слепые индексы метаданных для ключей из списка поиска:
+// индекс пары «ключ — значение» и индекс наличия ключа.
+func (c *Client) blindIndexes(metadata map[string]string) [][]byte {
+	var indexes [][]byte
+	for key, value := range metadata {
+		if c.Searchable(key) {
+			indexes = append(indexes, c.index.Index(key, value), c.index.KeyIndex(key))
+		}
+	}
+	return indexes
+}
@@ -0,0 +1,129 @@
+// Offline — работа с локальным хранилищем записей при недоступном сервере
+package client
+
+import (
+	"errors"
+
+	"github.com/dvkhr/gophkeeper/client/storage/vault"
+	"github.com/dvkhr/gophkeeper/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrNoVault возвращается, если клиент создан без локального хранилища.
+var ErrNoVault = errors.New("локальное хранилище не открыто")
+
+// IsOffline сообщает, что ошибка вызвана недоступностью сервера.
+func IsOffline(err error) bool {
+	st, ok := status.FromError(err)
+	return ok && st.Code() == codes.Unavailable
+}
+
+// Vault возвращает локальное хранилище записей.
+func (c *Client) Vault() *vault.Vault {
+	return c.vault
+}
+
+// PutLocal сохраняет запись в локальное хранилище и ставит её в очередь на отправку.
+func (c *Client) PutLocal(record *pb.DataRecord) error {
+	if c.vault == nil {
+		return ErrNoVault
+	}
+
+	c.vault.Put(record)
+	return c.vault.Save()
+}
+
+// DeleteLocal удаляет запись из локального хранилища и ставит удаление в очередь на отправку.
+func (c *Client) DeleteLocal(id string) error {
+	if c.vault == nil {
+		return ErrNoVault
+	}
+
+	if err := c.vault.Delete(id); err != nil {
+		return err
+	}
+	return c.vault.Save()
+}
+
+// Sync отправляет на сервер накопленные локальные изменения и применяет
+// к локальному хранилищу изменения, появившиеся на сервере после последней синхронизации.
+// При конфликтах выполняется повторная синхронизация: на сервер уходят локальные копии
+// конфликтных записей, а в хранилище загружаются их серверные версии.
+func (c *Client) Sync() (*SyncResult, error) {
+	if c.vault == nil {
+		return nil, ErrNoVault
+	}
+
+	resp, err := c.syncOnce()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Conflicts) > 0 {
+		retry, err := c.syncOnce()
+		if err != nil {
+			return nil, err
+		}
+		resp.Rejected = append(resp.Rejected, retry.Rejected...)
+		resp.Undecryptable = append(resp.Undecryptable, retry.Undecryptable...)
+	}
+
+	return resp, nil
+}
+
+// syncOnce выполняет один обмен изменениями между локальным хранилищем и сервером.
+func (c *Client) syncOnce() (*SyncResult, error) {
+	records, deletions := c.vault.Pending()
+
+	resp, err := c.SyncData(records, deletions, c.vault.Cursor())
+	if err != nil {
+		return nil, err
+	}
+
+	c.vault.Apply(resp.SyncResponse)
+	if err := c.vault.Save(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// PutBatches сохраняет записи в локальное хранилище и отправляет их на сервер порциями по size записей:
+// каждая порция уходит отдельным вызовом SyncData, поэтому большой импорт не упирается
+// в предельный размер сообщения gRPC. progress вызывается после каждой отправленной порции.
+// Возвращает число отправленных записей. Если отправка прервалась, записи неудавшейся порции
+// остаются в очереди локального хранилища, а следующие порции в него не попадают.
+func (c *Client) PutBatches(records []*pb.DataRecord, size int, progress func(sent int)) (int, error) {
+	if c.vault == nil {
+		return 0, ErrNoVault
+	}
+	if size <= 0 {
+		size = len(records)
+	}
+
+	sent := 0
+	for start := 0; start < len(records); start += size {
+		batch := records[start:min(start+size, len(records))]
+		for _, record := range batch {
+			c.vault.Put(record)
+		}
+		if err := c.vault.Save(); err != nil {
+			return sent, err
+		}
+
+		err := c.DoWithRetry(func() error {
+			_, err := c.Sync()
+			return err
+		})
+		if err != nil {
+			return sent, err
+		}
+
+		sent += len(batch)
+		if progress != nil {
+			progress(sent)
+		}
+	}
+	return sent, nil
+}
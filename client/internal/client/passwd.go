@@ -0,0 +1,72 @@
+// Passwd — смена мастер-пароля
+package client
+
+import (
+	"fmt"
+
+	"github.com/dvkhr/gophkeeper/client/storage/file"
+	"github.com/dvkhr/gophkeeper/pb"
+)
+
+// ChangeMasterPassword отправляет на сервер новый пароль и ключ хранилища, зашифрованный новым мастер-ключом.
+func (c *Client) ChangeMasterPassword(oldPassword, newPassword []byte, key *pb.VaultKey, expectedVersion int64) (*pb.ChangeMasterPasswordResponse, error) {
+	return c.service.ChangeMasterPassword(c.authContext(), &pb.ChangeMasterPasswordRequest{
+		OldPassword:     oldPassword,
+		NewPassword:     newPassword,
+		Key:             key,
+		ExpectedVersion: expectedVersion,
+	})
+}
+
+// ChangeMasterPassword меняет мастер-пароль: проверяет текущий пароль локально,
+// шифрует ключ хранилища ключом из нового пароля с новой солью и сохраняет его на сервере.
+// Ключ хранилища не меняется, поэтому записи и локальное хранилище не перешифровываются.
+// Сервер завершает остальные сессии пользователя — другие устройства должны войти заново.
+func (f *Factory) ChangeMasterPassword(oldPassword, newPassword []byte) error {
+	if ok, _ := f.sessionMgr.IsAuthenticated(); !ok {
+		return ErrUnauthorized
+	}
+
+	key, err := f.authenticator.Unlock(oldPassword)
+	if err != nil {
+		return err
+	}
+
+	client, err := f.openClient(key)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	kek, vaultKey, err := WrapVaultKey(key, string(newPassword))
+	if err != nil {
+		return err
+	}
+
+	var resp *pb.ChangeMasterPasswordResponse
+	err = client.DoWithRetry(func() error {
+		sess, err := f.sessionMgr.Load()
+		if err != nil {
+			return err
+		}
+		resp, err = client.ChangeMasterPassword(oldPassword, newPassword, vaultKey, sess.VaultKeyVersion)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	session, err := file.Load()
+	if err != nil {
+		return fmt.Errorf("пароль изменён, но не удалось загрузить сессию: %w", err)
+	}
+
+	ApplyVaultKey(session, resp.Key, kek)
+	session.AccessToken = resp.Auth.AccessToken
+	session.RefreshToken = resp.Auth.RefreshToken
+
+	if err := file.Save(session); err != nil {
+		return fmt.Errorf("пароль изменён, но не удалось сохранить сессию: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/logger"
+)
+
+// ListDeleted запрашивает записи корзины и расшифровывает их.
+func (c *Client) ListDeleted() ([]*pb.DeletedRecord, error) {
+	resp, err := c.service.ListDeleted(c.authContext(), &pb.ListDeletedRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, deleted := range resp.Records {
+		if err := c.decryptRecord(deleted.Record); err != nil {
+			logger.Logg.Warn("ошибка расшифрования записи корзины", "id", deleted.Record.Id)
+		}
+	}
+	return resp.Records, nil
+}
+
+// RestoreData восстанавливает запись id из корзины и возвращает её новую ревизию.
+func (c *Client) RestoreData(id string) (int64, error) {
+	resp, err := c.service.RestoreData(c.authContext(), &pb.RestoreDataRequest{Id: id})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Revision, nil
+}
@@ -0,0 +1,154 @@
+// VaultKey — ключ хранилища, зашифрованный мастер-ключом и хранящийся на сервере
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dvkhr/gophkeeper/client/session"
+	"github.com/dvkhr/gophkeeper/client/storage/file"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/crypto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrVaultKeyChanged возвращается, если на сервере другой ключ хранилища, чем в локальной сессии.
+var ErrVaultKeyChanged = errors.New("ключ хранилища изменён на другом устройстве — выполните login заново")
+
+// NewVaultKey генерирует случайный ключ хранилища и шифрует его ключом из мастер-пароля.
+// Возвращает ключ хранилища, ключ из пароля и данные для отправки на сервер.
+func NewVaultKey(password string) ([]byte, []byte, *pb.VaultKey, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	kek, vaultKey, err := WrapVaultKey(key, password)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return key, kek, vaultKey, nil
+}
+
+// WrapVaultKey получает ключ из мастер-пароля с новой солью и шифрует им ключ хранилища key.
+// Возвращает ключ из пароля и данные для отправки на сервер.
+func WrapVaultKey(key []byte, password string) ([]byte, *pb.VaultKey, error) {
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kdf := crypto.DefaultKDFParams()
+	kek, err := kdf.Derive(password, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrapped, err := crypto.WrapKey(kek, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return kek, &pb.VaultKey{WrappedKey: wrapped, Salt: salt, Kdf: kdfToProto(kdf)}, nil
+}
+
+// OpenVaultKey получает ключ из мастер-пароля по параметрам с сервера и расшифровывает им ключ хранилища.
+// Возвращает ключ из пароля; ошибка означает неверный пароль.
+func OpenVaultKey(password string, vaultKey *pb.VaultKey) ([]byte, error) {
+	kek, err := kdfFromProto(vaultKey.Kdf).Derive(password, vaultKey.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := crypto.UnwrapKey(kek, vaultKey.WrappedKey); err != nil {
+		return nil, ErrInvalidPassword
+	}
+	return kek, nil
+}
+
+// ApplyVaultKey записывает в сессию ключ хранилища с сервера и хэш ключа из пароля.
+func ApplyVaultKey(sess *file.Data, vaultKey *pb.VaultKey, kek []byte) {
+	kdf := kdfFromProto(vaultKey.Kdf)
+
+	sess.Salt = vaultKey.Salt
+	sess.KDF = &kdf
+	sess.MasterKeyHash = crypto.SHA256(kek)
+	sess.WrappedKey = vaultKey.WrappedKey
+	sess.VaultKeyVersion = vaultKey.Version
+}
+
+// GetVaultKey запрашивает ключ хранилища с сервера.
+// Возвращает nil без ошибки, если ключ ещё не сохранён.
+func (c *Client) GetVaultKey() (*pb.VaultKey, error) {
+	key, err := c.service.GetVaultKey(c.authContext(), &pb.GetVaultKeyRequest{})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// StoreVaultKey сохраняет ключ хранилища на сервере.
+// expectedVersion — версия ключа, которую клиент считает текущей (0, если ключа ещё нет).
+func (c *Client) StoreVaultKey(key *pb.VaultKey, expectedVersion int64) (*pb.VaultKey, error) {
+	resp, err := c.service.StoreVaultKey(c.authContext(), &pb.StoreVaultKeyRequest{
+		Key:             key,
+		ExpectedVersion: expectedVersion,
+	})
+	if status.Code(err) == codes.Aborted {
+		return nil, ErrVaultKeyChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// PublishVaultKey отправляет на сервер ключ хранилища из сессии, созданной до появления серверного ключа.
+// После этого ключ хранилища можно получить на другом устройстве при входе.
+// Возвращает версию ключа на сервере.
+func (c *Client) PublishVaultKey(sess *session.Data) (int64, error) {
+	if len(sess.WrappedKey) == 0 || sess.KDF == nil {
+		return 0, fmt.Errorf("в сессии нет ключа хранилища")
+	}
+
+	var stored *pb.VaultKey
+	err := c.DoWithRetry(func() error {
+		var err error
+		stored, err = c.StoreVaultKey(&pb.VaultKey{
+			WrappedKey: sess.WrappedKey,
+			Salt:       sess.Salt,
+			Kdf:        kdfToProto(*sess.KDF),
+		}, 0)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return stored.Version, nil
+}
+
+// kdfToProto преобразует параметры KDF в сообщение protobuf.
+func kdfToProto(kdf crypto.KDFParams) *pb.KDFParams {
+	return &pb.KDFParams{
+		Algorithm:   kdf.Algorithm,
+		Iterations:  kdf.Iterations,
+		Memory:      kdf.Memory,
+		Parallelism: uint32(kdf.Parallelism),
+		KeyLength:   kdf.KeyLength,
+	}
+}
+
+// kdfFromProto преобразует сообщение protobuf в параметры KDF.
+func kdfFromProto(kdf *pb.KDFParams) crypto.KDFParams {
+	return crypto.KDFParams{
+		Algorithm:   kdf.GetAlgorithm(),
+		Iterations:  kdf.GetIterations(),
+		Memory:      kdf.GetMemory(),
+		Parallelism: uint8(kdf.GetParallelism()),
+		KeyLength:   kdf.GetKeyLength(),
+	}
+}
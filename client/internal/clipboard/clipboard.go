@@ -0,0 +1,53 @@
+// Package clipboard копирует секреты в системный буфер обмена и очищает его по таймауту.
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrUnavailable возвращается, если в системе не найдена утилита для работы с буфером обмена.
+var ErrUnavailable = errors.New("буфер обмена недоступен: установите wl-clipboard, xclip или xsel")
+
+// Clipboard — системный буфер обмена.
+type Clipboard interface {
+	// Read возвращает текущее содержимое буфера.
+	Read() (string, error)
+	// Write заменяет содержимое буфера на text.
+	Write(text string) error
+}
+
+// CopyWithClear записывает secret в буфер и ждёт timeout или отмены ctx.
+// После этого буфер очищается, если в нём всё ещё secret: скопированное
+// пользователем позже не затирается. Возвращает true, если буфер очищен.
+func CopyWithClear(ctx context.Context, cb Clipboard, secret string, timeout time.Duration) (bool, error) {
+	if err := cb.Write(secret); err != nil {
+		return false, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	return Clear(cb, secret)
+}
+
+// Clear очищает буфер, если в нём всё ещё secret.
+func Clear(cb Clipboard, secret string) (bool, error) {
+	current, err := cb.Read()
+	if err != nil {
+		return false, err
+	}
+	if current != secret {
+		return false, nil
+	}
+	if err := cb.Write(""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
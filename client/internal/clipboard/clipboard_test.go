@@ -0,0 +1,52 @@
+package clipboard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyWithClear_ClearsAfterTimeout(t *testing.T) {
+	cb := &Fake{}
+
+	cleared, err := CopyWithClear(context.Background(), cb, "s3cret", 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, cleared)
+
+	text, _ := cb.Read()
+	assert.Empty(t, text)
+	assert.Equal(t, 2, cb.Writes())
+}
+
+// содержимое, скопированное пользователем после секрета, не затирается
+func TestCopyWithClear_KeepsNewContent(t *testing.T) {
+	cb := &Fake{}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		_ = cb.Write("other")
+	}()
+
+	cleared, err := CopyWithClear(context.Background(), cb, "s3cret", 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.False(t, cleared)
+
+	text, _ := cb.Read()
+	assert.Equal(t, "other", text)
+}
+
+// при отмене контекста буфер очищается сразу, не дожидаясь таймаута
+func TestCopyWithClear_Cancel(t *testing.T) {
+	cb := &Fake{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	cleared, err := CopyWithClear(ctx, cb, "s3cret", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, cleared)
+	assert.Less(t, time.Since(start), time.Second)
+}
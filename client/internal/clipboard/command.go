@@ -0,0 +1,74 @@
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Command — буфер обмена через внешние утилиты: wl-copy/wl-paste под Wayland, xclip или xsel под X11.
+type Command struct {
+	copyCmd  []string
+	pasteCmd []string
+}
+
+// backends перечисляет поддерживаемые утилиты в порядке предпочтения.
+var backends = []struct {
+	env      string
+	copyCmd  []string
+	pasteCmd []string
+}{
+	{"WAYLAND_DISPLAY", []string{"wl-copy"}, []string{"wl-paste", "--no-newline"}},
+	{"DISPLAY", []string{"xclip", "-selection", "clipboard", "-in"}, []string{"xclip", "-selection", "clipboard", "-out"}},
+	{"DISPLAY", []string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"}},
+}
+
+// Detect выбирает утилиту буфера обмена по переменным окружения графической сессии и установленным программам.
+func Detect() (*Command, error) {
+	for _, b := range backends {
+		if os.Getenv(b.env) == "" {
+			continue
+		}
+		if _, err := exec.LookPath(b.copyCmd[0]); err != nil {
+			continue
+		}
+		if _, err := exec.LookPath(b.pasteCmd[0]); err != nil {
+			continue
+		}
+		return &Command{copyCmd: b.copyCmd, pasteCmd: b.pasteCmd}, nil
+	}
+	return nil, ErrUnavailable
+}
+
+// Name возвращает имя используемой утилиты.
+func (c *Command) Name() string {
+	return c.copyCmd[0]
+}
+
+// Read возвращает содержимое буфера.
+func (c *Command) Read() (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(c.pasteCmd[0], c.pasteCmd[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// wl-paste завершается с ошибкой, если буфер пуст
+		if strings.Contains(stderr.String(), "No selection") || strings.Contains(stderr.String(), "Nothing is copied") {
+			return "", nil
+		}
+		return "", fmt.Errorf("не удалось прочитать буфер обмена (%s): %w", c.pasteCmd[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// Write записывает text в буфер. Секрет передаётся через stdin и не попадает в аргументы процесса.
+func (c *Command) Write(text string) error {
+	cmd := exec.Command(c.copyCmd[0], c.copyCmd[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("не удалось записать в буфер обмена (%s): %w", c.copyCmd[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package clipboard
+
+import "sync"
+
+// Fake — буфер обмена в памяти для тестов.
+type Fake struct {
+	mu     sync.Mutex
+	text   string
+	writes int
+}
+
+// Read возвращает содержимое буфера.
+func (f *Fake) Read() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.text, nil
+}
+
+// Write заменяет содержимое буфера.
+func (f *Fake) Write(text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.text = text
+	f.writes++
+	return nil
+}
+
+// Writes возвращает число записей в буфер.
+func (f *Fake) Writes() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writes
+}
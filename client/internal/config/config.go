@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 
 	"github.com/dvkhr/gophkeeper/pkg/logger"
 )
@@ -10,11 +11,18 @@ type Config struct {
 	Server struct {
 		Address string `yaml:"address"`
 	} `yaml:"server"`
+	Search struct {
+		// Keys — ключи метаданных, по которым разрешён поиск на сервере через слепые индексы.
+		Keys []string `yaml:"keys"`
+	} `yaml:"search"`
 }
 
-func Load(flagAddress string) *Config {
+func Load(flagAddress, flagSearchKeys string) *Config {
 	cfg := &Config{}
 
+	// Значение флага --search-keys или переменной GK_SEARCH_KEYS
+	cfg.Search.Keys = parseSearchKeys(flagSearchKeys)
+
 	if envAddr := os.Getenv("GK_SERVER"); envAddr != "" {
 		if err := ValidateServerAddress(envAddr); err != nil {
 			logger.Logg.Warn("Invalid server address in GK_SERVER, skipped",
@@ -49,3 +57,14 @@ func Load(flagAddress string) *Config {
 
 	return cfg
 }
+
+// parseSearchKeys разбирает список ключей метаданных через запятую.
+func parseSearchKeys(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
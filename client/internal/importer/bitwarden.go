@@ -0,0 +1,179 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Типы записей в JSON-экспорте Bitwarden.
+const (
+	bitwardenLogin    = 1
+	bitwardenNote     = 2
+	bitwardenCard     = 3
+	bitwardenIdentity = 4
+	bitwardenSSHKey   = 5
+)
+
+// bitwardenHiddenField — тип скрытого дополнительного поля Bitwarden.
+const bitwardenHiddenField = 1
+
+// bitwardenExport — JSON-экспорт Bitwarden.
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []bitwardenItem `json:"items"`
+}
+
+// bitwardenItem — запись JSON-экспорта Bitwarden.
+type bitwardenItem struct {
+	Type     int    `json:"type"`
+	Name     string `json:"name"`
+	Notes    string `json:"notes"`
+	FolderID string `json:"folderId"`
+	Favorite bool   `json:"favorite"`
+	Login    struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+		URIs     []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Card struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Fields []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		Type  int    `json:"type"`
+	} `json:"fields"`
+}
+
+// parseBitwardenJSON разбирает незашифрованный JSON-экспорт Bitwarden.
+// Дополнительные поля сохраняются в метаданных записи, скрытые — только в зашифрованном содержимом.
+func parseBitwardenJSON(r io.Reader) (*Result, error) {
+	var export bitwardenExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать экспорт Bitwarden: %w", err)
+	}
+	if export.Encrypted {
+		return nil, ErrEncryptedExport
+	}
+
+	folders := make(map[string]string, len(export.Folders))
+	for _, folder := range export.Folders {
+		folders[folder.ID] = folder.Name
+	}
+
+	res := &Result{}
+	for _, bw := range export.Items {
+		it := item{name: bw.Name, folder: folders[bw.FolderID], notes: bw.Notes}
+		if bw.Favorite {
+			it.tags = append(it.tags, favoriteTag)
+		}
+		for _, f := range bw.Fields {
+			if f.Type == bitwardenHiddenField {
+				it.secret(f.Name, f.Value)
+				continue
+			}
+			it.field(f.Name, f.Value)
+		}
+
+		switch bw.Type {
+		case bitwardenLogin:
+			it.kind = kindLogin
+			it.login, it.password, it.totp = bw.Login.Username, bw.Login.Password, bw.Login.TOTP
+			for _, uri := range bw.Login.URIs {
+				it.urls = append(it.urls, uri.URI)
+			}
+		case bitwardenNote:
+			it.kind = kindNote
+		case bitwardenCard:
+			it.kind = kindCard
+			it.holder, it.number, it.cvv, it.brand = bw.Card.CardholderName, bw.Card.Number, bw.Card.Code, bw.Card.Brand
+			it.expiry = cardExpiry(bw.Card.ExpMonth, bw.Card.ExpYear)
+		case bitwardenIdentity:
+			res.skip(bw.Name, "личные данные (identity) не поддерживаются")
+			continue
+		case bitwardenSSHKey:
+			res.skip(bw.Name, "SSH-ключи Bitwarden не поддерживаются, добавьте их командой add --type=sshkey")
+			continue
+		default:
+			res.skip(bw.Name, fmt.Sprintf("неизвестный тип записи Bitwarden: %d", bw.Type))
+			continue
+		}
+		res.add(it)
+	}
+	return res, nil
+}
+
+// parseBitwardenCSV разбирает CSV-экспорт Bitwarden. В нём есть только логины и заметки;
+// несколько URL разделены запятыми, дополнительные поля — строками "имя: значение".
+func parseBitwardenCSV(r io.Reader) (*Result, error) {
+	table, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	if !table.has("name") || !table.has("login_password") {
+		return nil, fmt.Errorf("не похоже на CSV-экспорт Bitwarden: нет столбцов name и login_password")
+	}
+
+	res := &Result{}
+	for _, row := range table.rows {
+		it := item{
+			name:   table.get(row, "name"),
+			folder: table.get(row, "folder"),
+			notes:  table.get(row, "notes"),
+		}
+		if table.get(row, "favorite") == "1" {
+			it.tags = append(it.tags, favoriteTag)
+		}
+		for _, line := range strings.Split(table.get(row, "fields"), "\n") {
+			if name, value, ok := strings.Cut(line, ":"); ok {
+				it.field(name, value)
+			}
+		}
+
+		switch kind := table.get(row, "type"); kind {
+		case "login", "":
+			it.kind = kindLogin
+			it.login = table.get(row, "login_username")
+			it.password = table.get(row, "login_password")
+			it.totp = table.get(row, "login_totp")
+			for _, uri := range strings.Split(table.get(row, "login_uri"), ",") {
+				if uri = strings.TrimSpace(uri); uri != "" {
+					it.urls = append(it.urls, uri)
+				}
+			}
+		case "note":
+			it.kind = kindNote
+		default:
+			res.skip(it.name, "тип записи не поддерживается: "+kind)
+			continue
+		}
+		res.add(it)
+	}
+	return res, nil
+}
+
+// cardExpiry собирает срок действия карты MM/YY из месяца и года; пустую строку, если их нет.
+func cardExpiry(month, year string) string {
+	month, year = strings.TrimSpace(month), strings.TrimSpace(year)
+	if month == "" || year == "" {
+		return ""
+	}
+	if len(month) == 1 {
+		month = "0" + month
+	}
+	return month + "/" + year
+}
@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bitwardenJSON = `{
+  "encrypted": false,
+  "folders": [{"id": "f1", "name": "Work/Dev"}],
+  "items": [
+    {
+      "type": 1, "name": "GitHub", "folderId": "f1", "favorite": true, "notes": null,
+      "login": {"username": "vasia", "password": "hunter2", "totp": "otpauth://totp/GitHub:vasia?secret=JBSWY3DPEHPK3PXP",
+                "uris": [{"match": null, "uri": "https://github.com"}]},
+      "fields": [{"name": "recovery", "value": "abc-def", "type": 1}, {"name": "team", "value": "backend", "type": 0}]
+    },
+    {"type": 2, "name": "Wi-Fi", "folderId": null, "notes": "пароль от роутера", "secureNote": {"type": 0}},
+    {
+      "type": 3, "name": "Visa", "folderId": null,
+      "card": {"cardholderName": "VASILY PUPKIN", "brand": "Visa", "number": "4111 1111 1111 1111",
+               "expMonth": "7", "expYear": "2027", "code": "123"}
+    },
+    {"type": 4, "name": "Паспорт", "identity": {"firstName": "Vasily"}}
+  ]
+}`
+
+func TestParseBitwardenJSON(t *testing.T) {
+	res, err := Parse(FormatBitwardenJSON, strings.NewReader(bitwardenJSON))
+	require.NoError(t, err)
+
+	require.Len(t, res.Entries, 5)
+
+	login := res.Entries[0]
+	assert.Equal(t, "GitHub", login.Name)
+	assert.Equal(t, payload.TypeLoginPass, login.Type)
+	assert.Equal(t, "hunter2", login.Values["password"])
+	assert.Equal(t, map[string]string{"url": "https://github.com", "team": "backend"}, login.Metadata)
+	assert.Equal(t, "Work/Dev", login.Folder)
+	assert.Equal(t, []string{favoriteTag}, login.Tags)
+
+	totp := res.Entries[1]
+	assert.Equal(t, payload.TypeTOTP, totp.Type)
+	assert.Equal(t, "otpauth://totp/GitHub:vasia?secret=JBSWY3DPEHPK3PXP", totp.Values["uri"])
+
+	// скрытое поле не попадает в метаданные, а хранится в содержимом отдельной заметки
+	secrets := res.Entries[2]
+	assert.Equal(t, payload.TypeText, secrets.Type)
+	assert.Equal(t, "recovery: abc-def", secrets.Values["content"])
+	assert.Nil(t, secrets.Metadata)
+	assert.Equal(t, []string{"recovery"}, secrets.SecretFields)
+	assert.Equal(t, "Work/Dev", secrets.Folder)
+
+	note := res.Entries[3]
+	assert.Equal(t, payload.TypeText, note.Type)
+	assert.Equal(t, "пароль от роутера", note.Values["content"])
+
+	card := res.Entries[4]
+	assert.Equal(t, payload.TypeCard, card.Type)
+	assert.Equal(t, "07/2027", card.Values["expiry"])
+	assert.Equal(t, "VASILY PUPKIN", card.Values["holder"])
+	assert.Equal(t, map[string]string{"brand": "Visa"}, card.Metadata)
+
+	require.Len(t, res.Skipped, 1)
+	assert.Equal(t, "Паспорт", res.Skipped[0].Name)
+
+	plan, err := NewPlan(res, func(string) bool { return false }, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github", "github-totp", "github-secrets", "wi-fi", "visa"}, recordIDs(plan.Records))
+	assert.Equal(t, "Work/Dev", plan.Records[0].Folder)
+	assert.Len(t, plan.Skipped, 1)
+	require.Len(t, plan.Secrets, 1)
+	assert.Equal(t, "GitHub secrets", plan.Secrets[0].Name)
+	assert.Contains(t, plan.Secrets[0].Reason, "recovery")
+	assert.Contains(t, plan.Secrets[0].Reason, "github-secrets")
+}
+
+func TestParseBitwardenJSON_Encrypted(t *testing.T) {
+	_, err := Parse(FormatBitwardenJSON, strings.NewReader(`{"encrypted": true, "encKeyValidation_DO_NOT_EDIT": "2.x"}`))
+	assert.ErrorIs(t, err, ErrEncryptedExport)
+
+	_, err = Parse(FormatBitwardenJSON, strings.NewReader(`not json`))
+	assert.Error(t, err)
+}
+
+func TestParseBitwardenCSV(t *testing.T) {
+	data := "\ufefffolder,favorite,type,name,notes,fields,reprompt,login_uri,login_username,login_password,login_totp\n" +
+		"Social,1,login,Twitter,,\"team: smm\nenv: prod\",0,\"https://twitter.com,https://x.com\",vasia,secret,JBSWY3DPEHPK3PXP\n" +
+		",,note,Рецепт,\"мука, яйца\",,0,,,,\n" +
+		",,card,Старый формат,,,0,,,,\n"
+
+	res, err := Parse(FormatBitwardenCSV, strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 3)
+
+	login := res.Entries[0]
+	assert.Equal(t, "Twitter", login.Name)
+	assert.Equal(t, "Social", login.Folder)
+	assert.Equal(t, []string{favoriteTag}, login.Tags)
+	assert.Equal(t, map[string]string{
+		"url":  "https://twitter.com",
+		"url2": "https://x.com",
+		"team": "smm",
+		"env":  "prod",
+	}, login.Metadata)
+
+	totp := res.Entries[1]
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", totp.Values["secret"])
+	assert.Equal(t, "vasia", totp.Values["account"])
+
+	assert.Equal(t, "мука, яйца", res.Entries[2].Values["content"])
+
+	require.Len(t, res.Skipped, 1)
+	assert.Equal(t, "Старый формат", res.Skipped[0].Name)
+
+	_, err = Parse(FormatBitwardenCSV, strings.NewReader("title,username\nGitHub,vasia\n"))
+	assert.Error(t, err)
+}
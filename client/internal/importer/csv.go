@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvTable — строки CSV-экспорта с доступом к столбцам по имени.
+type csvTable struct {
+	names   []string // Имена столбцов в порядке заголовка
+	columns map[string]int
+	rows    [][]string
+}
+
+// readCSV читает CSV с заголовком. Имена столбцов сравниваются без учёта регистра и пробелов по краям.
+func readCSV(r io.Reader) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("пустой CSV: нет заголовка")
+	}
+
+	header := records[0]
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	table := &csvTable{columns: make(map[string]int, len(header)), rows: records[1:]}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		table.names = append(table.names, name)
+		if _, dup := table.columns[name]; !dup {
+			table.columns[name] = i
+		}
+	}
+	return table, nil
+}
+
+// has сообщает, что в таблице есть хотя бы один из столбцов names.
+func (t *csvTable) has(names ...string) bool {
+	for _, name := range names {
+		if _, ok := t.columns[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// get возвращает значение первого непустого из столбцов names в строке row.
+func (t *csvTable) get(row []string, names ...string) string {
+	for _, name := range names {
+		if i, ok := t.columns[name]; ok && i < len(row) {
+			if value := strings.TrimSpace(row[i]); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
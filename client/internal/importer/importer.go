@@ -0,0 +1,380 @@
+// Package importer разбирает экспорт других менеджеров паролей (Bitwarden, KeePass, 1Password)
+// и превращает его записи в записи GophKeeper типов loginpass, card, text и totp.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dvkhr/gophkeeper/client/internal/catalog"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+)
+
+// Поддерживаемые форматы экспорта.
+const (
+	FormatBitwardenJSON  = "bitwarden-json"
+	FormatBitwardenCSV   = "bitwarden-csv"
+	FormatKeePassXML     = "keepass-xml"
+	FormatOnePasswordCSV = "1password-csv"
+)
+
+// Ключи метаданных, в которые попадают стандартные поля экспорта.
+const (
+	metaURL   = "url"
+	metaNotes = "notes"
+)
+
+const (
+	maxIDLength   = 64
+	defaultID     = "item"
+	favoriteTag   = "favorite"
+	totpSuffix    = " totp"
+	secretsSuffix = " secrets"
+)
+
+var (
+	// ErrUnknownFormat возвращается для неподдерживаемого формата экспорта.
+	ErrUnknownFormat = errors.New("неизвестный формат экспорта")
+
+	// ErrEncryptedExport возвращается для экспорта, зашифрованного паролем менеджера.
+	ErrEncryptedExport = errors.New("экспорт зашифрован — выгрузите его без шифрования")
+)
+
+// Entry — запись из экспорта, приведённая к типу GophKeeper.
+type Entry struct {
+	Name     string            // Название записи в исходном менеджере, из него строится ID
+	Type     string            // Тип записи GophKeeper
+	Values   map[string]string // Значения полей типа
+	Metadata map[string]string // URL, заметки и дополнительные поля
+	Folder   string            // Папка или группа в исходном менеджере
+	Tags     []string          // Метки
+
+	// SecretFields — названия скрытых полей, которые записаны в содержимое этой заметки,
+	// а не в метаданные: метаданные видны в списке записей и в выводе get
+	SecretFields []string
+}
+
+// Skipped — запись экспорта, которая не будет импортирована.
+type Skipped struct {
+	Name   string
+	Reason string
+}
+
+// Result — разобранный экспорт.
+type Result struct {
+	Entries []Entry
+	Skipped []Skipped // Записи неподдерживаемых типов
+}
+
+// Formats возвращает поддерживаемые форматы экспорта.
+func Formats() []string {
+	return []string{FormatBitwardenJSON, FormatBitwardenCSV, FormatKeePassXML, FormatOnePasswordCSV}
+}
+
+// Parse разбирает экспорт в формате format.
+func Parse(format string, r io.Reader) (*Result, error) {
+	switch format {
+	case FormatBitwardenJSON:
+		return parseBitwardenJSON(r)
+	case FormatBitwardenCSV:
+		return parseBitwardenCSV(r)
+	case FormatKeePassXML:
+		return parseKeePassXML(r)
+	case FormatOnePasswordCSV:
+		return parseOnePasswordCSV(r)
+	default:
+		return nil, fmt.Errorf("%w: %s (поддерживаются: %s)", ErrUnknownFormat, format, strings.Join(Formats(), ", "))
+	}
+}
+
+// Options — параметры импорта.
+type Options struct {
+	Folder string // Папка, в которую помещаются импортированные записи
+	Rename bool   // Сохранять записи с занятыми ID под новым ID вместо пропуска
+}
+
+// Plan — записи, подготовленные к загрузке.
+type Plan struct {
+	Records    []*pb.DataRecord
+	Duplicates []Skipped // Записи, ID которых уже есть в хранилище
+	Skipped    []Skipped // Записи неподдерживаемых типов и с неверными данными
+	Secrets    []Skipped // Заметки со скрытыми полями записей: в Reason — ID заметки и названия полей
+}
+
+// NewPlan строит записи GophKeeper из разобранного экспорта.
+// ID записи строится из её названия; одинаковые названия внутри экспорта получают суффиксы -2, -3 и т.д.
+// Запись, ID которой уже занят (exists), пропускается или, при opts.Rename, получает свободный ID.
+// Повторный импорт того же файла без Rename ничего не добавляет.
+func NewPlan(res *Result, exists func(id string) bool, opts Options) (*Plan, error) {
+	base, err := catalog.CleanPath(opts.Folder)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Skipped: append([]Skipped(nil), res.Skipped...)}
+	taken := make(map[string]bool)
+
+	for _, entry := range res.Entries {
+		data, err := entry.payload()
+		if err != nil {
+			plan.Skipped = append(plan.Skipped, Skipped{Name: entry.Name, Reason: err.Error()})
+			continue
+		}
+
+		slug := Slug(entry.Name)
+		id := uniqueID(slug, func(id string) bool { return taken[id] })
+		taken[id] = true
+		if exists(id) {
+			if !opts.Rename {
+				plan.Duplicates = append(plan.Duplicates, Skipped{Name: entry.Name, Reason: "ID уже существует: " + id})
+				continue
+			}
+			id = uniqueID(slug, func(id string) bool { return taken[id] || exists(id) })
+			taken[id] = true
+		}
+
+		if len(entry.SecretFields) > 0 {
+			plan.Secrets = append(plan.Secrets, Skipped{
+				Name:   entry.Name,
+				Reason: fmt.Sprintf("скрытые поля %s сохранены в содержимом заметки %s", strings.Join(entry.SecretFields, ", "), id),
+			})
+		}
+
+		plan.Records = append(plan.Records, &pb.DataRecord{
+			Id:            id,
+			Type:          entry.Type,
+			EncryptedData: data,
+			Metadata:      entry.Metadata,
+			Folder:        folderPath(base, entry.Folder),
+			Tags:          catalog.CleanTags(entry.Tags),
+		})
+	}
+
+	return plan, nil
+}
+
+// payload собирает, проверяет и сериализует содержимое записи.
+func (e Entry) payload() ([]byte, error) {
+	p, err := payload.Encode(e.Type, e.Values)
+	if err != nil {
+		return nil, fmt.Errorf("неверные данные %s: %w", e.Type, err)
+	}
+	if err := payload.Validate(p); err != nil {
+		return nil, fmt.Errorf("неверные данные %s: %w", e.Type, err)
+	}
+	return payload.Marshal(p)
+}
+
+// Slug строит ID записи из названия: буквы и цифры в нижнем регистре, остальное заменяется дефисами.
+func Slug(name string) string {
+	var b strings.Builder
+	dash := false
+	n := 0
+	for _, r := range strings.ToLower(name) {
+		if n == maxIDLength {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			n++
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+			n++
+		}
+	}
+
+	id := strings.TrimRight(b.String(), "-")
+	if id == "" {
+		return defaultID
+	}
+	return id
+}
+
+// uniqueID возвращает id или, если он занят, первый свободный id-2, id-3 и т.д.
+func uniqueID(id string, taken func(string) bool) string {
+	if !taken(id) {
+		return id
+	}
+	for i := 2; ; i++ {
+		candidate := id + "-" + strconv.Itoa(i)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// folderPath помещает папку записи внутрь base. Папка, которую нельзя использовать как путь,
+// заменяется base: запись не должна пропасть из-за названия группы.
+func folderPath(base, folder string) string {
+	folder, err := catalog.CleanPath(folder)
+	if err != nil || folder == "" {
+		return base
+	}
+	if base == "" {
+		return folder
+	}
+	return base + catalog.Separator + folder
+}
+
+// Виды записей экспорта.
+const (
+	kindLogin = "login"
+	kindCard  = "card"
+	kindNote  = "note"
+)
+
+// item — запись экспорта в общем для всех форматов виде.
+type item struct {
+	name    string
+	kind    string
+	folder  string
+	tags    []string
+	notes   string
+	urls    []string
+	fields  [][2]string // Дополнительные поля в порядке экспорта: имя и значение
+	secrets [][2]string // Скрытые дополнительные поля: хранятся только в зашифрованном содержимом
+
+	login, password, totp string
+
+	holder, number, expiry, cvv, brand string
+}
+
+// field добавляет дополнительное поле записи.
+func (it *item) field(name, value string) {
+	it.fields = append(it.fields, [2]string{name, value})
+}
+
+// secret добавляет скрытое дополнительное поле записи.
+func (it *item) secret(name, value string) {
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if name == "" || value == "" {
+		return
+	}
+	it.secrets = append(it.secrets, [2]string{name, value})
+}
+
+// secretsText записывает скрытые поля строками "имя: значение" и возвращает их названия.
+func (it *item) secretsText() (string, []string) {
+	lines := make([]string, 0, len(it.secrets))
+	names := make([]string, 0, len(it.secrets))
+	for _, f := range it.secrets {
+		lines = append(lines, f[0]+": "+f[1])
+		names = append(names, f[0])
+	}
+	return strings.Join(lines, "\n"), names
+}
+
+// add приводит запись экспорта к записям GophKeeper: логин без логина и пароля сохраняется как заметка,
+// секрет TOTP — отдельной записью totp рядом с логином. Скрытые поля дописываются в содержимое заметки,
+// а у логина и карты сохраняются отдельной заметкой secrets.
+func (r *Result) add(it item) {
+	name := strings.TrimSpace(it.name)
+
+	var entry Entry
+	switch it.kind {
+	case kindLogin:
+		if it.login == "" && it.password == "" {
+			it.kind = kindNote
+			r.add(it)
+			return
+		}
+		entry = newEntry(name, payload.TypeLoginPass, map[string]string{"login": it.login, "password": it.password})
+		entry.setMeta(metaNotes, it.notes)
+	case kindCard:
+		entry = newEntry(name, payload.TypeCard, map[string]string{
+			"number": it.number, "expiry": it.expiry, "cvv": it.cvv, "holder": it.holder,
+		})
+		entry.setMeta("brand", it.brand)
+		entry.setMeta(metaNotes, it.notes)
+	case kindNote:
+		content, secretFields := it.notes, []string(nil)
+		if len(it.secrets) > 0 {
+			text, names := it.secretsText()
+			content, secretFields = strings.TrimSpace(it.notes+"\n\n"+text), names
+		}
+		if strings.TrimSpace(content) == "" {
+			r.skip(name, "пустая запись")
+			return
+		}
+		entry = newEntry(name, payload.TypeText, map[string]string{"content": content})
+		entry.SecretFields = secretFields
+	default:
+		r.skip(name, "тип записи не поддерживается: "+it.kind)
+		return
+	}
+
+	for i, url := range it.urls {
+		key := metaURL
+		if i > 0 {
+			key += strconv.Itoa(i + 1)
+		}
+		entry.setMeta(key, url)
+	}
+	for _, f := range it.fields {
+		entry.setMeta(f[0], f[1])
+	}
+	entry.Folder, entry.Tags = it.folder, it.tags
+	r.Entries = append(r.Entries, entry.finish())
+
+	if it.kind == kindLogin && strings.TrimSpace(it.totp) != "" {
+		totp := strings.TrimSpace(it.totp)
+		values := map[string]string{"secret": totp, "issuer": name, "account": it.login}
+		if strings.HasPrefix(totp, "otpauth://") {
+			values = map[string]string{"uri": totp}
+		}
+		entry := newEntry(name+totpSuffix, payload.TypeTOTP, values)
+		entry.Folder, entry.Tags = it.folder, it.tags
+		r.Entries = append(r.Entries, entry.finish())
+	}
+
+	if it.kind != kindNote && len(it.secrets) > 0 {
+		text, names := it.secretsText()
+		entry := newEntry(name+secretsSuffix, payload.TypeText, map[string]string{"content": text})
+		entry.Folder, entry.Tags, entry.SecretFields = it.folder, it.tags, names
+		r.Entries = append(r.Entries, entry.finish())
+	}
+}
+
+// skip отмечает запись экспорта как пропущенную.
+func (r *Result) skip(name, reason string) {
+	r.Skipped = append(r.Skipped, Skipped{Name: strings.TrimSpace(name), Reason: reason})
+}
+
+// newEntry создаёт запись с пустыми метаданными.
+func newEntry(name, typ string, values map[string]string) Entry {
+	return Entry{Name: name, Type: typ, Values: values, Metadata: make(map[string]string)}
+}
+
+// setMeta сохраняет непустое значение в метаданных, не перезаписывая уже заданный ключ.
+func (e *Entry) setMeta(key, value string) {
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if key == "" || value == "" {
+		return
+	}
+	if _, ok := e.Metadata[key]; ok {
+		return
+	}
+	e.Metadata[key] = value
+}
+
+// finish убирает пустые метаданные: запись без них хранится в прежнем формате.
+func (e Entry) finish() Entry {
+	if len(e.Metadata) == 0 {
+		e.Metadata = nil
+	}
+	return e
+}
+
+// splitTags разбирает список меток, разделённых запятыми или точками с запятой.
+func splitTags(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' })
+}
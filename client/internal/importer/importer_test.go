@@ -0,0 +1,154 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"GitHub", "github"},
+		{"  My Bank (personal) ", "my-bank-personal"},
+		{"mail.google.com", "mail-google-com"},
+		{"Почта Яндекс", "почта-яндекс"},
+		{"***", "item"},
+		{"", "item"},
+		{strings.Repeat("a", 100), strings.Repeat("a", maxIDLength)},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Slug(tt.name), tt.name)
+	}
+}
+
+func TestParse_UnknownFormat(t *testing.T) {
+	_, err := Parse("lastpass-csv", strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func loginItem(name, login string) item {
+	return item{name: name, kind: kindLogin, login: login, password: "secret"}
+}
+
+func TestResult_Add(t *testing.T) {
+	res := &Result{}
+	res.add(item{
+		name: "GitHub", kind: kindLogin, folder: "work", tags: []string{"dev"},
+		login: "vasia", password: "secret", totp: "JBSWY3DPEHPK3PXP", notes: "рабочий",
+		urls:   []string{"https://github.com", "https://gist.github.com"},
+		fields: [][2]string{{"team", "backend"}, {"url", "не перезапишет"}},
+	})
+	res.add(item{name: "Заметка", kind: kindLogin, notes: "только текст"})
+	res.add(item{name: "Пусто", kind: kindNote})
+
+	require.Len(t, res.Entries, 3)
+
+	login := res.Entries[0]
+	assert.Equal(t, payload.TypeLoginPass, login.Type)
+	assert.Equal(t, map[string]string{"login": "vasia", "password": "secret"}, login.Values)
+	assert.Equal(t, map[string]string{
+		"url":   "https://github.com",
+		"url2":  "https://gist.github.com",
+		"notes": "рабочий",
+		"team":  "backend",
+	}, login.Metadata)
+	assert.Equal(t, "work", login.Folder)
+
+	totp := res.Entries[1]
+	assert.Equal(t, payload.TypeTOTP, totp.Type)
+	assert.Equal(t, "GitHub totp", totp.Name)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", totp.Values["secret"])
+	assert.Equal(t, "GitHub", totp.Values["issuer"])
+	assert.Nil(t, totp.Metadata)
+	assert.Equal(t, []string{"dev"}, totp.Tags)
+
+	// логин без логина и пароля сохраняется как заметка
+	assert.Equal(t, payload.TypeText, res.Entries[2].Type)
+	assert.Equal(t, "только текст", res.Entries[2].Values["content"])
+
+	require.Len(t, res.Skipped, 1)
+	assert.Equal(t, "Пусто", res.Skipped[0].Name)
+}
+
+// скрытые поля заметки дописываются в её содержимое
+func TestResult_AddSecretsToNote(t *testing.T) {
+	res := &Result{}
+	it := item{name: "Сервер", kind: kindNote, notes: "доступ по VPN"}
+	it.secret("root", "toor")
+	it.secret("пусто", " ")
+	res.add(it)
+
+	empty := item{name: "Только секреты", kind: kindNote}
+	empty.secret("token", "abc")
+	res.add(empty)
+
+	require.Len(t, res.Entries, 2)
+	assert.Equal(t, "доступ по VPN\n\nroot: toor", res.Entries[0].Values["content"])
+	assert.Equal(t, []string{"root"}, res.Entries[0].SecretFields)
+	assert.Nil(t, res.Entries[0].Metadata)
+	assert.Equal(t, "token: abc", res.Entries[1].Values["content"])
+	assert.Empty(t, res.Skipped)
+}
+
+func TestNewPlan(t *testing.T) {
+	res := &Result{Skipped: []Skipped{{Name: "Паспорт", Reason: "не поддерживается"}}}
+	res.add(loginItem("GitHub", "vasia"))
+	res.add(loginItem("GitHub", "petya"))
+	res.add(loginItem("Gmail", "vasia@gmail.com"))
+	res.add(item{name: "Карта", kind: kindCard, number: "1234", expiry: "12/27"})
+	res.Entries[0].Folder = "work/dev"
+
+	existing := map[string]bool{"gmail": true}
+	exists := func(id string) bool { return existing[id] }
+
+	plan, err := NewPlan(res, exists, Options{Folder: "imported"})
+	require.NoError(t, err)
+	require.Len(t, plan.Records, 2)
+	assert.Equal(t, "github", plan.Records[0].Id)
+	assert.Equal(t, "imported/work/dev", plan.Records[0].Folder)
+	assert.Equal(t, "github-2", plan.Records[1].Id)
+	assert.Equal(t, "imported", plan.Records[1].Folder)
+
+	p, err := payload.Unmarshal(payload.TypeLoginPass, plan.Records[1].EncryptedData)
+	require.NoError(t, err)
+	assert.Equal(t, "petya", p.GetLoginPass().GetLogin())
+
+	require.Len(t, plan.Duplicates, 1)
+	assert.Equal(t, "Gmail", plan.Duplicates[0].Name)
+
+	require.Len(t, plan.Skipped, 2)
+	assert.Equal(t, "Паспорт", plan.Skipped[0].Name)
+	assert.Equal(t, "Карта", plan.Skipped[1].Name)
+	assert.Contains(t, plan.Skipped[1].Reason, "invalid card number")
+
+	// повторный импорт без --rename ничего не добавляет
+	for _, record := range plan.Records {
+		existing[record.Id] = true
+	}
+	again, err := NewPlan(res, exists, Options{Folder: "imported"})
+	require.NoError(t, err)
+	assert.Empty(t, again.Records)
+	assert.Len(t, again.Duplicates, 3)
+
+	renamed, err := NewPlan(res, exists, Options{Rename: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github-3", "github-4", "gmail-2"}, recordIDs(renamed.Records))
+
+	_, err = NewPlan(res, exists, Options{Folder: "../x"})
+	assert.Error(t, err)
+}
+
+func recordIDs(records []*pb.DataRecord) []string {
+	var ids []string
+	for _, record := range records {
+		ids = append(ids, record.Id)
+	}
+	return ids
+}
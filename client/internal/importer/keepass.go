@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Стандартные поля записи KeePass. Остальные строки записи сохраняются в метаданных.
+const (
+	keepassTitle    = "Title"
+	keepassUserName = "UserName"
+	keepassPassword = "Password"
+	keepassURL      = "URL"
+	keepassNotes    = "Notes"
+)
+
+// Поля, в которых KeePassXC и KeePass 2.47+ хранят секрет TOTP.
+var keepassTOTPKeys = []string{"otp", "TimeOtp-Secret-Base32"}
+
+// keepassFile — XML-экспорт базы KeePass 2.x (KeePass, KeePassXC).
+type keepassFile struct {
+	Meta struct {
+		RecycleBinUUID string `xml:"RecycleBinUUID"`
+	} `xml:"Meta"`
+	Root struct {
+		Groups []keepassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+// keepassGroup — группа записей. Прежние версии записей (History) не импортируются.
+type keepassGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Groups  []keepassGroup `xml:"Group"`
+	Entries []keepassEntry `xml:"Entry"`
+}
+
+// keepassEntry — запись KeePass: набор именованных строк и метки.
+type keepassEntry struct {
+	Tags    string `xml:"Tags"`
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value struct {
+			Text            string `xml:",chardata"`
+			Protected       bool   `xml:"Protected,attr"`
+			ProtectInMemory bool   `xml:"ProtectInMemory,attr"`
+		} `xml:"Value"`
+	} `xml:"String"`
+}
+
+// parseKeePassXML разбирает XML-экспорт KeePass. Корневая группа базы не становится папкой,
+// вложенные группы — папки записей; содержимое корзины не импортируется.
+func parseKeePassXML(r io.Reader) (*Result, error) {
+	var file keepassFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать XML KeePass: %w", err)
+	}
+	if len(file.Root.Groups) == 0 {
+		return nil, fmt.Errorf("не похоже на XML-экспорт KeePass: нет групп записей")
+	}
+
+	res := &Result{}
+	for _, root := range file.Root.Groups {
+		res.addKeePassGroup(root, "", file.Meta.RecycleBinUUID)
+	}
+	return res, nil
+}
+
+// addKeePassGroup добавляет записи группы и её подгрупп, лежащих в папке folder.
+func (r *Result) addKeePassGroup(group keepassGroup, folder, recycleBin string) {
+	for _, entry := range group.Entries {
+		r.addKeePassEntry(entry, folder)
+	}
+
+	for _, sub := range group.Groups {
+		if recycleBin != "" && sub.UUID == recycleBin {
+			continue
+		}
+		// "/" в названии группы — не разделитель папок
+		name := strings.ReplaceAll(strings.TrimSpace(sub.Name), "/", "-")
+		path := name
+		if folder != "" {
+			path = folder + "/" + name
+		}
+		r.addKeePassGroup(sub, path, recycleBin)
+	}
+}
+
+// addKeePassEntry добавляет запись KeePass как логин или, если нет ни логина, ни пароля, как заметку.
+func (r *Result) addKeePassEntry(entry keepassEntry, folder string) {
+	values := make(map[string]string, len(entry.Strings))
+	hidden := make(map[string]bool)
+	var extra []string
+	for _, s := range entry.Strings {
+		if s.Value.Protected {
+			// Значение зашифровано ключом потока базы: XML извлечён из файла KDBX, а не экспортирован
+			r.skip(entryTitle(entry), "поле "+s.Key+" зашифровано, экспортируйте базу в формат KeePass XML")
+			return
+		}
+		if _, known := values[s.Key]; !known {
+			extra = append(extra, s.Key)
+		}
+		values[s.Key] = s.Value.Text
+		hidden[s.Key] = s.Value.ProtectInMemory
+	}
+
+	it := item{
+		name:     values[keepassTitle],
+		kind:     kindLogin,
+		folder:   folder,
+		tags:     splitTags(entry.Tags),
+		notes:    values[keepassNotes],
+		login:    strings.TrimSpace(values[keepassUserName]),
+		password: values[keepassPassword],
+	}
+	if url := strings.TrimSpace(values[keepassURL]); url != "" {
+		it.urls = []string{url}
+	}
+	for _, key := range keepassTOTPKeys {
+		if it.totp == "" {
+			it.totp = values[key]
+		}
+	}
+
+	for _, key := range extra {
+		switch key {
+		case keepassTitle, keepassUserName, keepassPassword, keepassURL, keepassNotes:
+			continue
+		}
+		if isKeePassTOTPKey(key) {
+			continue
+		}
+		if hidden[key] {
+			it.secret(key, values[key])
+			continue
+		}
+		it.field(key, values[key])
+	}
+
+	r.add(it)
+}
+
+// entryTitle возвращает название записи KeePass.
+func entryTitle(entry keepassEntry) string {
+	for _, s := range entry.Strings {
+		if s.Key == keepassTitle {
+			return s.Value.Text
+		}
+	}
+	return ""
+}
+
+// isKeePassTOTPKey сообщает, что в поле key хранится настройка TOTP.
+func isKeePassTOTPKey(key string) bool {
+	return key == "otp" || strings.HasPrefix(key, "TimeOtp-") || strings.HasPrefix(key, "TOTP ")
+}
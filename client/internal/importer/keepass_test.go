@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const keepassXML = `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<KeePassFile>
+	<Meta>
+		<RecycleBinUUID>cmVjeWNsZQ==</RecycleBinUUID>
+	</Meta>
+	<Root>
+		<Group>
+			<UUID>cm9vdA==</UUID>
+			<Name>Database</Name>
+			<Entry>
+				<Tags>prod;db</Tags>
+				<String><Key>Title</Key><Value>Postgres</Value></String>
+				<String><Key>UserName</Key><Value>admin</Value></String>
+				<String><Key>Password</Key><Value ProtectInMemory="True">s3cret</Value></String>
+				<String><Key>URL</Key><Value>postgres://db.local</Value></String>
+				<String><Key>Notes</Key><Value></Value></String>
+				<String><Key>otp</Key><Value>otpauth://totp/db?secret=JBSWY3DPEHPK3PXP</Value></String>
+				<String><Key>Port</Key><Value>5432</Value></String>
+				<String><Key>PIN</Key><Value ProtectInMemory="True">0000</Value></String>
+				<History>
+					<Entry>
+						<String><Key>Title</Key><Value>Postgres (old)</Value></String>
+						<String><Key>Password</Key><Value>old</Value></String>
+					</Entry>
+				</History>
+			</Entry>
+			<Group>
+				<UUID>d29yaw==</UUID>
+				<Name>Work/Team</Name>
+				<Group>
+					<UUID>bm90ZXM=</UUID>
+					<Name>Notes</Name>
+					<Entry>
+						<String><Key>Title</Key><Value>Инструкция</Value></String>
+						<String><Key>Notes</Key><Value>Перезапуск: systemctl restart app</Value></String>
+					</Entry>
+					<Entry>
+						<String><Key>Title</Key><Value>Зашифрованная</Value></String>
+						<String><Key>Password</Key><Value Protected="True">AAECAw==</Value></String>
+					</Entry>
+				</Group>
+			</Group>
+			<Group>
+				<UUID>cmVjeWNsZQ==</UUID>
+				<Name>Recycle Bin</Name>
+				<Entry>
+					<String><Key>Title</Key><Value>Удалённая</Value></String>
+					<String><Key>Password</Key><Value>x</Value></String>
+				</Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>`
+
+func TestParseKeePassXML(t *testing.T) {
+	res, err := Parse(FormatKeePassXML, strings.NewReader(keepassXML))
+	require.NoError(t, err)
+
+	require.Len(t, res.Entries, 4)
+
+	login := res.Entries[0]
+	assert.Equal(t, "Postgres", login.Name)
+	assert.Equal(t, payload.TypeLoginPass, login.Type)
+	assert.Equal(t, map[string]string{"login": "admin", "password": "s3cret"}, login.Values)
+	assert.Equal(t, map[string]string{"url": "postgres://db.local", "Port": "5432"}, login.Metadata)
+	assert.Empty(t, login.Folder)
+	assert.Equal(t, []string{"prod", "db"}, login.Tags)
+
+	totp := res.Entries[1]
+	assert.Equal(t, payload.TypeTOTP, totp.Type)
+	assert.Equal(t, "otpauth://totp/db?secret=JBSWY3DPEHPK3PXP", totp.Values["uri"])
+
+	secrets := res.Entries[2]
+	assert.Equal(t, "Postgres secrets", secrets.Name)
+	assert.Equal(t, "PIN: 0000", secrets.Values["content"])
+
+	note := res.Entries[3]
+	assert.Equal(t, payload.TypeText, note.Type)
+	assert.Equal(t, "Work-Team/Notes", note.Folder)
+
+	require.Len(t, res.Skipped, 1)
+	assert.Equal(t, "Зашифрованная", res.Skipped[0].Name)
+
+	_, err = Parse(FormatKeePassXML, strings.NewReader(`<KeePassFile><Root></Root></KeePassFile>`))
+	assert.Error(t, err)
+}
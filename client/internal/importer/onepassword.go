@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Столбцы CSV-экспорта 1Password. Названия различаются между версиями 1Password
+// (1Password 8: Title, Url, Username, Password, OTPAuth, Favorite, Archived, Tags, Notes;
+// 1Password 7 выгружает каждую категорию отдельно, карты — со столбцами Cardholder Name, Number и т.д.),
+// поэтому у каждого поля несколько вариантов названия.
+var (
+	onePasswordTitle    = []string{"title", "name"}
+	onePasswordURL      = []string{"url", "website", "login url", "urls"}
+	onePasswordUsername = []string{"username", "login username"}
+	onePasswordPassword = []string{"password", "login password"}
+	onePasswordOTP      = []string{"otpauth", "one-time password", "otp"}
+	onePasswordNotes    = []string{"notes", "notesplain"}
+	onePasswordTags     = []string{"tags"}
+	onePasswordFavorite = []string{"favorite"}
+	onePasswordArchived = []string{"archived"}
+	onePasswordHolder   = []string{"cardholder name", "cardholder"}
+	onePasswordNumber   = []string{"number", "card number", "ccnum"}
+	onePasswordExpiry   = []string{"expiry date", "expiry", "expires", "expiration date"}
+	onePasswordCVV      = []string{"verification number", "cvv", "cvc"}
+	onePasswordBrand    = []string{"type", "card type"}
+)
+
+// archivedTag — метка записей из архива 1Password.
+const archivedTag = "archived"
+
+// parseOnePasswordCSV разбирает CSV-экспорт 1Password. Строка с номером карты становится картой,
+// с логином или паролем — логином, остальные — заметками. Столбцы, не относящиеся к полям записи,
+// сохраняются в метаданных. Папок в CSV 1Password нет.
+func parseOnePasswordCSV(r io.Reader) (*Result, error) {
+	table, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	if !table.has(onePasswordTitle...) {
+		return nil, fmt.Errorf("не похоже на CSV-экспорт 1Password: нет столбца Title")
+	}
+
+	known := make(map[string]bool)
+	for _, names := range [][]string{
+		onePasswordTitle, onePasswordURL, onePasswordUsername, onePasswordPassword, onePasswordOTP,
+		onePasswordNotes, onePasswordTags, onePasswordFavorite, onePasswordArchived,
+		onePasswordHolder, onePasswordNumber, onePasswordExpiry, onePasswordCVV, onePasswordBrand,
+	} {
+		for _, name := range names {
+			known[name] = true
+		}
+	}
+
+	res := &Result{}
+	for _, row := range table.rows {
+		it := item{
+			name:  table.get(row, onePasswordTitle...),
+			notes: table.get(row, onePasswordNotes...),
+			tags:  splitTags(table.get(row, onePasswordTags...)),
+		}
+		if isTrue(table.get(row, onePasswordFavorite...)) {
+			it.tags = append(it.tags, favoriteTag)
+		}
+		if isTrue(table.get(row, onePasswordArchived...)) {
+			it.tags = append(it.tags, archivedTag)
+		}
+
+		if number := table.get(row, onePasswordNumber...); number != "" {
+			it.kind = kindCard
+			it.number = number
+			it.holder = table.get(row, onePasswordHolder...)
+			it.expiry = onePasswordExpiryDate(table.get(row, onePasswordExpiry...))
+			it.cvv = table.get(row, onePasswordCVV...)
+			it.brand = table.get(row, onePasswordBrand...)
+		} else {
+			it.kind = kindLogin
+			it.login = table.get(row, onePasswordUsername...)
+			it.password = table.get(row, onePasswordPassword...)
+			it.totp = table.get(row, onePasswordOTP...)
+		}
+		if url := table.get(row, onePasswordURL...); url != "" {
+			it.urls = []string{url}
+		}
+
+		for i, name := range table.names {
+			if !known[name] && i < len(row) {
+				it.field(name, row[i])
+			}
+		}
+
+		res.add(it)
+	}
+	return res, nil
+}
+
+// onePasswordExpiryDate приводит срок действия карты 1Password к виду MM/YYYY.
+// 1Password 7 выгружает его как YYYYMM.
+func onePasswordExpiryDate(expiry string) string {
+	if len(expiry) == 6 && !strings.Contains(expiry, "/") {
+		return expiry[4:] + "/" + expiry[:4]
+	}
+	return expiry
+}
+
+// isTrue разбирает логическое значение столбца CSV.
+func isTrue(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "1", "yes":
+		return true
+	default:
+		return false
+	}
+}
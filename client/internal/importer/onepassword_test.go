@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOnePasswordCSV(t *testing.T) {
+	data := "Title,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes,Environment\n" +
+		"Gmail,https://mail.google.com,vasia@gmail.com,secret,,true,false,\"personal,mail\",,\n" +
+		"Old VPN,,vpn-user,pass,,false,true,,,staging\n" +
+		"Ключ Wi-Fi,,,,,false,false,,пароль: 12345678,\n"
+
+	res, err := Parse(FormatOnePasswordCSV, strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 3)
+
+	gmail := res.Entries[0]
+	assert.Equal(t, payload.TypeLoginPass, gmail.Type)
+	assert.Equal(t, map[string]string{"url": "https://mail.google.com"}, gmail.Metadata)
+	assert.Equal(t, []string{"personal", "mail", favoriteTag}, gmail.Tags)
+
+	vpn := res.Entries[1]
+	assert.Equal(t, []string{archivedTag}, vpn.Tags)
+	assert.Equal(t, map[string]string{"environment": "staging"}, vpn.Metadata)
+
+	assert.Equal(t, payload.TypeText, res.Entries[2].Type)
+	assert.Empty(t, res.Skipped)
+}
+
+func TestParseOnePasswordCSV_Cards(t *testing.T) {
+	data := "title,cardholder name,number,expiry date,verification number,type\n" +
+		"Visa,VASILY PUPKIN,4111111111111111,202712,123,Visa\n"
+
+	res, err := Parse(FormatOnePasswordCSV, strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 1)
+
+	card := res.Entries[0]
+	assert.Equal(t, payload.TypeCard, card.Type)
+	assert.Equal(t, "12/2027", card.Values["expiry"])
+	assert.Equal(t, "123", card.Values["cvv"])
+	assert.Equal(t, map[string]string{"brand": "Visa"}, card.Metadata)
+
+	plan, err := NewPlan(res, func(string) bool { return false }, Options{})
+	require.NoError(t, err)
+	require.Len(t, plan.Records, 1)
+	p, err := payload.Unmarshal(payload.TypeCard, plan.Records[0].EncryptedData)
+	require.NoError(t, err)
+	assert.Equal(t, "12/27", p.GetCard().GetExpiry())
+
+	_, err = Parse(FormatOnePasswordCSV, strings.NewReader("site,login\nx,y\n"))
+	assert.Error(t, err)
+}
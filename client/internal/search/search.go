@@ -0,0 +1,284 @@
+// Package search ищет и фильтрует расшифрованные записи на клиенте.
+// Текст ищется по ID, типу, папке, меткам, метаданным и несекретным полям содержимого;
+// секретные поля (пароли, номера карт, ключи) в поиске не участвуют.
+package search
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dvkhr/gophkeeper/client/internal/catalog"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+)
+
+// Порядок результатов поиска.
+const (
+	SortRelevance = "relevance"
+	SortUpdated   = "updated"
+)
+
+// Оценки совпадения текста со значением поля.
+const (
+	scoreExact     = 100
+	scorePrefix    = 80
+	scoreWord      = 70
+	scoreSubstring = 60
+	scoreFuzzyMax  = 40
+)
+
+// Query — параметры поиска. Пустые условия не ограничивают результат.
+type Query struct {
+	Text   string            // Подстрока или, при Fuzzy, нечёткий образец
+	Fuzzy  bool              // Допускать пропущенные символы и опечатки
+	Types  []string          // Допустимые типы записей
+	Meta   map[string]string // Метаданные, которые должны совпасть (без учёта регистра)
+	Folder string            // Папка, в которой (или во вложенных папках которой) лежит запись
+	Tags   []string          // Метки, которые должны быть у записи (без учёта регистра)
+	Sort   string            // SortRelevance (по умолчанию) или SortUpdated
+	Limit  int               // Максимум результатов; 0 — без ограничения
+}
+
+// Result — найденная запись.
+type Result struct {
+	Record  *pb.DataRecord
+	Score   int      // Оценка совпадения текста; 0, если текст не задан
+	Matches []string // Поля, в которых найден текст, например "id" или "meta:site"
+}
+
+// Search отбирает записи, подходящие под запрос, и упорядочивает их.
+// Служебные записи пустых папок в результат не попадают.
+func Search(records []*pb.DataRecord, q Query) []Result {
+	text := strings.ToLower(strings.TrimSpace(q.Text))
+
+	var results []Result
+	for _, record := range records {
+		if catalog.IsFolder(record) || !matchesType(record, q.Types) || !matchesMeta(record, q.Meta) {
+			continue
+		}
+		if !catalog.Within(record.Folder, q.Folder) || !catalog.HasTags(record, q.Tags) {
+			continue
+		}
+
+		result := Result{Record: record}
+		if text != "" {
+			for _, field := range searchableFields(record) {
+				score := matchScore(strings.ToLower(field.value), text, q.Fuzzy)
+				if score == 0 {
+					continue
+				}
+				if !slices.Contains(result.Matches, field.name) {
+					result.Matches = append(result.Matches, field.name)
+				}
+				if score > result.Score {
+					result.Score = score
+				}
+			}
+			if result.Score == 0 {
+				continue
+			}
+		}
+		results = append(results, result)
+	}
+
+	sortResults(results, q.Sort)
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
+// sortResults упорядочивает результаты по релевантности или времени изменения.
+// При равенстве более новые записи идут первыми, затем — по ID.
+func sortResults(results []Result, order string) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if order != SortUpdated && a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Record.Timestamp != b.Record.Timestamp {
+			return a.Record.Timestamp > b.Record.Timestamp
+		}
+		return a.Record.Id < b.Record.Id
+	})
+}
+
+func matchesType(record *pb.DataRecord, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if strings.EqualFold(record.Type, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesMeta(record *pb.DataRecord, meta map[string]string) bool {
+	for key, value := range meta {
+		actual, ok := record.Metadata[key]
+		if !ok || (value != "" && !strings.EqualFold(actual, value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// field — значение, по которому ищется текст.
+type field struct {
+	name  string
+	value string
+}
+
+// searchableFields возвращает несекретные значения записи.
+func searchableFields(record *pb.DataRecord) []field {
+	fields := []field{{"id", record.Id}, {"type", record.Type}}
+	if record.Folder != "" {
+		fields = append(fields, field{"folder", record.Folder})
+	}
+	for _, tag := range record.Tags {
+		fields = append(fields, field{"tag", tag})
+	}
+	fixed := len(fields)
+
+	for key, value := range record.Metadata {
+		fields = append(fields, field{"meta:" + key, key + " " + value})
+	}
+
+	if record.BlobSize > 0 && len(record.EncryptedData) == 0 {
+		return fields
+	}
+	p, err := payload.Unmarshal(record.Type, record.EncryptedData)
+	if err != nil {
+		return fields
+	}
+	for name, value := range payload.PublicValues(p) {
+		fields = append(fields, field{name, value})
+	}
+
+	// порядок map случаен, а Matches выводится пользователю
+	sort.Slice(fields[fixed:], func(i, j int) bool { return fields[fixed+i].name < fields[fixed+j].name })
+	return fields
+}
+
+// matchScore оценивает совпадение образца text со значением value (оба в нижнем регистре).
+func matchScore(value, text string, fuzzy bool) int {
+	switch {
+	case value == text:
+		return scoreExact
+	case strings.HasPrefix(value, text):
+		return scorePrefix
+	case containsWord(value, text):
+		return scoreWord
+	case strings.Contains(value, text):
+		return scoreSubstring
+	case fuzzy:
+		return fuzzyScore(value, text)
+	default:
+		return 0
+	}
+}
+
+// containsWord сообщает, что одно из слов value начинается с text.
+func containsWord(value, text string) bool {
+	for _, word := range words(value) {
+		if strings.HasPrefix(word, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyScore оценивает нечёткое совпадение: text как подпоследовательность value
+// (чем плотнее расположены символы, тем выше оценка) или слово value с опечатками.
+func fuzzyScore(value, text string) int {
+	best := 0
+	if span := subsequenceSpan(value, text); span > 0 {
+		best = scoreFuzzyMax * utf8.RuneCountInString(text) / span
+	}
+
+	allowed := maxTypos(text)
+	for _, word := range words(value) {
+		if d := levenshtein(word, text); d <= allowed {
+			if score := scoreFuzzyMax - 10*d; score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
+
+// subsequenceSpan возвращает длину кратчайшего участка value, содержащего символы text по порядку, или 0.
+func subsequenceSpan(value, text string) int {
+	v, t := []rune(value), []rune(text)
+	if len(t) == 0 {
+		return 0
+	}
+
+	best := 0
+	for start := range v {
+		if v[start] != t[0] {
+			continue
+		}
+		i, j := start, 0
+		for i < len(v) && j < len(t) {
+			if v[i] == t[j] {
+				j++
+			}
+			i++
+		}
+		if j < len(t) {
+			break
+		}
+		if span := i - start; best == 0 || span < best {
+			best = span
+		}
+	}
+	return best
+}
+
+// maxTypos возвращает допустимое число опечаток для образца.
+func maxTypos(text string) int {
+	switch n := utf8.RuneCountInString(text); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// words разбивает строку на слова по небуквенно-цифровым символам.
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// levenshtein вычисляет расстояние редактирования между строками.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,140 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRecord(t *testing.T, id, typ string, values, metadata map[string]string, timestamp int64) *pb.DataRecord {
+	t.Helper()
+
+	p, err := payload.Encode(typ, values)
+	require.NoError(t, err)
+	data, err := payload.Marshal(p)
+	require.NoError(t, err)
+
+	return &pb.DataRecord{Id: id, Type: typ, EncryptedData: data, Metadata: metadata, Timestamp: timestamp}
+}
+
+func testRecords(t *testing.T) []*pb.DataRecord {
+	return []*pb.DataRecord{
+		newRecord(t, "gmail", payload.TypeLoginPass,
+			map[string]string{"login": "vasia@gmail.com", "password": "hunter2"},
+			map[string]string{"site": "mail.google.com"}, 300),
+		newRecord(t, "github", payload.TypeLoginPass,
+			map[string]string{"login": "vasia", "password": "gmail-is-not-here"},
+			map[string]string{"site": "github.com", "team": "backend"}, 200),
+		newRecord(t, "card1", payload.TypeCard,
+			map[string]string{"number": "4111111111111111", "expiry": "12/27", "holder": "VASILY PUPKIN"},
+			map[string]string{"bank": "Tinkoff"}, 100),
+		newRecord(t, "note", payload.TypeText,
+			map[string]string{"content": "Список покупок: молоко, хлеб"},
+			nil, 400),
+	}
+}
+
+func ids(results []Result) []string {
+	var out []string
+	for _, r := range results {
+		out = append(out, r.Record.Id)
+	}
+	return out
+}
+
+func TestSearch_Substring(t *testing.T) {
+	records := testRecords(t)
+
+	results := Search(records, Query{Text: "GMAIL"})
+	// пароль github содержит "gmail", но секретные поля не ищутся
+	assert.Equal(t, []string{"gmail"}, ids(results))
+	assert.Equal(t, scoreExact, results[0].Score)
+	assert.Contains(t, results[0].Matches, "id")
+	assert.Contains(t, results[0].Matches, "login")
+
+	assert.Equal(t, []string{"note"}, ids(Search(records, Query{Text: "молоко"})))
+	assert.Empty(t, Search(records, Query{Text: "4111"}))
+	assert.Empty(t, Search(records, Query{Text: "hunter2"}))
+}
+
+func TestSearch_Relevance(t *testing.T) {
+	records := testRecords(t)
+
+	// точное совпадение логина важнее вхождения в логин другой записи
+	results := Search(records, Query{Text: "vasia"})
+	assert.Equal(t, []string{"github", "gmail"}, ids(results))
+
+	results = Search(records, Query{Text: "vasia", Sort: SortUpdated})
+	assert.Equal(t, []string{"gmail", "github"}, ids(results))
+
+	results = Search(records, Query{Text: "vasia", Limit: 1})
+	assert.Equal(t, []string{"github"}, ids(results))
+}
+
+func TestSearch_Fuzzy(t *testing.T) {
+	records := testRecords(t)
+
+	// опечатка
+	assert.Empty(t, Search(records, Query{Text: "githib"}))
+	assert.Equal(t, []string{"github"}, ids(Search(records, Query{Text: "githib", Fuzzy: true})))
+	assert.Equal(t, []string{"card1"}, ids(Search(records, Query{Text: "tinkof", Fuzzy: true})))
+
+	// пропущенные символы
+	assert.Empty(t, Search(records, Query{Text: "gthb"}))
+	assert.Equal(t, []string{"github"}, ids(Search(records, Query{Text: "gthb", Fuzzy: true})))
+}
+
+func TestSearch_Filters(t *testing.T) {
+	records := testRecords(t)
+
+	results := Search(records, Query{Types: []string{"loginpass"}})
+	assert.Equal(t, []string{"gmail", "github"}, ids(results))
+
+	results = Search(records, Query{Meta: map[string]string{"site": "GitHub.com"}})
+	assert.Equal(t, []string{"github"}, ids(results))
+
+	// пустое значение требует только наличия ключа
+	results = Search(records, Query{Meta: map[string]string{"bank": ""}})
+	assert.Equal(t, []string{"card1"}, ids(results))
+
+	results = Search(records, Query{Text: "vasia", Types: []string{"card"}})
+	assert.Empty(t, results)
+
+	results = Search(records, Query{Text: "pupkin", Types: []string{"card", "text"}})
+	assert.Equal(t, []string{"card1"}, ids(results))
+
+	assert.Len(t, Search(records, Query{}), 4)
+}
+
+func TestSearch_FolderAndTags(t *testing.T) {
+	records := testRecords(t)
+	records[0].Folder = "personal"
+	records[1].Folder = "work/dev"
+	records[1].Tags = []string{"prod", "2fa"}
+	records[2].Tags = []string{"Prod"}
+	records = append(records, &pb.DataRecord{Id: "folder-1", Type: payload.TypeFolder, Folder: "work/dev"})
+
+	assert.Equal(t, []string{"github"}, ids(Search(records, Query{Folder: "work"})))
+	assert.Empty(t, Search(records, Query{Folder: "wor"}))
+	assert.Equal(t, []string{"github", "card1"}, ids(Search(records, Query{Tags: []string{"PROD"}})))
+	assert.Equal(t, []string{"github"}, ids(Search(records, Query{Tags: []string{"prod", "2fa"}})))
+
+	// служебная запись папки не попадает в результаты
+	results := Search(records, Query{Text: "dev"})
+	assert.Equal(t, []string{"github"}, ids(results))
+	assert.Equal(t, []string{"folder"}, results[0].Matches)
+
+	results = Search(records, Query{Text: "2fa"})
+	assert.Equal(t, []string{"tag"}, results[0].Matches)
+	assert.Len(t, Search(records, Query{}), 4)
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("gmail", "gmail"))
+	assert.Equal(t, 2, levenshtein("gmail", "gmial"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 1, levenshtein("молоко", "малоко"))
+}
@@ -0,0 +1,131 @@
+// Package sshagent отдаёт SSH-ключи из хранилища клиенту ssh по протоколу ssh-agent.
+// Ключи держатся только в памяти процесса и не записываются на диск.
+package sshagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/logger"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// LoadKeys добавляет в keyring закрытые ключи из записей типа sshkey и возвращает ID добавленных записей.
+// Записи других типов пропускаются, ошибки разбора отдельных ключей объединяются.
+func LoadKeys(keyring agent.Agent, records []*pb.DataRecord) ([]string, error) {
+	var loaded []string
+	var errs []error
+	for _, record := range records {
+		if record.Type != payload.TypeSSHKey {
+			continue
+		}
+
+		p, err := payload.Unmarshal(record.Type, record.EncryptedData)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("запись %s: %w", record.Id, err))
+			continue
+		}
+		values := payload.Values(p)
+
+		key, err := payload.SSHPrivateKey(values)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("запись %s: %w", record.Id, err))
+			continue
+		}
+
+		comment := values["comment"]
+		if comment == "" {
+			comment = record.Id
+		}
+		if err := keyring.Add(agent.AddedKey{PrivateKey: key, Comment: comment}); err != nil {
+			errs = append(errs, fmt.Errorf("запись %s: %w", record.Id, err))
+			continue
+		}
+		loaded = append(loaded, record.Id)
+	}
+	return loaded, errors.Join(errs...)
+}
+
+// Serve принимает подключения на Unix-сокете path и обслуживает их из keyring, пока не отменён ctx.
+// Сокет доступен только владельцу и удаляется при завершении. Если по пути path уже есть
+// не сокет, Serve завершается ошибкой и ничего не удаляет.
+func Serve(ctx context.Context, path string, keyring agent.Agent) error {
+	listener, err := listen(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("ошибка приёма подключения: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			// ServeAgent возвращает io.EOF, когда клиент закрывает соединение
+			if err := agent.ServeAgent(keyring, conn); err != nil && !errors.Is(err, io.EOF) {
+				logger.Logg.Error("Ошибка соединения ssh-agent", "error", err)
+			}
+		}()
+	}
+}
+
+// listen открывает Unix-сокет path, доступный только владельцу. Сокет создаётся в новом каталоге
+// с правами 0700 и переносится на место после смены прав, поэтому другие пользователи
+// не успевают к нему подключиться. Старый сокет агента по пути path заменяется.
+func listen(path string) (net.Listener, error) {
+	info, err := os.Lstat(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("не удалось проверить путь сокета: %w", err)
+	}
+	if err == nil && info.Mode().Type() != os.ModeSocket {
+		return nil, fmt.Errorf("%s уже существует и не является сокетом", path)
+	}
+
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".gophkeeper-agent-*")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать каталог для сокета: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть сокет %s: %w", path, err)
+	}
+	// Сокет будет перенесён, путь удаляет Serve
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	if err := os.Chmod(tmp, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("не удалось ограничить права на сокет: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("не удалось открыть сокет %s: %w", path, err)
+	}
+	return listener, nil
+}
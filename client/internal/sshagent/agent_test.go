@@ -0,0 +1,136 @@
+package sshagent
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func sshKeyRecord(t *testing.T, id, comment string) *pb.DataRecord {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(private, comment)
+	require.NoError(t, err)
+
+	p, err := payload.Encode(payload.TypeSSHKey, map[string]string{
+		"private-key": string(pem.EncodeToMemory(block)),
+		"comment":     comment,
+	})
+	require.NoError(t, err)
+	data, err := payload.Marshal(p)
+	require.NoError(t, err)
+
+	return &pb.DataRecord{Id: id, Type: payload.TypeSSHKey, EncryptedData: data}
+}
+
+func TestLoadKeys(t *testing.T) {
+	keyring := agent.NewKeyring()
+	records := []*pb.DataRecord{
+		sshKeyRecord(t, "laptop", "vasia@laptop"),
+		sshKeyRecord(t, "server", ""),
+		{Id: "note", Type: payload.TypeText},
+		{Id: "broken", Type: payload.TypeSSHKey, EncryptedData: []byte("not a payload")},
+	}
+
+	loaded, err := LoadKeys(keyring, records)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "broken")
+	assert.Equal(t, []string{"laptop", "server"}, loaded)
+
+	keys, err := keyring.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "vasia@laptop", keys[0].Comment)
+	assert.Equal(t, "server", keys[1].Comment)
+}
+
+// serve запускает агент на сокете path и ждёт, пока сокет появится.
+func serve(t *testing.T, path string, keyring agent.Agent) (stop func() error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, path, keyring) }()
+
+	require.Eventually(t, func() bool {
+		info, err := os.Lstat(path)
+		return err == nil && info.Mode().Type() == os.ModeSocket
+	}, time.Second, 10*time.Millisecond)
+
+	return func() error {
+		cancel()
+		return <-done
+	}
+}
+
+func TestServe(t *testing.T) {
+	keyring := agent.NewKeyring()
+	_, err := LoadKeys(keyring, []*pb.DataRecord{sshKeyRecord(t, "laptop", "vasia@laptop")})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "agent.sock")
+
+	// Старый сокет агента заменяется
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	stop := serve(t, path, keyring)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	client := agent.NewClient(conn)
+
+	keys, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "vasia@laptop", keys[0].Comment)
+
+	signature, err := client.Sign(keys[0], []byte("challenge"))
+	require.NoError(t, err)
+	public, err := ssh.ParsePublicKey(keys[0].Marshal())
+	require.NoError(t, err)
+	assert.NoError(t, public.Verify([]byte("challenge"), signature))
+	require.NoError(t, conn.Close())
+
+	require.NoError(t, stop())
+	assert.NoFileExists(t, path)
+
+	// Временный каталог сокета не остаётся
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestServe_RefusesNonSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "important.txt")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0600))
+
+	err := Serve(context.Background(), path, agent.NewKeyring())
+	require.Error(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"runtime"
+)
+
+// ErrNoMemoryDir возвращается, если в системе не найден каталог в оперативной памяти.
+var ErrNoMemoryDir = errors.New("не найден каталог в оперативной памяти (tmpfs) для временных файлов с секретами")
+
+// MemoryTempDir создаёт каталог, доступный только владельцу, в файловой системе в памяти
+// ($XDG_RUNTIME_DIR или /dev/shm), чтобы расшифрованные данные не попадали на диск.
+// Вызывающий удаляет каталог сам.
+func MemoryTempDir(pattern string) (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", ErrNoMemoryDir
+	}
+
+	for _, base := range []string{os.Getenv("XDG_RUNTIME_DIR"), "/dev/shm"} {
+		if base == "" {
+			continue
+		}
+		if info, err := os.Stat(base); err != nil || !info.IsDir() {
+			continue
+		}
+		if dir, err := os.MkdirTemp(base, pattern); err == nil {
+			return dir, nil
+		}
+	}
+	return "", ErrNoMemoryDir
+}
@@ -3,14 +3,21 @@
 
 package session
 
-import "github.com/dvkhr/gophkeeper/client/storage/file"
+import (
+	"github.com/dvkhr/gophkeeper/client/storage/file"
+	"github.com/dvkhr/gophkeeper/pkg/crypto"
+)
 
 // Data — данные сессии
 type Data struct {
-	Salt          []byte
-	AccessToken   string
-	RefreshToken  string
-	MasterKeyHash []byte
+	Salt            []byte
+	AccessToken     string
+	RefreshToken    string
+	MasterKeyHash   []byte
+	KDF             *crypto.KDFParams
+	WrappedKey      []byte
+	VaultKeyVersion int64
+	UserID          string
 }
 
 // Manager управляет сессией клиента: загрузка соли, ввод пароля, создание gRPC-клиента
@@ -27,19 +34,27 @@ func (m *Manager) Load() (*Data, error) {
 		return nil, err
 	}
 	return &Data{
-		Salt:          data.Salt,
-		AccessToken:   data.AccessToken,
-		RefreshToken:  data.RefreshToken,
-		MasterKeyHash: data.MasterKeyHash,
+		Salt:            data.Salt,
+		AccessToken:     data.AccessToken,
+		RefreshToken:    data.RefreshToken,
+		MasterKeyHash:   data.MasterKeyHash,
+		KDF:             data.KDF,
+		WrappedKey:      data.WrappedKey,
+		VaultKeyVersion: data.VaultKeyVersion,
+		UserID:          data.UserID,
 	}, nil
 }
 
 func (m *Manager) Save(data *Data) error {
 	return file.Save(&file.Data{
-		Salt:          data.Salt,
-		AccessToken:   data.AccessToken,
-		RefreshToken:  data.RefreshToken,
-		MasterKeyHash: data.MasterKeyHash,
+		Salt:            data.Salt,
+		AccessToken:     data.AccessToken,
+		RefreshToken:    data.RefreshToken,
+		MasterKeyHash:   data.MasterKeyHash,
+		KDF:             data.KDF,
+		WrappedKey:      data.WrappedKey,
+		VaultKeyVersion: data.VaultKeyVersion,
+		UserID:          data.UserID,
 	})
 }
 
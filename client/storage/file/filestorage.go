@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+
+	"github.com/dvkhr/gophkeeper/pkg/crypto"
 )
 
 // Data — данные, которые хранятся в файле
@@ -13,21 +15,39 @@ type Data struct {
 	AccessToken   string `json:"access_token,omitempty"`
 	RefreshToken  string `json:"refresh_token,omitempty"`
 	MasterKeyHash []byte `json:"master_key_hash,omitempty"`
+
+	// KDF — алгоритм и параметры получения ключа из мастер-пароля; пусто для PBKDF2 старых версий
+	KDF *crypto.KDFParams `json:"kdf,omitempty"`
+
+	// WrappedKey — ключ хранилища, зашифрованный ключом из мастер-пароля.
+	// У старых сессий ключом хранилища становится прежний ключ из пароля: данные не перешифровываются.
+	WrappedKey []byte `json:"wrapped_key,omitempty"`
+
+	// VaultKeyVersion — версия ключа хранилища на сервере; 0, если ключ ещё не отправлен на сервер
+	VaultKeyVersion int64 `json:"vault_key_version,omitempty"`
+
+	// UserID — пользователь сессии; по нему выбирается файл локального хранилища.
+	// Пусто у сессий, созданных до разделения хранилищ по пользователям
+	UserID string `json:"user_id,omitempty"`
 }
 
-// getPath возвращает путь к файлу данных
-func getPath() string {
-	var dir string
+// Dir возвращает каталог, в котором клиент хранит свои файлы
+func Dir() string {
 	if runtime.GOOS == "windows" {
-		dir = os.Getenv("APPDATA")
-	} else {
-		home := os.Getenv("HOME")
-		dir = filepath.Join(home, ".config")
-		// Создаём директорию, если её нет
-		_ = os.MkdirAll(dir, 0700)
+		return os.Getenv("APPDATA")
 	}
 
-	return filepath.Join(dir, ".gophkeeper.json")
+	home := os.Getenv("HOME")
+	dir := filepath.Join(home, ".config")
+	// Создаём директорию, если её нет
+	_ = os.MkdirAll(dir, 0700)
+
+	return dir
+}
+
+// getPath возвращает путь к файлу данных
+func getPath() string {
+	return filepath.Join(Dir(), ".gophkeeper.json")
 }
 
 // Save сохраняет данные в файл с правами 0600
@@ -0,0 +1,419 @@
+// Package vault хранит локальную копию записей пользователя и очередь несинхронизированных изменений.
+// Данные сохраняются в файле `~/.config/.gophkeeper.vault`, зашифрованном мастер-ключом.
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/client/storage/file"
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/crypto"
+)
+
+// ConflictSuffix добавляется к ID локальной копии записи, изменённой одновременно на сервере.
+const ConflictSuffix = ".conflict"
+
+var (
+	// ErrNotFound возвращается, если записи нет в локальном хранилище.
+	ErrNotFound = errors.New("запись не найдена в локальном хранилище")
+
+	// ErrWrongKey возвращается, если хранилище зашифровано другим ключом.
+	ErrWrongKey = errors.New("локальное хранилище зашифровано другим ключом")
+)
+
+// staleSuffix добавляется к файлу хранилища, который не расшифровывается ключом пользователя.
+const staleSuffix = ".stale"
+
+// Op — тип локального изменения, ожидающего синхронизации.
+type Op string
+
+const (
+	OpPut    Op = "put"
+	OpDelete Op = "delete"
+)
+
+// Change — локальное изменение записи, ещё не отправленное на сервер.
+type Change struct {
+	Op           Op    `json:"op"`
+	BaseRevision int64 `json:"base_revision"`
+}
+
+// Upload — незавершённая загрузка содержимого бинарной записи. Шифротекст лежит
+// во временном файле Path, чтобы после перезапуска клиента продолжить загрузку
+// с того же шифротекста, не шифруя файл заново.
+type Upload struct {
+	ID     string `json:"id"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Sha256 []byte `json:"sha256"`
+
+	// Source, SourceSize и SourceModTime описывают исходный файл: если он изменился,
+	// сохранённый шифротекст устарел
+	Source        string `json:"source"`
+	SourceSize    int64  `json:"source_size"`
+	SourceModTime int64  `json:"source_mod_time"`
+}
+
+// state — содержимое файла хранилища до шифрования.
+type state struct {
+	Cursor  int64                     `json:"cursor"`
+	Records map[string]*pb.DataRecord `json:"records"`
+	Pending map[string]Change         `json:"pending"`
+	Uploads map[string]Upload         `json:"uploads,omitempty"`
+
+	// Rejected — причины окончательного отказа сервера по ID записи. Такие изменения
+	// не отправляются повторно, пока пользователь не изменит или не удалит запись
+	Rejected map[string]string `json:"rejected,omitempty"`
+}
+
+// Vault — зашифрованное локальное хранилище записей.
+// Записи хранятся в расшифрованном виде только в памяти, на диск попадает зашифрованный файл.
+type Vault struct {
+	path      string
+	encryptor *crypto.Encryptor
+	state     state
+}
+
+// DefaultPath возвращает путь к файлу хранилища рядом с файлом сессии.
+// Его используют сессии, в которых не сохранён пользователь.
+func DefaultPath() string {
+	return filepath.Join(file.Dir(), ".gophkeeper.vault")
+}
+
+// PathFor возвращает путь к файлу хранилища пользователя userID.
+// У каждого пользователя свой файл, поэтому вход под другим аккаунтом не затрагивает чужое хранилище;
+// имя файла строится из хэша ID, чтобы не раскрывать его.
+func PathFor(userID string) string {
+	if userID == "" {
+		return DefaultPath()
+	}
+	sum := sha256.Sum256([]byte(userID))
+	return filepath.Join(file.Dir(), ".gophkeeper-"+hex.EncodeToString(sum[:8])+".vault")
+}
+
+// Reclaim проверяет, что хранилище path расшифровывается ключом key. Хранилище, зашифрованное
+// другим ключом, откладывается в файл с суффиксом ".stale" — прочитать его очередь изменений
+// этим ключом нельзя, а без этого все команды завершались бы ошибкой. Возвращает путь
+// отложенного файла или пустую строку, если хранилище открывается или его ещё нет.
+func Reclaim(path string, key []byte) (string, error) {
+	_, err := Open(path, key)
+	if err == nil || !errors.Is(err, ErrWrongKey) {
+		return "", err
+	}
+
+	stale := path + staleSuffix
+	if err := os.Rename(path, stale); err != nil {
+		return "", fmt.Errorf("не удалось отложить локальное хранилище: %w", err)
+	}
+	return stale, nil
+}
+
+// Open открывает хранилище по пути path и расшифровывает его ключом key.
+// Если файла ещё нет, возвращается пустое хранилище.
+func Open(path string, key []byte) (*Vault, error) {
+	encryptor, err := crypto.NewEncryptor(key)
+	if err != nil {
+		return nil, fmt.Errorf("неверный ключ хранилища: %w", err)
+	}
+
+	v := &Vault{
+		path:      path,
+		encryptor: encryptor,
+		state: state{
+			Records: make(map[string]*pb.DataRecord),
+			Pending: make(map[string]Change),
+		},
+	}
+
+	ciphertext, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать локальное хранилище: %w", err)
+	}
+
+	plaintext, err := encryptor.Decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось расшифровать локальное хранилище: %w", ErrWrongKey)
+	}
+
+	if err := json.Unmarshal(plaintext, &v.state); err != nil {
+		return nil, fmt.Errorf("повреждено локальное хранилище: %w", err)
+	}
+	if v.state.Records == nil {
+		v.state.Records = make(map[string]*pb.DataRecord)
+	}
+	if v.state.Pending == nil {
+		v.state.Pending = make(map[string]Change)
+	}
+
+	return v, nil
+}
+
+// Save шифрует хранилище и атомарно записывает его на диск с правами 0600.
+func (v *Vault) Save() error {
+	plaintext, err := json.Marshal(v.state)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := v.encryptor.Encrypt(plaintext)
+	if err != nil {
+		return err
+	}
+
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, ciphertext, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.path)
+}
+
+// Cursor возвращает курсор последней успешной синхронизации.
+func (v *Vault) Cursor() int64 {
+	return v.state.Cursor
+}
+
+// Get возвращает запись по ID.
+func (v *Vault) Get(id string) (*pb.DataRecord, error) {
+	record, ok := v.state.Records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return record, nil
+}
+
+// List возвращает все локальные записи в порядке убывания времени изменения.
+func (v *Vault) List() []*pb.DataRecord {
+	records := make([]*pb.DataRecord, 0, len(v.state.Records))
+	for _, record := range v.state.Records {
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Timestamp != records[j].Timestamp {
+			return records[i].Timestamp > records[j].Timestamp
+		}
+		return records[i].Id < records[j].Id
+	})
+	return records
+}
+
+// Put сохраняет запись локально и ставит её в очередь на отправку.
+// Базовой ревизией считается ревизия последней известной серверной версии записи.
+func (v *Vault) Put(record *pb.DataRecord) {
+	base := v.baseRevision(record.Id)
+
+	stored := cloneRecord(record)
+	stored.Revision = base
+	stored.Timestamp = time.Now().Unix()
+
+	v.state.Records[record.Id] = stored
+	v.state.Pending[record.Id] = Change{Op: OpPut, BaseRevision: base}
+	delete(v.state.Rejected, record.Id)
+}
+
+// Delete удаляет запись локально и ставит удаление в очередь на отправку.
+func (v *Vault) Delete(id string) error {
+	if _, ok := v.state.Records[id]; !ok {
+		return ErrNotFound
+	}
+
+	base := v.baseRevision(id)
+	delete(v.state.Records, id)
+	delete(v.state.Rejected, id)
+
+	if change, ok := v.state.Pending[id]; ok && change.Op == OpPut && change.BaseRevision == 0 {
+		// Запись ещё не попала на сервер — удалять там нечего
+		delete(v.state.Pending, id)
+		return nil
+	}
+
+	v.state.Pending[id] = Change{Op: OpDelete, BaseRevision: base}
+	return nil
+}
+
+// Upload возвращает незавершённую загрузку содержимого записи id.
+func (v *Vault) Upload(id string) (Upload, bool) {
+	upload, ok := v.state.Uploads[id]
+	return upload, ok
+}
+
+// SetUpload запоминает незавершённую загрузку содержимого записи id.
+func (v *Vault) SetUpload(id string, upload Upload) {
+	if v.state.Uploads == nil {
+		v.state.Uploads = make(map[string]Upload)
+	}
+	v.state.Uploads[id] = upload
+}
+
+// DeleteUpload забывает загрузку содержимого записи id.
+func (v *Vault) DeleteUpload(id string) {
+	delete(v.state.Uploads, id)
+}
+
+// Rejected возвращает окончательно отклонённые сервером изменения: ID записи и причину отказа.
+func (v *Vault) Rejected() map[string]string {
+	return maps.Clone(v.state.Rejected)
+}
+
+// HasPending сообщает, есть ли несинхронизированные изменения.
+func (v *Vault) HasPending() bool {
+	return len(v.state.Pending) > 0
+}
+
+// Pending возвращает изменения для отправки на сервер: записи с базовой ревизией в поле Revision
+// и удаления в виде tombstones.
+func (v *Vault) Pending() ([]*pb.DataRecord, []*pb.Tombstone) {
+	var (
+		records   []*pb.DataRecord
+		deletions []*pb.Tombstone
+	)
+
+	ids := make([]string, 0, len(v.state.Pending))
+	for id := range v.state.Pending {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		change := v.state.Pending[id]
+		switch change.Op {
+		case OpPut:
+			record := cloneRecord(v.state.Records[id])
+			record.Revision = change.BaseRevision
+			records = append(records, record)
+		case OpDelete:
+			deletions = append(deletions, &pb.Tombstone{Id: id, Revision: change.BaseRevision})
+		}
+	}
+
+	return records, deletions
+}
+
+// Apply применяет ответ сервера на синхронизацию.
+// Отправленные изменения снимаются с очереди. Изменения, отклонённые из-за временного сбоя,
+// остаются в ней и отправляются при следующей синхронизации; окончательно отклонённые
+// переносятся в Rejected, а локальная версия записи сохраняется до исправления. Локальная версия записи, конфликтующая
+// с серверной, сохраняется под ID с суффиксом ConflictSuffix и остаётся в очереди,
+// а сама запись заменяется серверной версией.
+func (v *Vault) Apply(resp *pb.SyncResponse) {
+	conflicted := make(map[string]bool, len(resp.Conflicts))
+	for _, conflict := range resp.Conflicts {
+		conflicted[conflict.Id] = true
+	}
+	retryable := make(map[string]bool, len(resp.Rejected))
+	for _, r := range resp.Rejected {
+		if r.Retryable {
+			retryable[r.Id] = true
+			continue
+		}
+		if _, pending := v.state.Pending[r.Id]; pending {
+			if v.state.Rejected == nil {
+				v.state.Rejected = make(map[string]string)
+			}
+			v.state.Rejected[r.Id] = r.Reason
+		}
+	}
+
+	for id := range v.state.Pending {
+		if !conflicted[id] && !retryable[id] {
+			delete(v.state.Pending, id)
+		}
+	}
+
+	for _, conflict := range resp.Conflicts {
+		change := v.state.Pending[conflict.Id]
+		if local, ok := v.state.Records[conflict.Id]; ok && change.Op == OpPut {
+			copyID := conflict.Id + ConflictSuffix
+			copyRecord := cloneRecord(local)
+			copyRecord.Id = copyID
+			copyRecord.Revision = 0
+			v.state.Records[copyID] = copyRecord
+			v.state.Pending[copyID] = Change{Op: OpPut}
+		}
+
+		// Серверная версия записи могла измениться до курсора клиента — запросим её заново
+		delete(v.state.Records, conflict.Id)
+		delete(v.state.Pending, conflict.Id)
+	}
+
+	if resp.FullResync || v.state.Cursor == 0 {
+		for id := range v.state.Records {
+			if !v.local(id) {
+				delete(v.state.Records, id)
+			}
+		}
+	}
+
+	for _, tombstone := range resp.Tombstones {
+		if !v.local(tombstone.Id) {
+			delete(v.state.Records, tombstone.Id)
+		}
+	}
+
+	for _, record := range resp.Records {
+		if !v.local(record.Id) {
+			v.state.Records[record.Id] = record
+		}
+	}
+
+	if len(resp.Conflicts) > 0 {
+		// Сбрасываем курсор, чтобы следующая синхронизация вернула актуальные версии конфликтных записей
+		v.state.Cursor = 0
+		return
+	}
+	v.state.Cursor = resp.Cursor
+}
+
+// local сообщает, что локальная версия записи не должна заменяться серверной:
+// изменение ещё не отправлено или окончательно отклонено сервером.
+func (v *Vault) local(id string) bool {
+	if _, pending := v.state.Pending[id]; pending {
+		return true
+	}
+	_, rejected := v.state.Rejected[id]
+	return rejected
+}
+
+// baseRevision возвращает ревизию последней известной серверной версии записи.
+func (v *Vault) baseRevision(id string) int64 {
+	if change, ok := v.state.Pending[id]; ok {
+		return change.BaseRevision
+	}
+	if record, ok := v.state.Records[id]; ok {
+		return record.Revision
+	}
+	return 0
+}
+
+// cloneRecord возвращает копию записи, не разделяющую с исходной карту метаданных и список меток.
+func cloneRecord(record *pb.DataRecord) *pb.DataRecord {
+	metadata := make(map[string]string, len(record.Metadata))
+	for k, v := range record.Metadata {
+		metadata[k] = v
+	}
+
+	return &pb.DataRecord{
+		Id:            record.Id,
+		Type:          record.Type,
+		EncryptedData: append([]byte(nil), record.EncryptedData...),
+		Metadata:      metadata,
+		Timestamp:     record.Timestamp,
+		Revision:      record.Revision,
+		BlobSize:      record.BlobSize,
+		BlobSha256:    append([]byte(nil), record.BlobSha256...),
+		Folder:        record.Folder,
+		Tags:          append([]string(nil), record.Tags...),
+	}
+}
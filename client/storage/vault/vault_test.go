@@ -0,0 +1,267 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = []byte("this-is-32-byte-key-for-aes-256!")
+
+func openTestVault(t *testing.T) (*Vault, string) {
+	path := filepath.Join(t.TempDir(), ".gophkeeper.vault")
+	v, err := Open(path, testKey)
+	require.NoError(t, err)
+	return v, path
+}
+
+// сохранение, шифрование и повторное открытие
+func TestVault_SaveAndOpen(t *testing.T) {
+	v, path := openTestVault(t)
+
+	v.Put(&pb.DataRecord{Id: "note", Type: "text", EncryptedData: []byte("secret note")})
+	require.NoError(t, v.Save())
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "secret note")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	reopened, err := Open(path, testKey)
+	require.NoError(t, err)
+	record, err := reopened.Get("note")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret note"), record.EncryptedData)
+	assert.True(t, reopened.HasPending())
+}
+
+// чужой ключ
+func TestVault_OpenWithWrongKey(t *testing.T) {
+	v, path := openTestVault(t)
+	require.NoError(t, v.Save())
+
+	_, err := Open(path, []byte("another-32-byte-key-for-testing!"))
+	require.ErrorIs(t, err, ErrWrongKey)
+}
+
+// хранилище с чужим ключом откладывается, своё остаётся на месте
+func TestReclaim(t *testing.T) {
+	v, path := openTestVault(t)
+	v.Put(&pb.DataRecord{Id: "note", Type: "text"})
+	require.NoError(t, v.Save())
+
+	stale, err := Reclaim(path, testKey)
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+	assert.FileExists(t, path)
+
+	stale, err = Reclaim(path, []byte("another-32-byte-key-for-testing!"))
+	require.NoError(t, err)
+	assert.Equal(t, path+staleSuffix, stale)
+	assert.NoFileExists(t, path)
+
+	reopened, err := Open(stale, testKey)
+	require.NoError(t, err)
+	records, _ := reopened.Pending()
+	assert.Len(t, records, 1)
+
+	stale, err = Reclaim(filepath.Join(t.TempDir(), "missing.vault"), testKey)
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+}
+
+// незавершённая загрузка переживает перезапуск клиента
+func TestVault_Uploads(t *testing.T) {
+	v, path := openTestVault(t)
+
+	upload := Upload{ID: "0123456789abcdef", Path: "/tmp/upload-1", Size: 42, Sha256: []byte("hash"), Source: "/home/user/file"}
+	v.SetUpload("file", upload)
+	require.NoError(t, v.Save())
+
+	reopened, err := Open(path, testKey)
+	require.NoError(t, err)
+	got, ok := reopened.Upload("file")
+	require.True(t, ok)
+	assert.Equal(t, upload, got)
+
+	reopened.DeleteUpload("file")
+	_, ok = reopened.Upload("file")
+	assert.False(t, ok)
+}
+
+func TestPathFor(t *testing.T) {
+	assert.Equal(t, DefaultPath(), PathFor(""))
+	assert.NotEqual(t, PathFor("user-1"), PathFor("user-2"))
+	assert.Equal(t, PathFor("user-1"), PathFor("user-1"))
+	assert.NotContains(t, PathFor("user-1"), "user-1")
+}
+
+// очередь изменений и применение ответа сервера
+func TestVault_PendingAndApply(t *testing.T) {
+	v, _ := openTestVault(t)
+
+	v.Put(&pb.DataRecord{Id: "a", Type: "text", EncryptedData: []byte("a")})
+
+	records, deletions := v.Pending()
+	require.Len(t, records, 1)
+	assert.Equal(t, int64(0), records[0].Revision)
+	assert.Empty(t, deletions)
+
+	v.Apply(&pb.SyncResponse{
+		Records: []*pb.DataRecord{
+			{Id: "a", Type: "text", EncryptedData: []byte("a"), Revision: 1},
+			{Id: "b", Type: "text", EncryptedData: []byte("b"), Revision: 3},
+		},
+		Cursor: 10,
+	})
+
+	assert.False(t, v.HasPending())
+	assert.Equal(t, int64(10), v.Cursor())
+	assert.Len(t, v.List(), 2)
+
+	// изменение отправляется с базовой ревизией сервера
+	v.Put(&pb.DataRecord{Id: "b", Type: "text", EncryptedData: []byte("b2")})
+	require.NoError(t, v.Delete("a"))
+
+	records, deletions = v.Pending()
+	require.Len(t, records, 1)
+	assert.Equal(t, int64(3), records[0].Revision)
+	require.Len(t, deletions, 1)
+	assert.Equal(t, "a", deletions[0].Id)
+	assert.Equal(t, int64(1), deletions[0].Revision)
+}
+
+// tombstone с сервера удаляет локальную копию
+func TestVault_ApplyTombstones(t *testing.T) {
+	v, _ := openTestVault(t)
+
+	v.Apply(&pb.SyncResponse{
+		Records: []*pb.DataRecord{{Id: "a", Type: "text", Revision: 1}},
+		Cursor:  5,
+	})
+	v.Apply(&pb.SyncResponse{
+		Tombstones: []*pb.Tombstone{{Id: "a", Revision: 2}},
+		Cursor:     6,
+	})
+
+	_, err := v.Get("a")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, int64(6), v.Cursor())
+}
+
+// конфликтная локальная версия сохраняется отдельной записью
+func TestVault_ApplyConflict(t *testing.T) {
+	v, _ := openTestVault(t)
+
+	v.Apply(&pb.SyncResponse{
+		Records: []*pb.DataRecord{{Id: "a", Type: "text", EncryptedData: []byte("server"), Revision: 1}},
+		Cursor:  5,
+	})
+
+	v.Put(&pb.DataRecord{Id: "a", Type: "text", EncryptedData: []byte("local")})
+	v.Apply(&pb.SyncResponse{
+		Conflicts: []*pb.SyncConflict{{Id: "a", BaseRevision: 1, ServerRevision: 2}},
+		Cursor:    7,
+	})
+
+	conflictCopy, err := v.Get("a" + ConflictSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("local"), conflictCopy.EncryptedData)
+
+	records, _ := v.Pending()
+	require.Len(t, records, 1)
+	assert.Equal(t, "a"+ConflictSuffix, records[0].Id)
+
+	// курсор сброшен, чтобы получить серверную версию конфликтной записи
+	assert.Equal(t, int64(0), v.Cursor())
+}
+
+// изменения, отклонённые из-за временного сбоя, остаются в очереди
+func TestVault_ApplyRejected(t *testing.T) {
+	v, _ := openTestVault(t)
+
+	v.Put(&pb.DataRecord{Id: "a", Type: "text", EncryptedData: []byte("a")})
+	v.Put(&pb.DataRecord{Id: "b", Type: "text", EncryptedData: []byte("b")})
+	v.Apply(&pb.SyncResponse{
+		Records:  []*pb.DataRecord{{Id: "a", Type: "text", EncryptedData: []byte("a"), Revision: 1}},
+		Rejected: []*pb.SyncRejected{{Id: "b", Reason: "failed to save record", Retryable: true}},
+		Cursor:   3,
+	})
+
+	records, _ := v.Pending()
+	require.Len(t, records, 1)
+	assert.Equal(t, "b", records[0].Id)
+	assert.Empty(t, v.Rejected())
+
+	record, err := v.Get("b")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("b"), record.EncryptedData)
+}
+
+// окончательно отклонённые изменения не отправляются повторно, но локальная версия сохраняется
+func TestVault_ApplyRejectedPermanently(t *testing.T) {
+	v, path := openTestVault(t)
+
+	v.Put(&pb.DataRecord{Id: "bad", Type: "Bad Type", EncryptedData: []byte("local")})
+	v.Apply(&pb.SyncResponse{
+		Rejected:   []*pb.SyncRejected{{Id: "bad", Reason: `invalid record type "Bad Type"`}},
+		FullResync: true,
+		Cursor:     3,
+	})
+
+	assert.False(t, v.HasPending())
+	assert.Equal(t, map[string]string{"bad": `invalid record type "Bad Type"`}, v.Rejected())
+	record, err := v.Get("bad")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("local"), record.EncryptedData)
+
+	require.NoError(t, v.Save())
+	reopened, err := Open(path, testKey)
+	require.NoError(t, err)
+	assert.Len(t, reopened.Rejected(), 1)
+
+	// исправленная запись снова отправляется
+	reopened.Put(&pb.DataRecord{Id: "bad", Type: "text", EncryptedData: []byte("fixed")})
+	assert.Empty(t, reopened.Rejected())
+	assert.True(t, reopened.HasPending())
+}
+
+// папка и метки сохраняются в файле и в очереди на отправку
+func TestVault_FolderAndTags(t *testing.T) {
+	v, path := openTestVault(t)
+
+	tags := []string{"prod", "team"}
+	v.Put(&pb.DataRecord{Id: "db", Type: "loginpass", Folder: "team/prod", Tags: tags})
+	tags[0] = "changed"
+	require.NoError(t, v.Save())
+
+	reopened, err := Open(path, testKey)
+	require.NoError(t, err)
+	record, err := reopened.Get("db")
+	require.NoError(t, err)
+	assert.Equal(t, "team/prod", record.Folder)
+	assert.Equal(t, []string{"prod", "team"}, record.Tags)
+
+	records, _ := reopened.Pending()
+	require.Len(t, records, 1)
+	assert.Equal(t, "team/prod", records[0].Folder)
+	assert.Equal(t, []string{"prod", "team"}, records[0].Tags)
+}
+
+// удаление ещё не отправленной записи не попадает в очередь
+func TestVault_DeleteUnsynced(t *testing.T) {
+	v, _ := openTestVault(t)
+
+	v.Put(&pb.DataRecord{Id: "draft", Type: "text"})
+	require.NoError(t, v.Delete("draft"))
+
+	assert.False(t, v.HasPending())
+	assert.ErrorIs(t, v.Delete("missing"), ErrNotFound)
+}
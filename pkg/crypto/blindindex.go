@@ -0,0 +1,52 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+const (
+	// blindIndexContext отделяет ключ слепых индексов от других применений ключа хранилища.
+	blindIndexContext = "gophkeeper/blind-index/v1"
+
+	// folderIDContext отделяет идентификаторы папок от слепых индексов метаданных.
+	folderIDContext = "gophkeeper/folder-id/v1"
+)
+
+// BlindIndexer вычисляет слепые индексы — HMAC-SHA256 пар «ключ метаданных — значение».
+// Сервер может искать записи по совпадению индексов, не зная самих значений.
+// Одинаковые значения дают одинаковые индексы, поэтому индексировать стоит
+// только поля, по которым нужен поиск.
+type BlindIndexer struct {
+	key []byte
+}
+
+// NewBlindIndexer создаёт BlindIndexer с ключом, полученным из ключа хранилища key.
+func NewBlindIndexer(key []byte) *BlindIndexer {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(blindIndexContext))
+	return &BlindIndexer{key: mac.Sum(nil)}
+}
+
+// Index возвращает слепой индекс значения value поля метаданных field.
+func (b *BlindIndexer) Index(field, value string) []byte {
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write(appendField(appendField(nil, field), value))
+	return mac.Sum(nil)
+}
+
+// KeyIndex возвращает слепой индекс наличия поля метаданных field с любым значением.
+// Значение поля в индекс не входит, поэтому он не совпадает ни с одним индексом Index.
+func (b *BlindIndexer) KeyIndex(field string) []byte {
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write(appendField(nil, field))
+	return mac.Sum(nil)
+}
+
+// FolderID возвращает идентификатор папки path: одинаковый на всех устройствах пользователя
+// и не раскрывающий серверу имя папки.
+func (b *BlindIndexer) FolderID(path string) []byte {
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write(appendField(appendField(nil, folderIDContext), path))
+	return mac.Sum(nil)
+}
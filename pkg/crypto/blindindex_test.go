@@ -0,0 +1,45 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// индекс детерминирован для ключа и различает поле, значение и ключ
+func TestBlindIndexer_Index(t *testing.T) {
+	indexer := NewBlindIndexer([]byte("this-is-32-byte-key-for-aes-256!"))
+
+	index := indexer.Index("site", "example.com")
+	assert.Len(t, index, 32)
+	assert.Equal(t, index, indexer.Index("site", "example.com"))
+
+	assert.NotEqual(t, index, indexer.Index("site", "example.org"))
+	assert.NotEqual(t, index, indexer.Index("bank", "example.com"))
+	assert.NotEqual(t, indexer.Index("ab", "c"), indexer.Index("a", "bc"))
+
+	other := NewBlindIndexer([]byte("another-32-byte-key-for-testing!"))
+	assert.NotEqual(t, index, other.Index("site", "example.com"))
+}
+
+// индекс ключа не зависит от значения и не совпадает с индексами пар
+func TestBlindIndexer_KeyIndex(t *testing.T) {
+	indexer := NewBlindIndexer([]byte("this-is-32-byte-key-for-aes-256!"))
+
+	index := indexer.KeyIndex("site")
+	assert.Len(t, index, 32)
+	assert.Equal(t, index, indexer.KeyIndex("site"))
+	assert.NotEqual(t, index, indexer.KeyIndex("bank"))
+	assert.NotEqual(t, index, indexer.Index("site", ""))
+}
+
+// идентификатор папки детерминирован и не совпадает с индексами метаданных
+func TestBlindIndexer_FolderID(t *testing.T) {
+	indexer := NewBlindIndexer([]byte("this-is-32-byte-key-for-aes-256!"))
+
+	id := indexer.FolderID("team/prod")
+	assert.Equal(t, id, indexer.FolderID("team/prod"))
+	assert.NotEqual(t, id, indexer.FolderID("team/dev"))
+	assert.NotEqual(t, id, indexer.KeyIndex("team/prod"))
+	assert.NotEqual(t, id, indexer.Index("folder", "team/prod"))
+}
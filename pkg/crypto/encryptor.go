@@ -4,8 +4,21 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"io"
+	"sort"
+)
+
+// sealedVersion — версия формата шифротекста с дополнительными данными (AAD).
+// Шифротексты Encrypt версии не имеют: они начинаются сразу со случайного nonce.
+const sealedVersion = 1
+
+// Контексты AAD отделяют данные, метаданные и содержимое записей от других применений ключа.
+const (
+	recordAADContext   = "gophkeeper/record/v1"
+	metadataAADContext = "gophkeeper/metadata/v1"
+	streamAADContext   = "gophkeeper/stream/v1"
 )
 
 // Encryptor предоставляет методы для шифрования и расшифровки данных с использованием AES-GCM.
@@ -70,3 +83,87 @@ func (e *Encryptor) Decrypt(ciphertext []byte) ([]byte, error) {
 
 	return plaintext, nil
 }
+
+// EncryptWithAAD шифрует данные AES-GCM и подтверждает подлинность дополнительных данных aad.
+// Возвращает зашифрованные данные в формате: [версия: 1 байт][nonce][ciphertext].
+// Расшифровать их можно только DecryptWithAAD с теми же aad.
+func (e *Encryptor) EncryptWithAAD(plaintext, aad []byte) ([]byte, error) {
+	gcm, err := e.newGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 1+gcm.NonceSize(), 1+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	out[0] = sealedVersion
+	nonce := out[1:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(out, nonce, plaintext, sealedAAD(aad)), nil
+}
+
+// DecryptWithAAD расшифровывает данные EncryptWithAAD и проверяет, что они зашифрованы с теми же aad.
+// Шифротексты старого формата Encrypt (без версии и AAD) расшифровываются без проверки aad.
+func (e *Encryptor) DecryptWithAAD(ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := e.newGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) > 1+gcm.NonceSize() && ciphertext[0] == sealedVersion {
+		nonce, encrypted := ciphertext[1:1+gcm.NonceSize()], ciphertext[1+gcm.NonceSize():]
+		if plaintext, err := gcm.Open(nil, nonce, encrypted, sealedAAD(aad)); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	// Старый формат: nonce случаен и может начинаться с байта версии, поэтому проверяем оба варианта
+	return e.Decrypt(ciphertext)
+}
+
+// sealedAAD добавляет к aad версию формата, чтобы её нельзя было подменить.
+func sealedAAD(aad []byte) []byte {
+	return append([]byte{sealedVersion}, aad...)
+}
+
+// RecordAAD возвращает каноническое представление ID, типа и метаданных записи для AAD.
+// Каждое поле записывается с длиной, метаданные — в порядке ключей,
+// поэтому результат не зависит от порядка обхода map и не допускает неоднозначных склеек.
+func RecordAAD(id, typ string, metadata map[string]string) []byte {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	aad := appendField(nil, recordAADContext)
+	aad = appendField(aad, id)
+	aad = appendField(aad, typ)
+	aad = binary.BigEndian.AppendUint32(aad, uint32(len(keys)))
+	for _, k := range keys {
+		aad = appendField(aad, k)
+		aad = appendField(aad, metadata[k])
+	}
+	return aad
+}
+
+// MetadataAAD возвращает AAD зашифрованных метаданных записи: её ID и тип.
+func MetadataAAD(id, typ string) []byte {
+	aad := appendField(nil, metadataAADContext)
+	aad = appendField(aad, id)
+	return appendField(aad, typ)
+}
+
+// StreamAAD возвращает AAD потокового содержимого бинарной записи — её ID.
+// Метаданные в AAD не входят: их можно менять, не загружая содержимое заново.
+func StreamAAD(id string) []byte {
+	aad := appendField(nil, streamAADContext)
+	return appendField(aad, id)
+}
+
+// appendField дописывает строку с длиной в 4 байта big-endian.
+func appendField(dst []byte, s string) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(s)))
+	return append(dst, s...)
+}
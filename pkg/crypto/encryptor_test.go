@@ -49,3 +49,53 @@ func TestEncryptor_DecryptWithWrongKey(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "decryption failed")
 }
+
+// AAD привязывает шифротекст к записи
+func TestEncryptor_EncryptWithAAD(t *testing.T) {
+	encryptor, err := NewEncryptor([]byte("this-is-32-byte-key-for-aes-256!"))
+	require.NoError(t, err)
+
+	aad := RecordAAD("id-1", "text", map[string]string{"site": "example.com", "user": "alice"})
+
+	ciphertext, err := encryptor.EncryptWithAAD([]byte("secret"), aad)
+	require.NoError(t, err)
+
+	decrypted, err := encryptor.DecryptWithAAD(ciphertext, aad)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), decrypted)
+
+	for _, other := range [][]byte{
+		RecordAAD("id-2", "text", map[string]string{"site": "example.com", "user": "alice"}),
+		RecordAAD("id-1", "card", map[string]string{"site": "example.com", "user": "alice"}),
+		RecordAAD("id-1", "text", map[string]string{"site": "evil.com", "user": "alice"}),
+		RecordAAD("id-1", "text", nil),
+	} {
+		_, err = encryptor.DecryptWithAAD(ciphertext, other)
+		assert.Error(t, err)
+	}
+}
+
+// записи старого формата расшифровываются без AAD
+func TestEncryptor_DecryptWithAAD_Legacy(t *testing.T) {
+	encryptor, err := NewEncryptor([]byte("this-is-32-byte-key-for-aes-256!"))
+	require.NoError(t, err)
+
+	ciphertext, err := encryptor.Encrypt([]byte("old secret"))
+	require.NoError(t, err)
+
+	decrypted, err := encryptor.DecryptWithAAD(ciphertext, RecordAAD("id-1", "text", nil))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("old secret"), decrypted)
+}
+
+// каноническое представление не зависит от порядка метаданных и различает границы полей
+func TestRecordAAD_Canonical(t *testing.T) {
+	a := RecordAAD("id", "text", map[string]string{"a": "1", "b": "2", "c": "3"})
+	b := RecordAAD("id", "text", map[string]string{"c": "3", "b": "2", "a": "1"})
+	assert.Equal(t, a, b)
+
+	assert.NotEqual(t, RecordAAD("ab", "c", nil), RecordAAD("a", "bc", nil))
+	assert.NotEqual(t,
+		RecordAAD("id", "text", map[string]string{"k": "v1"}),
+		RecordAAD("id", "text", map[string]string{"kv": "1"}))
+}
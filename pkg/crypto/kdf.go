@@ -0,0 +1,84 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Алгоритмы получения ключа из мастер-пароля
+const (
+	KDFPBKDF2   = "pbkdf2-sha256"
+	KDFArgon2id = "argon2id"
+)
+
+// Параметры Argon2id по умолчанию
+const (
+	Argon2Time        = 3         // количество проходов
+	Argon2Memory      = 64 * 1024 // объём памяти, КиБ
+	Argon2Parallelism = 4         // количество потоков
+)
+
+// ErrUnknownKDF возвращается для неизвестного алгоритма получения ключа.
+var ErrUnknownKDF = errors.New("unknown key derivation function")
+
+// KDFParams описывает алгоритм получения ключа и его параметры.
+// Сохраняется вместе с солью, чтобы ключ можно было получить заново после смены параметров по умолчанию.
+type KDFParams struct {
+	Algorithm   string `json:"algorithm"`
+	Iterations  uint32 `json:"iterations"`            // итерации PBKDF2 или проходы Argon2id
+	Memory      uint32 `json:"memory,omitempty"`      // память Argon2id, КиБ
+	Parallelism uint8  `json:"parallelism,omitempty"` // потоки Argon2id
+	KeyLength   uint32 `json:"key_length"`
+}
+
+// DefaultKDFParams возвращает параметры для новых ключей: Argon2id.
+func DefaultKDFParams() KDFParams {
+	return KDFParams{
+		Algorithm:   KDFArgon2id,
+		Iterations:  Argon2Time,
+		Memory:      Argon2Memory,
+		Parallelism: Argon2Parallelism,
+		KeyLength:   KeyLength,
+	}
+}
+
+// LegacyKDFParams возвращает параметры PBKDF2, которыми получены ключи до появления KDFParams.
+func LegacyKDFParams() KDFParams {
+	return KDFParams{
+		Algorithm:  KDFPBKDF2,
+		Iterations: Iterations,
+		KeyLength:  KeyLength,
+	}
+}
+
+// Derive получает ключ из пароля и соли по алгоритму и параметрам p.
+func (p KDFParams) Derive(password string, salt []byte) ([]byte, error) {
+	if p.KeyLength == 0 || p.Iterations == 0 {
+		return nil, fmt.Errorf("invalid %s parameters", p.Algorithm)
+	}
+
+	switch p.Algorithm {
+	case KDFArgon2id:
+		if p.Memory == 0 || p.Parallelism == 0 {
+			return nil, fmt.Errorf("invalid %s parameters", p.Algorithm)
+		}
+		return argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength), nil
+	case KDFPBKDF2:
+		return pbkdf2.Key([]byte(password), salt, int(p.Iterations), int(p.KeyLength), sha256.New), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKDF, p.Algorithm)
+	}
+}
+
+// Outdated сообщает, что ключ получен слабее, чем параметрами по умолчанию, и его стоит обновить.
+func (p KDFParams) Outdated() bool {
+	def := DefaultKDFParams()
+	if p.Algorithm != def.Algorithm {
+		return true
+	}
+	return p.Iterations < def.Iterations || p.Memory < def.Memory
+}
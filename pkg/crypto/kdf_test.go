@@ -0,0 +1,67 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// параметры по умолчанию — Argon2id, ключ детерминирован для пароля и соли
+func TestKDFParams_DeriveArgon2id(t *testing.T) {
+	kdf := DefaultKDFParams()
+	assert.Equal(t, KDFArgon2id, kdf.Algorithm)
+	assert.False(t, kdf.Outdated())
+
+	salt := []byte("test-salt-1234567890123456789012")
+
+	key1, err := kdf.Derive("master-password", salt)
+	require.NoError(t, err)
+	assert.Len(t, key1, KeyLength)
+
+	key2, err := kdf.Derive("master-password", salt)
+	require.NoError(t, err)
+	assert.Equal(t, key1, key2)
+
+	key3, err := kdf.Derive("other-password", salt)
+	require.NoError(t, err)
+	assert.NotEqual(t, key1, key3)
+}
+
+// старые параметры дают тот же ключ, что и DeriveKey, и требуют обновления
+func TestKDFParams_Legacy(t *testing.T) {
+	kdf := LegacyKDFParams()
+	assert.True(t, kdf.Outdated())
+
+	salt := []byte("test-salt-1234567890123456789012")
+
+	key, err := kdf.Derive("master-password", salt)
+	require.NoError(t, err)
+	assert.Equal(t, DeriveKey("master-password", salt), key)
+}
+
+// неизвестный алгоритм и пустые параметры
+func TestKDFParams_Invalid(t *testing.T) {
+	_, err := KDFParams{Algorithm: "scrypt", Iterations: 1, KeyLength: 32}.Derive("pass", []byte("salt"))
+	assert.ErrorIs(t, err, ErrUnknownKDF)
+
+	_, err = KDFParams{Algorithm: KDFArgon2id, Iterations: 1, KeyLength: 32}.Derive("pass", []byte("salt"))
+	assert.Error(t, err)
+}
+
+// ключ данных, обёрнутый ключом из пароля, восстанавливается только тем же ключом
+func TestWrapKey(t *testing.T) {
+	kek := []byte("this-is-32-byte-key-for-aes-256!")
+	key := []byte("data-key-32-bytes-for-the-vault!")
+
+	wrapped, err := WrapKey(kek, key)
+	require.NoError(t, err)
+	assert.NotContains(t, string(wrapped), string(key))
+
+	unwrapped, err := UnwrapKey(kek, wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, key, unwrapped)
+
+	_, err = UnwrapKey([]byte("another-32-byte-key-for-testing!"), wrapped)
+	assert.Error(t, err)
+}
@@ -13,7 +13,8 @@ const (
 	KeyLength  = 32    //длина ключа
 )
 
-// DeriveKey генерирует ключ из пароля и соли с помощью PBKDF2
+// DeriveKey генерирует ключ из пароля и соли с помощью PBKDF2.
+// Используется для ключей, созданных до появления KDFParams; новые ключи получаются через KDFParams.Derive.
 func DeriveKey(password string, salt []byte) []byte {
 	return pbkdf2.Key(
 		[]byte(password), // пароль
@@ -34,8 +35,35 @@ func GenerateSalt() ([]byte, error) {
 	return salt, nil
 }
 
+// GenerateKey генерирует случайный ключ длиной KeyLength
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
 // SHA256 возвращает хеш SHA-256 от входных данных
 func SHA256(data []byte) []byte {
 	hash := sha256.Sum256(data)
 	return hash[:]
 }
+
+// WrapKey шифрует ключ key ключом kek.
+func WrapKey(kek, key []byte) ([]byte, error) {
+	encryptor, err := NewEncryptor(kek)
+	if err != nil {
+		return nil, err
+	}
+	return encryptor.Encrypt(key)
+}
+
+// UnwrapKey расшифровывает ключ, зашифрованный WrapKey.
+func UnwrapKey(kek, wrapped []byte) ([]byte, error) {
+	encryptor, err := NewEncryptor(kek)
+	if err != nil {
+		return nil, err
+	}
+	return encryptor.Decrypt(wrapped)
+}
@@ -0,0 +1,281 @@
+package crypto
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Потоковое шифрование разбивает данные на сегменты по StreamSegmentSize байт
+// и шифрует каждый отдельно AES-GCM. Формат потока:
+//
+//	[версия: 1 байт][префикс nonce: 7 байт][сегмент 0][сегмент 1]...[последний сегмент]
+//
+// Nonce сегмента — [префикс: 7][номер сегмента: 4, big-endian][флаг последнего сегмента: 1].
+// Номер в nonce не даёт переставить сегменты, флаг — обрезать поток по границе сегмента.
+// AAD каждого сегмента — заголовок потока и AAD, переданные при шифровании (например, StreamAAD),
+// поэтому поток нельзя выдать за содержимое другой записи. В потоках версии 1 AAD — только заголовок.
+const (
+	StreamSegmentSize = 64 * 1024
+
+	streamVersion       = 2
+	streamVersionLegacy = 1
+	streamPrefixSize    = 7
+	streamHeaderSize    = 1 + streamPrefixSize
+	gcmTagSize          = 16
+)
+
+var (
+	// ErrStreamVersion возвращается для потока неизвестного формата.
+	ErrStreamVersion = errors.New("unsupported stream version")
+
+	// ErrStreamTruncated возвращается, если поток закончился до последнего сегмента.
+	ErrStreamTruncated = errors.New("stream truncated")
+
+	// ErrStreamCorrupted возвращается, если сегмент не прошёл проверку подлинности.
+	ErrStreamCorrupted = errors.New("decryption failed: invalid key or corrupted stream")
+
+	// ErrStreamTooLong возвращается, если число сегментов превышает счётчик nonce.
+	ErrStreamTooLong = errors.New("stream too long")
+)
+
+// EncryptStream возвращает io.WriteCloser, который шифрует записанные данные сегментами и пишет их в w.
+// aad привязывает поток к контексту (например, к записи): расшифровать его можно только с теми же aad.
+// Close дописывает последний сегмент и обязателен: без него поток считается обрезанным.
+// Close не закрывает w.
+func (e *Encryptor) EncryptStream(w io.Writer, aad []byte) (io.WriteCloser, error) {
+	return e.encryptStream(w, streamVersion, aad)
+}
+
+// encryptStream шифрует поток в формате версии version.
+func (e *Encryptor) encryptStream(w io.Writer, version byte, aad []byte) (io.WriteCloser, error) {
+	gcm, err := e.newGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	header[0] = version
+	if _, err := io.ReadFull(rand.Reader, header[1:]); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &streamWriter{
+		w:      w,
+		gcm:    gcm,
+		header: header,
+		aad:    segmentAAD(header, aad),
+		buf:    make([]byte, 0, StreamSegmentSize),
+		out:    make([]byte, 0, StreamSegmentSize+gcm.Overhead()),
+	}, nil
+}
+
+// DecryptStream возвращает io.Reader, который читает поток из r, проверяет и расшифровывает сегменты.
+// aad должны совпадать с переданными в EncryptStream; потоки версии 1 расшифровываются без их проверки.
+// Ошибка чтения возвращается при повреждении, перестановке сегментов или обрезанном потоке.
+func (e *Encryptor) DecryptStream(r io.Reader, aad []byte) (io.Reader, error) {
+	gcm, err := e.newGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrStreamTruncated
+		}
+		return nil, err
+	}
+	if header[0] != streamVersion && header[0] != streamVersionLegacy {
+		return nil, ErrStreamVersion
+	}
+
+	return &streamReader{
+		r:      bufio.NewReaderSize(r, StreamSegmentSize+gcm.Overhead()),
+		gcm:    gcm,
+		header: header,
+		aad:    segmentAAD(header, aad),
+		seg:    make([]byte, StreamSegmentSize+gcm.Overhead()),
+		out:    make([]byte, 0, StreamSegmentSize),
+	}, nil
+}
+
+// segmentAAD возвращает AAD сегментов потока с заголовком header.
+func segmentAAD(header, aad []byte) []byte {
+	if header[0] == streamVersionLegacy {
+		return header
+	}
+	return append(append([]byte{}, header...), aad...)
+}
+
+// StreamCiphertextSize возвращает размер потока, зашифрованного EncryptStream, для открытого текста размера n.
+func StreamCiphertextSize(n int64) int64 {
+	segments := max((n+StreamSegmentSize-1)/StreamSegmentSize, 1)
+	return streamHeaderSize + n + segments*int64(gcmTagSize)
+}
+
+// newGCM создаёт AES-GCM с ключом шифровальщика.
+func (e *Encryptor) newGCM() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// segmentNonce возвращает nonce сегмента с номером counter.
+func segmentNonce(header []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, streamPrefixSize+5)
+	nonce = append(nonce, header[1:]...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// streamWriter шифрует данные сегментами.
+// Полный сегмент отправляется только когда приходят следующие данные:
+// до Close неизвестно, окажется ли он последним.
+type streamWriter struct {
+	w       io.Writer
+	gcm     cipher.AEAD
+	header  []byte
+	aad     []byte
+	buf     []byte
+	out     []byte
+	counter uint32
+	closed  bool
+	err     error
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	if s.closed {
+		return 0, errors.New("write to closed stream")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		if len(s.buf) == StreamSegmentSize {
+			if err := s.flush(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(s.buf[len(s.buf):StreamSegmentSize], p)
+		s.buf = s.buf[:len(s.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close шифрует оставшиеся данные последним сегментом.
+func (s *streamWriter) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.flush(true)
+}
+
+// flush шифрует накопленный сегмент и пишет его в w.
+func (s *streamWriter) flush(last bool) error {
+	if s.counter == math.MaxUint32 && !last {
+		s.err = ErrStreamTooLong
+		return s.err
+	}
+
+	s.out = s.gcm.Seal(s.out[:0], segmentNonce(s.header, s.counter, last), s.buf, s.aad)
+	if _, err := s.w.Write(s.out); err != nil {
+		s.err = err
+		return err
+	}
+
+	s.counter++
+	s.buf = s.buf[:0]
+	return nil
+}
+
+// streamReader расшифровывает поток сегментами.
+type streamReader struct {
+	r       *bufio.Reader
+	gcm     cipher.AEAD
+	header  []byte
+	aad     []byte
+	seg     []byte
+	out     []byte
+	plain   []byte
+	counter uint32
+	done    bool
+	err     error
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.plain) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.done {
+			return 0, io.EOF
+		}
+		s.err = s.next()
+	}
+
+	n := copy(p, s.plain)
+	s.plain = s.plain[n:]
+	return n, nil
+}
+
+// next читает и расшифровывает следующий сегмент.
+// Сегмент считается последним, если за ним поток заканчивается.
+func (s *streamReader) next() error {
+	n, err := io.ReadFull(s.r, s.seg)
+	switch {
+	case err == io.EOF:
+		return ErrStreamTruncated
+	case err == io.ErrUnexpectedEOF:
+		s.done = true
+	case err != nil:
+		return err
+	default:
+		if _, err := s.r.Peek(1); err == io.EOF {
+			s.done = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if !s.done && s.counter == math.MaxUint32 {
+		return ErrStreamTooLong
+	}
+
+	plain, err := s.gcm.Open(s.out[:0], segmentNonce(s.header, s.counter, s.done), s.seg[:n], s.aad)
+	if err != nil {
+		if s.done {
+			// Последним оказался сегмент без флага — поток обрезан по границе сегмента
+			if _, errMiddle := s.gcm.Open(nil, segmentNonce(s.header, s.counter, false), s.seg[:n], s.aad); errMiddle == nil {
+				return ErrStreamTruncated
+			}
+		}
+		return ErrStreamCorrupted
+	}
+
+	s.counter++
+	s.plain = plain
+	return nil
+}
@@ -0,0 +1,150 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testStreamAAD = StreamAAD("file")
+
+func encryptStream(t *testing.T, encryptor *Encryptor, plaintext []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := encryptor.EncryptStream(&buf, testStreamAAD)
+	require.NoError(t, err)
+	_, err = w.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func decryptStream(encryptor *Encryptor, ciphertext []byte) ([]byte, error) {
+	r, err := encryptor.DecryptStream(bytes.NewReader(ciphertext), testStreamAAD)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// шифрование и расшифровка потоков разного размера, включая границы сегментов
+func TestStream_EncryptDecrypt(t *testing.T) {
+	encryptor, err := NewEncryptor([]byte("this-is-32-byte-key-for-aes-256!"))
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, StreamSegmentSize - 1, StreamSegmentSize, StreamSegmentSize + 1, 3*StreamSegmentSize + 17} {
+		plaintext := make([]byte, size)
+		_, err := rand.Read(plaintext)
+		require.NoError(t, err)
+
+		ciphertext := encryptStream(t, encryptor, plaintext)
+		assert.Equal(t, StreamCiphertextSize(int64(size)), int64(len(ciphertext)), "size %d", size)
+
+		decrypted, err := decryptStream(encryptor, ciphertext)
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, plaintext, decrypted, "size %d", size)
+	}
+}
+
+// запись мелкими частями даёт тот же формат
+func TestStream_SmallWrites(t *testing.T) {
+	encryptor, err := NewEncryptor([]byte("this-is-32-byte-key-for-aes-256!"))
+	require.NoError(t, err)
+
+	plaintext := bytes.Repeat([]byte("gophkeeper"), StreamSegmentSize/5)
+
+	var buf bytes.Buffer
+	w, err := encryptor.EncryptStream(&buf, testStreamAAD)
+	require.NoError(t, err)
+	for i := 0; i < len(plaintext); i += 1000 {
+		_, err := w.Write(plaintext[i:min(i+1000, len(plaintext))])
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	decrypted, err := decryptStream(encryptor, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+}
+
+// обрезка по границе сегмента и внутри сегмента
+func TestStream_Truncated(t *testing.T) {
+	encryptor, err := NewEncryptor([]byte("this-is-32-byte-key-for-aes-256!"))
+	require.NoError(t, err)
+
+	ciphertext := encryptStream(t, encryptor, make([]byte, 2*StreamSegmentSize+10))
+	segment := StreamSegmentSize + gcmTagSize
+
+	_, err = decryptStream(encryptor, ciphertext[:streamHeaderSize+segment])
+	assert.ErrorIs(t, err, ErrStreamTruncated)
+
+	_, err = decryptStream(encryptor, ciphertext[:streamHeaderSize])
+	assert.ErrorIs(t, err, ErrStreamTruncated)
+
+	_, err = decryptStream(encryptor, ciphertext[:len(ciphertext)-1])
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+}
+
+// перестановка сегментов
+func TestStream_Reordered(t *testing.T) {
+	encryptor, err := NewEncryptor([]byte("this-is-32-byte-key-for-aes-256!"))
+	require.NoError(t, err)
+
+	ciphertext := encryptStream(t, encryptor, make([]byte, 3*StreamSegmentSize))
+	segment := StreamSegmentSize + gcmTagSize
+
+	first := ciphertext[streamHeaderSize : streamHeaderSize+segment]
+	second := ciphertext[streamHeaderSize+segment : streamHeaderSize+2*segment]
+
+	var reordered []byte
+	reordered = append(reordered, ciphertext[:streamHeaderSize]...)
+	reordered = append(reordered, second...)
+	reordered = append(reordered, first...)
+	reordered = append(reordered, ciphertext[streamHeaderSize+2*segment:]...)
+
+	_, err = decryptStream(encryptor, reordered)
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+}
+
+// чужой ключ и неизвестная версия
+func TestStream_WrongKeyAndVersion(t *testing.T) {
+	encryptor1, _ := NewEncryptor([]byte("this-is-32-byte-key-for-aes-256!"))
+	encryptor2, _ := NewEncryptor([]byte("another-32-byte-key-for-testing!"))
+
+	ciphertext := encryptStream(t, encryptor1, []byte("secret data"))
+
+	_, err := decryptStream(encryptor2, ciphertext)
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+
+	ciphertext[0] = 3
+	_, err = decryptStream(encryptor1, ciphertext)
+	assert.ErrorIs(t, err, ErrStreamVersion)
+}
+
+// поток привязан к записи; потоки версии 1 читаются без проверки AAD
+func TestStream_AAD(t *testing.T) {
+	encryptor, err := NewEncryptor([]byte("this-is-32-byte-key-for-aes-256!"))
+	require.NoError(t, err)
+
+	ciphertext := encryptStream(t, encryptor, []byte("secret data"))
+	r, err := encryptor.DecryptStream(bytes.NewReader(ciphertext), StreamAAD("other-file"))
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+
+	var legacy bytes.Buffer
+	w, err := encryptor.encryptStream(&legacy, streamVersionLegacy, nil)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("old data"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	decrypted, err := decryptStream(encryptor, legacy.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []byte("old data"), decrypted)
+}
@@ -0,0 +1,77 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedAlgorithm возвращается для алгоритма HMAC, который не поддерживает TOTP.
+var ErrUnsupportedAlgorithm = errors.New("unsupported TOTP algorithm")
+
+// TOTP вычисляет одноразовый пароль по RFC 6238 для момента t.
+// algorithm — SHA1, SHA256 или SHA512, period — длина шага времени, digits — число цифр кода.
+func TOTP(secret []byte, t time.Time, period time.Duration, digits int, algorithm string) (string, error) {
+	if period < time.Second {
+		return "", fmt.Errorf("invalid TOTP period: %s", period)
+	}
+	if digits < 1 || digits > 10 {
+		return "", fmt.Errorf("invalid TOTP digits: %d", digits)
+	}
+
+	newHash, err := totpHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	counter := uint64(t.Unix()) / uint64(period/time.Second)
+	return hotp(newHash, secret, counter, digits), nil
+}
+
+// TOTPRemaining возвращает время до смены кода с шагом period после момента t.
+func TOTPRemaining(t time.Time, period time.Duration) time.Duration {
+	step := int64(period / time.Second)
+	if step <= 0 {
+		return 0
+	}
+	return time.Duration(step-t.Unix()%step) * time.Second
+}
+
+// hotp вычисляет HOTP по RFC 4226 с динамическим усечением.
+func hotp(newHash func() hash.Hash, secret []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(newHash, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%mod)
+}
+
+func totpHash(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToUpper(algorithm) {
+	case "", "SHA1":
+		return sha1.New, nil
+	case "SHA256":
+		return sha256.New, nil
+	case "SHA512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+}
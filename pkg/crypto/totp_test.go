@@ -0,0 +1,63 @@
+package crypto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тестовые векторы из приложения B RFC 6238
+func TestTOTP_RFC6238(t *testing.T) {
+	secrets := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	tests := []struct {
+		unix      int64
+		algorithm string
+		want      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1234567890, "SHA1", "89005924"},
+		{2000000000, "SHA256", "90698825"},
+		{20000000000, "SHA512", "47863826"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTP(secrets[tt.algorithm], time.Unix(tt.unix, 0), 30*time.Second, 8, tt.algorithm)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, code, "%s at %d", tt.algorithm, tt.unix)
+	}
+}
+
+func TestTOTP_SixDigits(t *testing.T) {
+	code, err := TOTP([]byte("12345678901234567890"), time.Unix(59, 0), 30*time.Second, 6, "sha1")
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+}
+
+func TestTOTP_InvalidParams(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	_, err := TOTP(secret, time.Now(), 30*time.Second, 6, "MD5")
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+	_, err = TOTP(secret, time.Now(), 0, 6, "SHA1")
+	assert.Error(t, err)
+
+	_, err = TOTP(secret, time.Now(), 30*time.Second, 0, "SHA1")
+	assert.Error(t, err)
+}
+
+func TestTOTPRemaining(t *testing.T) {
+	assert.Equal(t, 30*time.Second, TOTPRemaining(time.Unix(60, 0), 30*time.Second))
+	assert.Equal(t, time.Second, TOTPRemaining(time.Unix(89, 0), 30*time.Second))
+}
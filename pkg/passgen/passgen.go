@@ -0,0 +1,231 @@
+// Package passgen генерирует пароли и парольные фразы (diceware) с помощью crypto/rand
+// и оценивает их энтропию.
+package passgen
+
+import (
+	"bufio"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// Классы символов пароля.
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+
+	// ambiguousChars легко спутать при чтении или переписывании пароля.
+	ambiguousChars = "0O1lI|5S2Z8B`'\""
+)
+
+// Ограничения параметров генерации.
+const (
+	MinLength = 4
+	MaxLength = 256
+	MinWords  = 3
+	MaxWords  = 32
+)
+
+var (
+	ErrNoCharClasses = errors.New("at least one character class must be enabled")
+	ErrInvalidLength = fmt.Errorf("password length must be between %d and %d", MinLength, MaxLength)
+	ErrInvalidWords  = fmt.Errorf("passphrase must have between %d and %d words", MinWords, MaxWords)
+	ErrEmptyWordlist = errors.New("wordlist is empty")
+)
+
+//go:embed words.txt
+var defaultWords string
+
+// Options — параметры генерации пароля.
+type Options struct {
+	Length           int
+	Lower            bool
+	Upper            bool
+	Digits           bool
+	Symbols          bool
+	ExcludeAmbiguous bool
+}
+
+// DefaultOptions возвращает параметры по умолчанию: 20 символов всех классов.
+func DefaultOptions() Options {
+	return Options{Length: 20, Lower: true, Upper: true, Digits: true, Symbols: true}
+}
+
+// classes возвращает алфавиты включённых классов символов.
+func (o Options) classes() []string {
+	var classes []string
+	for _, class := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{o.Lower, lowerChars},
+		{o.Upper, upperChars},
+		{o.Digits, digitChars},
+		{o.Symbols, symbolChars},
+	} {
+		if !class.enabled {
+			continue
+		}
+		chars := class.chars
+		if o.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(ambiguousChars, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+		classes = append(classes, chars)
+	}
+	return classes
+}
+
+// Password генерирует пароль. В пароле есть хотя бы один символ каждого включённого класса.
+func Password(opts Options) (string, error) {
+	if opts.Length < MinLength || opts.Length > MaxLength {
+		return "", ErrInvalidLength
+	}
+	classes := opts.classes()
+	if len(classes) == 0 {
+		return "", ErrNoCharClasses
+	}
+	if opts.Length < len(classes) {
+		return "", fmt.Errorf("%w: too short for %d character classes", ErrInvalidLength, len(classes))
+	}
+
+	alphabet := strings.Join(classes, "")
+	password := make([]byte, 0, opts.Length)
+	for _, class := range classes {
+		c, err := pick(class)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	for len(password) < opts.Length {
+		c, err := pick(alphabet)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	if err := shuffle(password); err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// PasswordEntropy оценивает энтропию пароля в битах как длина × log2(размер алфавита).
+// Обязательный символ каждого класса немного снижает реальную энтропию, для оценки этим пренебрегаем.
+func PasswordEntropy(opts Options) float64 {
+	size := len(strings.Join(opts.classes(), ""))
+	if size == 0 || opts.Length <= 0 {
+		return 0
+	}
+	return float64(opts.Length) * math.Log2(float64(size))
+}
+
+// Passphrase генерирует парольную фразу из words случайных слов списка wordlist, соединённых separator.
+// Если wordlist пуст, используется встроенный список.
+func Passphrase(words int, separator string, wordlist []string) (string, error) {
+	if words < MinWords || words > MaxWords {
+		return "", ErrInvalidWords
+	}
+	if len(wordlist) == 0 {
+		wordlist = DefaultWordlist()
+	}
+
+	chosen := make([]string, words)
+	for i := range chosen {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(wordlist))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random number: %w", err)
+		}
+		chosen[i] = wordlist[n.Int64()]
+	}
+	return strings.Join(chosen, separator), nil
+}
+
+// PassphraseEntropy оценивает энтропию фразы из words слов списка размером listSize в битах.
+func PassphraseEntropy(words, listSize int) float64 {
+	if words <= 0 || listSize <= 1 {
+		return 0
+	}
+	return float64(words) * math.Log2(float64(listSize))
+}
+
+// DefaultWordlist возвращает встроенный список слов.
+func DefaultWordlist() []string {
+	return strings.Fields(defaultWords)
+}
+
+// LoadWordlist читает список слов по одному в строке. Поддерживается формат списков EFF,
+// где перед словом стоят номера бросков кубика. Повторы слов отбрасываются.
+func LoadWordlist(r io.Reader) ([]string, error) {
+	var words []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		word := fields[len(fields)-1]
+		if len(fields) > 1 && !isDiceRoll(fields[0]) {
+			return nil, fmt.Errorf("invalid wordlist line: %q", scanner.Text())
+		}
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wordlist: %w", err)
+	}
+	if len(words) < 2 {
+		return nil, ErrEmptyWordlist
+	}
+	return words, nil
+}
+
+// isDiceRoll сообщает, что s — номер броска кубиков вида 11111.
+func isDiceRoll(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// pick возвращает случайный символ из chars.
+func pick(chars string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return chars[n.Int64()], nil
+}
+
+// shuffle перемешивает b алгоритмом Фишера — Йетса.
+func shuffle(b []byte) error {
+	for i := len(b) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return fmt.Errorf("failed to generate random number: %w", err)
+		}
+		j := n.Int64()
+		b[i], b[j] = b[j], b[i]
+	}
+	return nil
+}
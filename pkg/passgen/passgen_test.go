@@ -0,0 +1,98 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassword_Classes(t *testing.T) {
+	opts := DefaultOptions()
+	for i := 0; i < 50; i++ {
+		password, err := Password(opts)
+		require.NoError(t, err)
+		assert.Len(t, password, opts.Length)
+		assert.True(t, strings.ContainsAny(password, lowerChars))
+		assert.True(t, strings.ContainsAny(password, upperChars))
+		assert.True(t, strings.ContainsAny(password, digitChars))
+		assert.True(t, strings.ContainsAny(password, symbolChars))
+	}
+
+	password, err := Password(Options{Length: 32, Digits: true})
+	require.NoError(t, err)
+	assert.Equal(t, "", strings.Trim(password, digitChars))
+}
+
+func TestPassword_ExcludeAmbiguous(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Length = 128
+	opts.ExcludeAmbiguous = true
+	for i := 0; i < 20; i++ {
+		password, err := Password(opts)
+		require.NoError(t, err)
+		assert.False(t, strings.ContainsAny(password, ambiguousChars), password)
+	}
+}
+
+func TestPassword_InvalidOptions(t *testing.T) {
+	_, err := Password(Options{Length: 16})
+	assert.ErrorIs(t, err, ErrNoCharClasses)
+
+	_, err = Password(Options{Length: 2, Lower: true})
+	assert.ErrorIs(t, err, ErrInvalidLength)
+
+	_, err = Password(Options{Length: MaxLength + 1, Lower: true})
+	assert.ErrorIs(t, err, ErrInvalidLength)
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	assert.InDelta(t, 16*math.Log2(26), PasswordEntropy(Options{Length: 16, Lower: true}), 1e-9)
+	assert.InDelta(t, 10*math.Log2(10), PasswordEntropy(Options{Length: 10, Digits: true}), 1e-9)
+	assert.Less(t,
+		PasswordEntropy(Options{Length: 16, Lower: true, Digits: true, ExcludeAmbiguous: true}),
+		PasswordEntropy(Options{Length: 16, Lower: true, Digits: true}))
+	assert.Zero(t, PasswordEntropy(Options{Length: 16}))
+}
+
+func TestPassphrase(t *testing.T) {
+	wordlist := DefaultWordlist()
+	require.Greater(t, len(wordlist), 1000)
+
+	phrase, err := Passphrase(6, "-", nil)
+	require.NoError(t, err)
+	words := strings.Split(phrase, "-")
+	assert.Len(t, words, 6)
+	for _, word := range words {
+		assert.Contains(t, wordlist, word)
+	}
+
+	phrase, err = Passphrase(4, " ", []string{"alpha", "beta"})
+	require.NoError(t, err)
+	for _, word := range strings.Fields(phrase) {
+		assert.Contains(t, []string{"alpha", "beta"}, word)
+	}
+
+	_, err = Passphrase(1, "-", nil)
+	assert.ErrorIs(t, err, ErrInvalidWords)
+
+	assert.InDelta(t, 6*math.Log2(7776), PassphraseEntropy(6, 7776), 1e-9)
+}
+
+func TestLoadWordlist(t *testing.T) {
+	words, err := LoadWordlist(strings.NewReader("11111\tabacus\n11112\tabdomen\n\n11113 abacus\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abacus", "abdomen"}, words)
+
+	words, err = LoadWordlist(strings.NewReader("one\ntwo\nthree\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "three"}, words)
+
+	_, err = LoadWordlist(strings.NewReader("not a wordlist\n"))
+	assert.Error(t, err)
+
+	_, err = LoadWordlist(strings.NewReader("single\n"))
+	assert.ErrorIs(t, err, ErrEmptyWordlist)
+}
@@ -0,0 +1,13 @@
+package payload
+
+// TypeFolder — служебная запись пустой папки. Папка хранится в DataRecord.folder,
+// содержимое записи пустое; пока в папке есть другие записи, такая запись не нужна.
+const TypeFolder = "folder"
+
+func init() {
+	Register(TypeSpec{
+		Name:     TypeFolder,
+		Usage:    "папка",
+		Internal: true,
+	})
+}
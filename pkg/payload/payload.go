@@ -0,0 +1,109 @@
+// Package payload описывает содержимое записей GophKeeper: реестр типов записей,
+// сериализацию перед шифрованием, проверку полей, вывод по полям и разбор записей старого текстового формата.
+package payload
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Встроенные типы записей
+const (
+	TypeLoginPass = "loginpass"
+	TypeCard      = "card"
+	TypeText      = "text"
+	TypeBinary    = "binary"
+)
+
+// Сериализованный Payload начинается с заголовка [0x00][версия формата].
+// Записи старого формата — текст вида "ключ:значение", нулевым байтом они не начинаются.
+const formatVersion = 1
+
+var (
+	// ErrUnknownType возвращается для записи неизвестного типа.
+	ErrUnknownType = errors.New("unknown record type")
+
+	// ErrTypeMismatch возвращается, если содержимое не соответствует типу записи.
+	ErrTypeMismatch = errors.New("payload does not match record type")
+
+	// ErrUnsupportedFormat возвращается для содержимого более новой версии формата.
+	ErrUnsupportedFormat = errors.New("unsupported payload format")
+)
+
+// Marshal сериализует содержимое записи для шифрования.
+func Marshal(p *pb.Payload) ([]byte, error) {
+	data, err := proto.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return append([]byte{0, formatVersion}, data...), nil
+}
+
+// Unmarshal разбирает расшифрованное содержимое записи типа recordType.
+// Записи старого текстового формата разбираются по строкам "ключ:значение".
+func Unmarshal(recordType string, data []byte) (*pb.Payload, error) {
+	if IsLegacy(data) {
+		return parseLegacy(recordType, data)
+	}
+	if data[1] != formatVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedFormat, data[1])
+	}
+
+	p := &pb.Payload{}
+	if err := proto.Unmarshal(data[2:], p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	if TypeOf(p) != recordType {
+		return nil, fmt.Errorf("%w: %s is not %s", ErrTypeMismatch, TypeOf(p), recordType)
+	}
+	return p, nil
+}
+
+// IsLegacy сообщает, что содержимое записано в старом текстовом формате.
+func IsLegacy(data []byte) bool {
+	return len(data) < 2 || data[0] != 0
+}
+
+// TypeOf возвращает тип записи для содержимого p; пустую строку, если содержимое не задано.
+func TypeOf(p *pb.Payload) string {
+	switch kind := p.GetKind().(type) {
+	case *pb.Payload_LoginPass:
+		return TypeLoginPass
+	case *pb.Payload_Card:
+		return TypeCard
+	case *pb.Payload_Text:
+		return TypeText
+	case *pb.Payload_Binary:
+		return TypeBinary
+	case *pb.Payload_Custom:
+		return kind.Custom.Type
+	default:
+		return ""
+	}
+}
+
+// parseLegacy разбирает содержимое старого формата разбором, объявленным типом в реестре.
+func parseLegacy(recordType string, data []byte) (*pb.Payload, error) {
+	spec, ok := Lookup(recordType)
+	if !ok || spec.ParseLegacy == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, recordType)
+	}
+	return spec.ParseLegacy(data)
+}
+
+// legacyFields разбирает строки "ключ:значение"; значение может содержать двоеточия.
+func legacyFields(data []byte) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		key, value, ok := strings.Cut(string(line), ":")
+		if ok {
+			fields[strings.TrimSpace(key)] = strings.TrimRight(value, "\r")
+		}
+	}
+	return fields
+}
@@ -0,0 +1,86 @@
+package payload
+
+import (
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// сериализация и разбор содержимого каждого типа
+func TestMarshalUnmarshal(t *testing.T) {
+	payloads := []*pb.Payload{
+		{Kind: &pb.Payload_LoginPass{LoginPass: &pb.LoginPass{Login: "alice", Password: "secret"}}},
+		{Kind: &pb.Payload_Card{Card: &pb.Card{Number: "4111111111111111", Expiry: "12/30", Cvv: "123"}}},
+		{Kind: &pb.Payload_Text{Text: &pb.Text{Content: "login:not-a-legacy-record"}}},
+		{Kind: &pb.Payload_Binary{Binary: &pb.BinaryRef{FileName: "key.pem", Size: 42}}},
+	}
+
+	for _, p := range payloads {
+		data, err := Marshal(p)
+		require.NoError(t, err)
+		assert.False(t, IsLegacy(data))
+
+		got, err := Unmarshal(TypeOf(p), data)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(p, got), TypeOf(p))
+	}
+}
+
+// тип содержимого должен совпадать с типом записи
+func TestUnmarshal_TypeMismatch(t *testing.T) {
+	data, err := Marshal(&pb.Payload{Kind: &pb.Payload_Text{Text: &pb.Text{Content: "x"}}})
+	require.NoError(t, err)
+
+	_, err = Unmarshal(TypeCard, data)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+
+	data[1] = formatVersion + 1
+	_, err = Unmarshal(TypeText, data)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+// записи старого формата "ключ:значение"
+func TestUnmarshal_Legacy(t *testing.T) {
+	p, err := Unmarshal(TypeLoginPass, []byte("login:alice\npassword:pa:ss"))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", p.GetLoginPass().Login)
+	assert.Equal(t, "pa:ss", p.GetLoginPass().Password)
+
+	p, err = Unmarshal(TypeCard, []byte("number:4111 1111 1111 1111\nexpiry:12/30\ncvv:123"))
+	require.NoError(t, err)
+	assert.Equal(t, "4111111111111111", p.GetCard().Number)
+	assert.Equal(t, "12/30", p.GetCard().Expiry)
+	assert.Equal(t, "123", p.GetCard().Cvv)
+
+	p, err = Unmarshal(TypeText, []byte("just text"))
+	require.NoError(t, err)
+	assert.Equal(t, "just text", p.GetText().Content)
+
+	_, err = Unmarshal("sshkey", []byte("data"))
+	assert.ErrorIs(t, err, ErrUnknownType)
+}
+
+// проверка карты: алгоритм Луна, срок действия, CVV
+func TestValidateCard(t *testing.T) {
+	valid := &pb.Card{Number: "4111111111111111", Expiry: "12/30", Cvv: "123"}
+	assert.NoError(t, ValidateCard(valid))
+
+	assert.ErrorIs(t, ValidateCard(&pb.Card{Number: "4111111111111112", Expiry: "12/30"}), ErrInvalidCardNumber)
+	assert.ErrorIs(t, ValidateCard(&pb.Card{Number: "4111-1111", Expiry: "12/30"}), ErrInvalidCardNumber)
+	assert.ErrorIs(t, ValidateCard(&pb.Card{Number: "4111111111111111", Expiry: "13/30"}), ErrInvalidExpiry)
+	assert.ErrorIs(t, ValidateCard(&pb.Card{Number: "4111111111111111", Expiry: "1230"}), ErrInvalidExpiry)
+	assert.ErrorIs(t, ValidateCard(&pb.Card{Number: "4111111111111111", Expiry: "12/30", Cvv: "12a"}), ErrInvalidCVV)
+
+	expiry, err := NormalizeExpiry("07/2031")
+	require.NoError(t, err)
+	assert.Equal(t, "07/31", expiry)
+}
+
+// вывод по полям пропускает пустые необязательные поля
+func TestFields(t *testing.T) {
+	fields := Fields(&pb.Payload{Kind: &pb.Payload_Card{Card: &pb.Card{Number: "4111111111111111", Expiry: "12/30"}}})
+	assert.Equal(t, []Field{{Name: "Номер", Value: "4111111111111111"}, {Name: "Срок", Value: "12/30"}}, fields)
+}
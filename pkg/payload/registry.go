@@ -0,0 +1,147 @@
+package payload
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/dvkhr/gophkeeper/pb"
+)
+
+// FieldSpec описывает поле записи: флаг CLI, подпись при выводе и обязательность.
+type FieldSpec struct {
+	Name     string                    // Имя поля, оно же имя флага CLI
+	Label    string                    // Подпись при выводе
+	Usage    string                    // Описание флага CLI
+	Required bool                      // Поле обязательно
+	FileFlag string                    // Флаг CLI, через который значение можно прочитать из файла
+	ReadOnly bool                      // Поле вычисляется при сохранении и не задаётся флагом
+	Secret   bool                      // Поле содержит секрет: не участвует в поиске и не выводится в его результатах
+	Format   func(value string) string // Вывод значения; по умолчанию значение выводится как есть
+}
+
+// TypeSpec описывает тип записи.
+// Типу без собственного сообщения в payload.proto достаточно имени и полей:
+// его содержимое хранится в CustomFields.
+type TypeSpec struct {
+	Name   string
+	Usage  string
+	Fields []FieldSpec
+
+	// Internal — служебный тип: записи создаёт сам клиент, а не команда add.
+	Internal bool
+
+	// Encode собирает содержимое из значений полей; по умолчанию — CustomFields.
+	Encode func(values map[string]string) (*pb.Payload, error)
+
+	// Decode возвращает значения полей содержимого; по умолчанию — из CustomFields.
+	Decode func(p *pb.Payload) map[string]string
+
+	// Validate проверяет значения полей после проверки обязательных.
+	Validate func(values map[string]string) error
+
+	// ParseLegacy разбирает содержимое старого текстового формата; nil — у типа его нет.
+	ParseLegacy func(data []byte) (*pb.Payload, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]TypeSpec)
+
+	typeNameRe = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+)
+
+// ValidTypeName сообщает, что name можно использовать как имя типа записи.
+// Сервер хранит тип как непрозрачную строку и проверяет только её формат.
+func ValidTypeName(name string) bool {
+	return typeNameRe.MatchString(name)
+}
+
+// Register добавляет тип в реестр.
+// Как и database/sql.Register, вызывается из init и паникует при недопустимом имени или повторной регистрации.
+func Register(spec TypeSpec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if !ValidTypeName(spec.Name) {
+		panic(fmt.Sprintf("payload: invalid type name %q", spec.Name))
+	}
+	if _, dup := registry[spec.Name]; dup {
+		panic(fmt.Sprintf("payload: Register called twice for type %s", spec.Name))
+	}
+	registry[spec.Name] = spec
+}
+
+// Lookup возвращает описание типа по имени.
+func Lookup(name string) (TypeSpec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	spec, ok := registry[name]
+	return spec, ok
+}
+
+// Types возвращает зарегистрированные типы в порядке имён.
+func Types() []TypeSpec {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	specs := make([]TypeSpec, 0, len(registry))
+	for _, spec := range registry {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// Encode собирает содержимое записи типа typeName из значений полей.
+// Пустые значения и значения неизвестных полей не сохраняются.
+func Encode(typeName string, values map[string]string) (*pb.Payload, error) {
+	spec, ok := Lookup(typeName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, typeName)
+	}
+	if spec.Encode != nil {
+		return spec.Encode(values)
+	}
+	return encodeCustom(spec, values), nil
+}
+
+// encodeCustom сохраняет непустые значения полей типа spec в CustomFields.
+func encodeCustom(spec TypeSpec, values map[string]string) *pb.Payload {
+	custom := &pb.CustomFields{Type: spec.Name, Values: make(map[string]string)}
+	for _, field := range spec.Fields {
+		if v := values[field.Name]; v != "" {
+			custom.Values[field.Name] = v
+		}
+	}
+	return &pb.Payload{Kind: &pb.Payload_Custom{Custom: custom}}
+}
+
+// Values возвращает значения полей содержимого записи.
+func Values(p *pb.Payload) map[string]string {
+	if spec, ok := Lookup(TypeOf(p)); ok && spec.Decode != nil {
+		return spec.Decode(p)
+	}
+	return p.GetCustom().GetValues()
+}
+
+// PublicValues возвращает непустые значения несекретных полей содержимого записи.
+// Поля типов, которых нет в реестре, считаются секретными.
+func PublicValues(p *pb.Payload) map[string]string {
+	public := make(map[string]string)
+
+	spec, ok := Lookup(TypeOf(p))
+	if !ok {
+		return public
+	}
+
+	values := Values(p)
+	for _, field := range spec.Fields {
+		if v := values[field.Name]; v != "" && !field.Secret {
+			public[field.Name] = v
+		}
+	}
+	return public
+}
@@ -0,0 +1,92 @@
+package payload
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// тип без собственного сообщения хранит значения полей в CustomFields
+func init() {
+	Register(TypeSpec{
+		Name: "totp-test",
+		Fields: []FieldSpec{
+			{Name: "issuer", Label: "Сервис"},
+			{Name: "secret", Label: "Секрет", Required: true},
+		},
+		Validate: func(values map[string]string) error {
+			if len(values["secret"]) < 4 {
+				return errors.New("secret too short")
+			}
+			return nil
+		},
+	})
+}
+
+func TestRegistry_CustomType(t *testing.T) {
+	spec, ok := Lookup("totp-test")
+	require.True(t, ok)
+	assert.Len(t, spec.Fields, 2)
+
+	p, err := Encode("totp-test", map[string]string{"issuer": "github", "secret": "JBSWY3DP", "unknown": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "totp-test", TypeOf(p))
+	assert.Equal(t, map[string]string{"issuer": "github", "secret": "JBSWY3DP"}, Values(p))
+	require.NoError(t, Validate(p))
+
+	data, err := Marshal(p)
+	require.NoError(t, err)
+	got, err := Unmarshal("totp-test", data)
+	require.NoError(t, err)
+	assert.Equal(t, []Field{{Name: "Сервис", Value: "github"}, {Name: "Секрет", Value: "JBSWY3DP"}}, Fields(got))
+
+	p, err = Encode("totp-test", map[string]string{"issuer": "github"})
+	require.NoError(t, err)
+	assert.Error(t, Validate(p))
+
+	p, err = Encode("totp-test", map[string]string{"secret": "abc"})
+	require.NoError(t, err)
+	assert.EqualError(t, Validate(p), "secret too short")
+
+	// записей старого формата у типа нет
+	_, err = Unmarshal("totp-test", []byte("secret:abc"))
+	assert.ErrorIs(t, err, ErrUnknownType)
+}
+
+func TestRegistry_Names(t *testing.T) {
+	assert.True(t, ValidTypeName("loginpass"))
+	assert.True(t, ValidTypeName("ssh-key_2"))
+	assert.False(t, ValidTypeName(""))
+	assert.False(t, ValidTypeName("Card"))
+	assert.False(t, ValidTypeName("1type"))
+	assert.False(t, ValidTypeName("type with spaces"))
+
+	_, err := Encode("unknown", nil)
+	assert.ErrorIs(t, err, ErrUnknownType)
+
+	assert.Panics(t, func() { Register(TypeSpec{Name: TypeCard}) })
+	assert.Panics(t, func() { Register(TypeSpec{Name: "Bad Name"}) })
+
+	var names []string
+	for _, spec := range Types() {
+		names = append(names, spec.Name)
+	}
+	assert.Subset(t, names, []string{TypeBinary, TypeCard, TypeLoginPass, TypeText})
+}
+
+func TestPublicValues(t *testing.T) {
+	p, err := Encode(TypeLoginPass, map[string]string{"login": "vasia", "password": "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"login": "vasia"}, PublicValues(p))
+
+	p, err = Encode(TypeCard, map[string]string{"number": "4111111111111111", "expiry": "12/27", "cvv": "123", "holder": "VASILY"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"expiry": "12/27", "holder": "VASILY"}, PublicValues(p))
+
+	// поля неизвестного типа не раскрываются
+	unknown := &pb.Payload{Kind: &pb.Payload_Custom{Custom: &pb.CustomFields{Type: "unknown", Values: map[string]string{"k": "v"}}}}
+	assert.Empty(t, PublicValues(unknown))
+}
@@ -0,0 +1,47 @@
+package payload
+
+import (
+	"sort"
+
+	"github.com/dvkhr/gophkeeper/pb"
+)
+
+// Field — поле содержимого записи для вывода.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Fields возвращает поля содержимого записи в порядке, объявленном типом в реестре. Пустые поля пропускаются.
+// Поля типа, которого нет в реестре (например, добавленного более новым клиентом), выводятся по именам.
+func Fields(p *pb.Payload) []Field {
+	values := Values(p)
+
+	spec, ok := Lookup(TypeOf(p))
+	if !ok {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fields := make([]Field, 0, len(names))
+		for _, name := range names {
+			fields = append(fields, Field{Name: name, Value: values[name]})
+		}
+		return fields
+	}
+
+	var fields []Field
+	for _, field := range spec.Fields {
+		value := values[field.Name]
+		if value == "" {
+			continue
+		}
+		if field.Format != nil {
+			value = field.Format(value)
+		}
+		fields = append(fields, Field{Name: field.Label, Value: value})
+	}
+	return fields
+}
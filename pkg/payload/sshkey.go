@@ -0,0 +1,101 @@
+package payload
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"golang.org/x/crypto/ssh"
+)
+
+// TypeSSHKey — SSH-ключ. Собственного сообщения в payload.proto у типа нет, поля хранятся в CustomFields.
+const TypeSSHKey = "sshkey"
+
+// ErrSSHKeyMismatch возвращается, если открытый ключ не соответствует закрытому.
+var ErrSSHKeyMismatch = errors.New("public key does not match private key")
+
+var sshKeySpec = TypeSpec{
+	Name:  TypeSSHKey,
+	Usage: "SSH-ключ",
+	Fields: []FieldSpec{
+		{Name: "private-key", Label: "Закрытый ключ", Usage: "Закрытый ключ SSH (OpenSSH или PEM)", Required: true, FileFlag: "private-key-file", Secret: true},
+		{Name: "public-key", Label: "Открытый ключ", Usage: "Открытый ключ SSH в формате authorized_keys", FileFlag: "public-key-file"},
+		{Name: "comment", Label: "Комментарий", Usage: "Комментарий ключа"},
+		{Name: "passphrase", Label: "Пароль ключа", Usage: "Пароль закрытого ключа", Secret: true},
+	},
+}
+
+func init() {
+	sshKeySpec.Encode = encodeSSHKey
+	sshKeySpec.Validate = validateSSHKey
+	Register(sshKeySpec)
+}
+
+// SSHPrivateKey разбирает закрытый ключ из значений полей записи типа sshkey,
+// расшифровывая его паролем ключа, если он задан.
+func SSHPrivateKey(values map[string]string) (any, error) {
+	pemBytes := []byte(values["private-key"])
+	if passphrase := values["passphrase"]; passphrase != "" {
+		return ssh.ParseRawPrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+	}
+	return ssh.ParseRawPrivateKey(pemBytes)
+}
+
+// encodeSSHKey сохраняет SSH-ключ; отсутствующие открытый ключ и комментарий
+// берутся из закрытого ключа и строки authorized_keys.
+func encodeSSHKey(values map[string]string) (*pb.Payload, error) {
+	values = cloneValues(values)
+
+	if strings.TrimSpace(values["public-key"]) == "" {
+		key, err := SSHPrivateKey(values)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		signer, err := ssh.NewSignerFromKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		values["public-key"] = string(bytes.TrimSpace(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	} else if values["comment"] == "" {
+		if _, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(values["public-key"])); err == nil {
+			values["comment"] = comment
+		}
+	}
+
+	return encodeCustom(sshKeySpec, values), nil
+}
+
+// validateSSHKey проверяет, что закрытый ключ разбирается, а открытый ему соответствует.
+func validateSSHKey(values map[string]string) error {
+	key, err := SSHPrivateKey(values)
+	if err != nil {
+		return fmt.Errorf("invalid private key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid private key: %w", err)
+	}
+
+	if values["public-key"] == "" {
+		return nil
+	}
+	public, _, _, _, err := ssh.ParseAuthorizedKey([]byte(values["public-key"]))
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	if !bytes.Equal(public.Marshal(), signer.PublicKey().Marshal()) {
+		return ErrSSHKeyMismatch
+	}
+	return nil
+}
+
+// cloneValues копирует значения полей, чтобы не менять map вызывающего.
+func cloneValues(values map[string]string) map[string]string {
+	clone := make(map[string]string, len(values))
+	for k, v := range values {
+		clone[k] = v
+	}
+	return clone
+}
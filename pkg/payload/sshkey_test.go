@@ -0,0 +1,83 @@
+package payload
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func generateSSHKey(t *testing.T, passphrase string) (privateKey, publicKey string) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(private, "vasia@laptop")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(private, "vasia@laptop", []byte(passphrase))
+	}
+	require.NoError(t, err)
+
+	sshPublic, err := ssh.NewPublicKey(public)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(block)), strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublic))) + " vasia@laptop"
+}
+
+func TestSSHKey_DerivesPublicKey(t *testing.T) {
+	privateKey, publicKey := generateSSHKey(t, "")
+
+	p, err := Encode(TypeSSHKey, map[string]string{"private-key": privateKey})
+	require.NoError(t, err)
+	require.NoError(t, Validate(p))
+
+	values := Values(p)
+	assert.Equal(t, TypeSSHKey, TypeOf(p))
+	assert.Equal(t, strings.TrimSuffix(publicKey, " vasia@laptop"), values["public-key"])
+
+	key, err := SSHPrivateKey(values)
+	require.NoError(t, err)
+	assert.IsType(t, &ed25519.PrivateKey{}, key)
+}
+
+func TestSSHKey_CommentFromPublicKey(t *testing.T) {
+	privateKey, publicKey := generateSSHKey(t, "")
+
+	p, err := Encode(TypeSSHKey, map[string]string{"private-key": privateKey, "public-key": publicKey})
+	require.NoError(t, err)
+	require.NoError(t, Validate(p))
+	assert.Equal(t, "vasia@laptop", Values(p)["comment"])
+}
+
+func TestSSHKey_Passphrase(t *testing.T) {
+	privateKey, _ := generateSSHKey(t, "secret")
+
+	_, err := Encode(TypeSSHKey, map[string]string{"private-key": privateKey})
+	require.Error(t, err)
+
+	p, err := Encode(TypeSSHKey, map[string]string{"private-key": privateKey, "passphrase": "secret"})
+	require.NoError(t, err)
+	require.NoError(t, Validate(p))
+
+	wrong, err := Encode(TypeSSHKey, map[string]string{"private-key": privateKey, "passphrase": "secret"})
+	require.NoError(t, err)
+	wrong.GetCustom().Values["passphrase"] = "wrong"
+	assert.Error(t, Validate(wrong))
+}
+
+func TestSSHKey_Mismatch(t *testing.T) {
+	privateKey, _ := generateSSHKey(t, "")
+	_, otherPublic := generateSSHKey(t, "")
+
+	p, err := Encode(TypeSSHKey, map[string]string{"private-key": privateKey, "public-key": otherPublic})
+	require.NoError(t, err)
+	assert.ErrorIs(t, Validate(p), ErrSSHKeyMismatch)
+}
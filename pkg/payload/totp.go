@@ -0,0 +1,201 @@
+package payload
+
+import (
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/crypto"
+)
+
+// TypeTOTP — секрет двухфакторной аутентификации (TOTP, RFC 6238). Поля хранятся в CustomFields.
+const TypeTOTP = "totp"
+
+// Параметры TOTP по умолчанию, как в Google Authenticator.
+const (
+	defaultTOTPAlgorithm = "SHA1"
+	defaultTOTPDigits    = 6
+	defaultTOTPPeriod    = 30
+)
+
+var (
+	ErrInvalidOTPAuthURI = errors.New("invalid otpauth URI")
+	ErrInvalidTOTPSecret = errors.New("invalid TOTP secret")
+)
+
+var totpSpec = TypeSpec{
+	Name:  TypeTOTP,
+	Usage: "секрет TOTP для двухфакторной аутентификации",
+	Fields: []FieldSpec{
+		{Name: "uri", Usage: "URI вида otpauth://totp/...; заполняет остальные поля TOTP", Secret: true},
+		{Name: "secret", Label: "Секрет", Usage: "Секрет TOTP в base32", Secret: true},
+		{Name: "issuer", Label: "Сервис", Usage: "Сервис, выдавший секрет"},
+		{Name: "account", Label: "Аккаунт", Usage: "Аккаунт в сервисе"},
+		{Name: "algorithm", Label: "Алгоритм", Usage: "Алгоритм HMAC: SHA1, SHA256 или SHA512"},
+		{Name: "digits", Label: "Цифр", Usage: "Число цифр кода"},
+		{Name: "period", Label: "Период", Usage: "Период смены кода в секундах", Format: func(v string) string {
+			return v + " с"
+		}},
+	},
+}
+
+func init() {
+	totpSpec.Encode = encodeTOTP
+	totpSpec.Validate = validateTOTP
+	Register(totpSpec)
+}
+
+// TOTPCode возвращает код TOTP записи для момента t и время, через которое он сменится.
+func TOTPCode(values map[string]string, t time.Time) (string, time.Duration, error) {
+	params, err := parseTOTP(values)
+	if err != nil {
+		return "", 0, err
+	}
+
+	code, err := crypto.TOTP(params.secret, t, params.period, params.digits, params.algorithm)
+	if err != nil {
+		return "", 0, err
+	}
+	return code, crypto.TOTPRemaining(t, params.period), nil
+}
+
+// totpParams — разобранные параметры генерации кода.
+type totpParams struct {
+	secret    []byte
+	algorithm string
+	digits    int
+	period    time.Duration
+}
+
+// encodeTOTP сохраняет секрет TOTP. Если задан --uri, поля берутся из него,
+// явно переданные флаги имеют приоритет. Сам URI не сохраняется.
+func encodeTOTP(values map[string]string) (*pb.Payload, error) {
+	values = cloneValues(values)
+
+	if uri := values["uri"]; uri != "" {
+		fromURI, err := ParseOTPAuthURI(uri)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fromURI {
+			if values[k] == "" {
+				values[k] = v
+			}
+		}
+		delete(values, "uri")
+	}
+
+	values["secret"] = normalizeTOTPSecret(values["secret"])
+	values["algorithm"] = strings.ToUpper(values["algorithm"])
+	if values["algorithm"] == "" {
+		values["algorithm"] = defaultTOTPAlgorithm
+	}
+	if values["digits"] == "" {
+		values["digits"] = strconv.Itoa(defaultTOTPDigits)
+	}
+	if values["period"] == "" {
+		values["period"] = strconv.Itoa(defaultTOTPPeriod)
+	}
+
+	return encodeCustom(totpSpec, values), nil
+}
+
+// validateTOTP проверяет, что по полям записи можно вычислить код.
+func validateTOTP(values map[string]string) error {
+	_, err := parseTOTP(values)
+	return err
+}
+
+// ParseOTPAuthURI разбирает URI otpauth://totp/Issuer:account?secret=...
+// в значения полей записи типа totp.
+func ParseOTPAuthURI(raw string) (map[string]string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOTPAuthURI, err)
+	}
+	if u.Scheme != "otpauth" {
+		return nil, fmt.Errorf("%w: scheme must be otpauth", ErrInvalidOTPAuthURI)
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return nil, fmt.Errorf("%w: only totp is supported, got %q", ErrInvalidOTPAuthURI, u.Host)
+	}
+
+	query := u.Query()
+	values := map[string]string{
+		"secret":    query.Get("secret"),
+		"issuer":    query.Get("issuer"),
+		"algorithm": query.Get("algorithm"),
+		"digits":    query.Get("digits"),
+		"period":    query.Get("period"),
+	}
+	if values["secret"] == "" {
+		return nil, fmt.Errorf("%w: secret is required", ErrInvalidOTPAuthURI)
+	}
+
+	// Метка имеет вид "Issuer:account" или "account"
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		if values["issuer"] == "" {
+			values["issuer"] = strings.TrimSpace(issuer)
+		}
+		label = account
+	}
+	values["account"] = strings.TrimSpace(label)
+
+	return values, nil
+}
+
+// parseTOTP проверяет поля записи и разбирает их в параметры генерации кода.
+func parseTOTP(values map[string]string) (totpParams, error) {
+	var params totpParams
+
+	if values["secret"] == "" {
+		return params, fmt.Errorf("%w: secret is required", ErrInvalidTOTPSecret)
+	}
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalizeTOTPSecret(values["secret"]))
+	if err != nil || len(secret) == 0 {
+		return params, fmt.Errorf("%w: must be base32", ErrInvalidTOTPSecret)
+	}
+	params.secret = secret
+
+	params.algorithm = values["algorithm"]
+	if params.algorithm == "" {
+		params.algorithm = defaultTOTPAlgorithm
+	}
+	switch strings.ToUpper(params.algorithm) {
+	case "SHA1", "SHA256", "SHA512":
+	default:
+		return params, fmt.Errorf("%w: %s", crypto.ErrUnsupportedAlgorithm, params.algorithm)
+	}
+
+	params.digits = defaultTOTPDigits
+	if v := values["digits"]; v != "" {
+		params.digits, err = strconv.Atoi(v)
+		if err != nil || params.digits < 6 || params.digits > 8 {
+			return params, fmt.Errorf("invalid TOTP digits: %s (must be 6-8)", v)
+		}
+	}
+
+	period := defaultTOTPPeriod
+	if v := values["period"]; v != "" {
+		period, err = strconv.Atoi(v)
+		if err != nil || period <= 0 {
+			return params, fmt.Errorf("invalid TOTP period: %s", v)
+		}
+	}
+	params.period = time.Duration(period) * time.Second
+
+	return params, nil
+}
+
+// normalizeTOTPSecret приводит секрет к верхнему регистру без пробелов, дефисов и выравнивания.
+func normalizeTOTPSecret(secret string) string {
+	secret = strings.ToUpper(secret)
+	secret = strings.NewReplacer(" ", "", "-", "", "=", "").Replace(secret)
+	return secret
+}
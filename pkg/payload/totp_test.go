@@ -0,0 +1,76 @@
+package payload
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// секрет "12345678901234567890" из RFC 6238 в base32
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestParseOTPAuthURI(t *testing.T) {
+	values, err := ParseOTPAuthURI("otpauth://totp/GitHub:vasia%40example.com?secret=" + rfcTOTPSecret + "&algorithm=SHA256&digits=8&period=60")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"secret":    rfcTOTPSecret,
+		"issuer":    "GitHub",
+		"account":   "vasia@example.com",
+		"algorithm": "SHA256",
+		"digits":    "8",
+		"period":    "60",
+	}, values)
+
+	// параметр issuer важнее префикса метки
+	values, err = ParseOTPAuthURI("otpauth://totp/Old:vasia?secret=" + rfcTOTPSecret + "&issuer=New")
+	require.NoError(t, err)
+	assert.Equal(t, "New", values["issuer"])
+
+	for _, uri := range []string{
+		"https://example.com/?secret=" + rfcTOTPSecret,
+		"otpauth://hotp/GitHub:vasia?secret=" + rfcTOTPSecret,
+		"otpauth://totp/GitHub:vasia",
+	} {
+		_, err := ParseOTPAuthURI(uri)
+		assert.ErrorIs(t, err, ErrInvalidOTPAuthURI, uri)
+	}
+}
+
+func TestTOTP_EncodeFromURI(t *testing.T) {
+	p, err := Encode(TypeTOTP, map[string]string{
+		"uri":    "otpauth://totp/GitHub:vasia?secret=gezd+gnbv+gy3t+qojq+gezd+gnbv+gy3t+qojq",
+		"digits": "8",
+	})
+	require.NoError(t, err)
+	require.NoError(t, Validate(p))
+
+	values := Values(p)
+	assert.Equal(t, TypeTOTP, TypeOf(p))
+	assert.NotContains(t, values, "uri")
+	assert.Equal(t, rfcTOTPSecret, values["secret"])
+	assert.Equal(t, "SHA1", values["algorithm"])
+	assert.Equal(t, "8", values["digits"])
+	assert.Equal(t, "30", values["period"])
+
+	code, remaining, err := TOTPCode(values, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "94287082", code)
+	assert.Equal(t, time.Second, remaining)
+}
+
+func TestTOTP_Validate(t *testing.T) {
+	tests := []map[string]string{
+		{},
+		{"secret": "not base32!"},
+		{"secret": rfcTOTPSecret, "algorithm": "MD5"},
+		{"secret": rfcTOTPSecret, "digits": "4"},
+		{"secret": rfcTOTPSecret, "period": "0"},
+	}
+	for _, values := range tests {
+		p, err := Encode(TypeTOTP, values)
+		require.NoError(t, err)
+		assert.Error(t, Validate(p), values)
+	}
+}
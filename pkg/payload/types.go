@@ -0,0 +1,133 @@
+package payload
+
+import (
+	"strconv"
+
+	"github.com/dvkhr/gophkeeper/pb"
+)
+
+// Встроенные типы записей. У них собственные сообщения в payload.proto и старый текстовый формат.
+func init() {
+	Register(TypeSpec{
+		Name:  TypeLoginPass,
+		Usage: "пара логин-пароль",
+		Fields: []FieldSpec{
+			{Name: "login", Label: "Логин", Usage: "Логин", Required: true},
+			{Name: "password", Label: "Пароль", Usage: "Пароль", Required: true, Secret: true},
+		},
+		Encode: func(values map[string]string) (*pb.Payload, error) {
+			return &pb.Payload{Kind: &pb.Payload_LoginPass{LoginPass: &pb.LoginPass{
+				Login:    values["login"],
+				Password: values["password"],
+			}}}, nil
+		},
+		Decode: func(p *pb.Payload) map[string]string {
+			return map[string]string{
+				"login":    p.GetLoginPass().GetLogin(),
+				"password": p.GetLoginPass().GetPassword(),
+			}
+		},
+		ParseLegacy: func(data []byte) (*pb.Payload, error) {
+			fields := legacyFields(data)
+			return &pb.Payload{Kind: &pb.Payload_LoginPass{LoginPass: &pb.LoginPass{
+				Login:    fields["login"],
+				Password: fields["password"],
+			}}}, nil
+		},
+	})
+
+	Register(TypeSpec{
+		Name:  TypeCard,
+		Usage: "банковская карта",
+		Fields: []FieldSpec{
+			{Name: "number", Label: "Номер", Usage: "Номер карты", Required: true, Secret: true},
+			{Name: "expiry", Label: "Срок", Usage: "Срок действия карты, MM/YY", Required: true},
+			{Name: "cvv", Label: "CVV", Usage: "CVV/CVC карты", Secret: true},
+			{Name: "holder", Label: "Владелец", Usage: "Владелец карты"},
+		},
+		Encode: func(values map[string]string) (*pb.Payload, error) {
+			expiry, err := NormalizeExpiry(values["expiry"])
+			if err != nil {
+				return nil, err
+			}
+			return &pb.Payload{Kind: &pb.Payload_Card{Card: &pb.Card{
+				Number: NormalizeCardNumber(values["number"]),
+				Expiry: expiry,
+				Cvv:    values["cvv"],
+				Holder: values["holder"],
+			}}}, nil
+		},
+		Decode: func(p *pb.Payload) map[string]string {
+			card := p.GetCard()
+			return map[string]string{
+				"number": card.GetNumber(),
+				"expiry": card.GetExpiry(),
+				"cvv":    card.GetCvv(),
+				"holder": card.GetHolder(),
+			}
+		},
+		Validate: func(values map[string]string) error {
+			return ValidateCard(&pb.Card{Number: values["number"], Expiry: values["expiry"], Cvv: values["cvv"]})
+		},
+		ParseLegacy: func(data []byte) (*pb.Payload, error) {
+			fields := legacyFields(data)
+			return &pb.Payload{Kind: &pb.Payload_Card{Card: &pb.Card{
+				Number: NormalizeCardNumber(fields["number"]),
+				Expiry: fields["expiry"],
+				Cvv:    fields["cvv"],
+			}}}, nil
+		},
+	})
+
+	Register(TypeSpec{
+		Name:  TypeText,
+		Usage: "произвольный текст",
+		Fields: []FieldSpec{
+			{Name: "content", Label: "Текст", Usage: "Текст", Required: true, FileFlag: "file"},
+		},
+		Encode: func(values map[string]string) (*pb.Payload, error) {
+			return &pb.Payload{Kind: &pb.Payload_Text{Text: &pb.Text{Content: values["content"]}}}, nil
+		},
+		Decode: func(p *pb.Payload) map[string]string {
+			return map[string]string{"content": p.GetText().GetContent()}
+		},
+		ParseLegacy: func(data []byte) (*pb.Payload, error) {
+			return &pb.Payload{Kind: &pb.Payload_Text{Text: &pb.Text{Content: string(data)}}}, nil
+		},
+	})
+
+	// Содержимое бинарной записи передаётся потоком через UploadBinary; в записи хранится его описание.
+	// При добавлении поле file — путь к файлу, в записи сохраняется только имя.
+	Register(TypeSpec{
+		Name:  TypeBinary,
+		Usage: "файл",
+		Fields: []FieldSpec{
+			{Name: "file", Label: "Файл", Usage: "Путь к файлу", Required: true},
+			{Name: "size", Label: "Размер", ReadOnly: true, Format: func(v string) string { return v + " байт" }},
+		},
+		Encode: func(values map[string]string) (*pb.Payload, error) {
+			size, err := parseSize(values["size"])
+			if err != nil {
+				return nil, err
+			}
+			return &pb.Payload{Kind: &pb.Payload_Binary{Binary: &pb.BinaryRef{
+				FileName: values["file"],
+				Size:     size,
+			}}}, nil
+		},
+		Decode: func(p *pb.Payload) map[string]string {
+			return map[string]string{
+				"file": p.GetBinary().GetFileName(),
+				"size": strconv.FormatInt(p.GetBinary().GetSize(), 10),
+			}
+		},
+		Validate: func(values map[string]string) error {
+			_, err := parseSize(values["size"])
+			return err
+		},
+		// Содержимое старых бинарных записей — сам файл
+		ParseLegacy: func(data []byte) (*pb.Payload, error) {
+			return &pb.Payload{Kind: &pb.Payload_Binary{Binary: &pb.BinaryRef{Size: int64(len(data))}}}, nil
+		},
+	})
+}
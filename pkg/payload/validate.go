@@ -0,0 +1,125 @@
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dvkhr/gophkeeper/pb"
+)
+
+var (
+	// ErrInvalidCardNumber возвращается для номера карты, не прошедшего проверку Луна.
+	ErrInvalidCardNumber = errors.New("invalid card number")
+
+	// ErrInvalidExpiry возвращается для срока действия не в формате MM/YY.
+	ErrInvalidExpiry = errors.New("invalid card expiry, expected MM/YY")
+
+	// ErrInvalidCVV возвращается для CVV не из 3–4 цифр.
+	ErrInvalidCVV = errors.New("invalid card CVV, expected 3 or 4 digits")
+)
+
+// Validate проверяет содержимое записи по описанию её типа в реестре:
+// обязательные поля и проверку, объявленную типом.
+func Validate(p *pb.Payload) error {
+	typeName := TypeOf(p)
+	if typeName == "" {
+		return errors.New("payload is empty")
+	}
+	spec, ok := Lookup(typeName)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, typeName)
+	}
+
+	values := Values(p)
+	for _, field := range spec.Fields {
+		if field.Required && values[field.Name] == "" {
+			return fmt.Errorf("%s is required", field.Name)
+		}
+	}
+
+	if spec.Validate != nil {
+		return spec.Validate(values)
+	}
+	return nil
+}
+
+// ValidateCard проверяет номер карты алгоритмом Луна, формат срока действия и CVV.
+// Номер должен быть нормализован NormalizeCardNumber.
+func ValidateCard(card *pb.Card) error {
+	if len(card.Number) < 12 || len(card.Number) > 19 || !isDigits(card.Number) || !luhnValid(card.Number) {
+		return ErrInvalidCardNumber
+	}
+	if _, err := NormalizeExpiry(card.Expiry); err != nil {
+		return err
+	}
+	if card.Cvv != "" && (len(card.Cvv) < 3 || len(card.Cvv) > 4 || !isDigits(card.Cvv)) {
+		return ErrInvalidCVV
+	}
+	return nil
+}
+
+// NormalizeCardNumber удаляет из номера карты пробелы и дефисы.
+func NormalizeCardNumber(number string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, number)
+}
+
+// NormalizeExpiry приводит срок действия MM/YY или MM/YYYY к виду MM/YY.
+func NormalizeExpiry(expiry string) (string, error) {
+	month, year, ok := strings.Cut(strings.TrimSpace(expiry), "/")
+	if !ok || len(month) != 2 || (len(year) != 2 && len(year) != 4) || !isDigits(month) || !isDigits(year) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidExpiry, expiry)
+	}
+
+	m, _ := strconv.Atoi(month)
+	if m < 1 || m > 12 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidExpiry, expiry)
+	}
+	return month + "/" + year[len(year)-2:], nil
+}
+
+// luhnValid проверяет контрольную цифру номера алгоритмом Луна.
+func luhnValid(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// parseSize разбирает неотрицательный размер в байтах.
+func parseSize(value string) (int64, error) {
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return size, nil
+}
+
+// isDigits сообщает, что строка непустая и состоит только из цифр ASCII.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"github.com/dvkhr/gophkeeper/pkg/logger"
 	"github.com/dvkhr/gophkeeper/server/internal/api"
 	"github.com/dvkhr/gophkeeper/server/internal/auth"
+	"github.com/dvkhr/gophkeeper/server/internal/blob"
 	"github.com/dvkhr/gophkeeper/server/internal/config"
 	"github.com/dvkhr/gophkeeper/server/internal/db"
 	"github.com/dvkhr/gophkeeper/server/internal/repository"
@@ -51,10 +53,25 @@ func main() {
 
 	logger.Logg.Info("Database is ready. Starting server...")
 
+	blobDir := cfg.Storage.BlobDir
+	if blobDir == "" {
+		blobDir = "data/blobs"
+	}
+	blobs, err := blob.NewStore(blobDir)
+	if err != nil {
+		logger.Logg.Error("Failed to open blob storage", "error", err)
+		return
+	}
+
 	repo := repository.NewPostgresRepository(dbConn)
 	service := service.New(repo, cfg)
+	service.Blobs = blobs
 	server := api.NewKeeperServer(service)
 
+	purgeCtx, stopPurger := context.WithCancel(context.Background())
+	defer stopPurger()
+	go service.RunPurger(purgeCtx)
+
 	// Подготовка gRPC сервера
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
 	if err != nil {
@@ -62,9 +79,11 @@ func main() {
 		panic(err)
 	}
 	interceptor := auth.AuthInterceptor(*cfg, repo)
+	streamInterceptor := auth.AuthStreamInterceptor(*cfg, repo)
 
 	grpcServer := grpc.NewServer(
 		grpc.UnaryInterceptor(interceptor),
+		grpc.StreamInterceptor(streamInterceptor),
 	)
 
 	pb.RegisterKeeperServiceServer(grpcServer, server)
@@ -74,6 +93,8 @@ func main() {
 		"dsn", cfg.Database.DSN,
 		"jwt_ttl_Hours", cfg.Auth.JWTTTLHours,
 		"jwt_ttl_Minutes", cfg.Auth.JWTTTLMinutes,
+		"trash_retention_hours", cfg.Data.TrashRetentionHours,
+		"blob_dir", blobDir,
 	)
 
 	go func() {
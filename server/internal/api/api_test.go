@@ -1,14 +1,18 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/dvkhr/gophkeeper/pb"
 	"github.com/dvkhr/gophkeeper/pkg/logger"
 	"github.com/dvkhr/gophkeeper/server/internal/auth"
+	"github.com/dvkhr/gophkeeper/server/internal/blob"
 	"github.com/dvkhr/gophkeeper/server/internal/config"
 	"github.com/dvkhr/gophkeeper/server/internal/db"
 	"github.com/dvkhr/gophkeeper/server/internal/repository"
@@ -16,7 +20,9 @@ import (
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -52,10 +58,54 @@ func setupTestServer(t *testing.T) *KeeperServer {
 		},
 	}
 	srv := service.New(repo, cfg)
+	srv.Blobs, err = blob.NewStore(t.TempDir())
+	require.NoError(t, err)
 
 	return NewKeeperServer(srv)
 }
 
+// uploadStream — поток загрузки, отдающий заранее подготовленные сообщения
+type uploadStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	reqs []*pb.UploadBinaryRequest
+	resp *pb.UploadBinaryResponse
+}
+
+func (s *uploadStream) Context() context.Context { return s.ctx }
+
+func (s *uploadStream) Recv() (*pb.UploadBinaryRequest, error) {
+	if len(s.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+
+func (s *uploadStream) SendAndClose(resp *pb.UploadBinaryResponse) error {
+	s.resp = resp
+	return nil
+}
+
+// downloadStream — поток скачивания, накапливающий отправленные чанки
+type downloadStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	chunks []*pb.BinaryChunk
+}
+
+func (s *downloadStream) Context() context.Context { return s.ctx }
+
+func (s *downloadStream) Send(chunk *pb.BinaryChunk) error {
+	s.chunks = append(s.chunks, &pb.BinaryChunk{
+		Chunk:     bytes.Clone(chunk.Chunk),
+		TotalSize: chunk.TotalSize,
+		Sha256:    chunk.Sha256,
+	})
+	return nil
+}
+
 // успешная регистрация
 func TestRegister_Success(t *testing.T) {
 	server := setupTestServer(t)
@@ -248,6 +298,39 @@ func TestStoreData_EmptyId(t *testing.T) {
 	assert.Nil(t, resp)
 }
 
+// тип записи — строка в допустимом формате, не только встроенные типы
+func TestStoreData_RecordType(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerResp, err := server.Register(context.Background(), &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	})
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	_, err = server.StoreData(ctx, &pb.StoreDataRequest{
+		Record: &pb.DataRecord{Id: "totp-1", Type: "totp", EncryptedData: []byte("data")},
+	})
+	require.NoError(t, err)
+
+	resp, err := server.GetData(ctx, &pb.GetDataRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Records, 1)
+	assert.Equal(t, "totp", resp.Records[0].Type)
+
+	for _, recordType := range []string{"", "Bad Type", "x'; DROP TABLE user_data; --"} {
+		_, err = server.StoreData(ctx, &pb.StoreDataRequest{
+			Record: &pb.DataRecord{Id: "bad", Type: recordType, EncryptedData: []byte("data")},
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), recordType)
+	}
+}
+
 // пустая запись
 func TestStoreData_RecordNil(t *testing.T) {
 	server := setupTestServer(t)
@@ -488,15 +571,125 @@ func TestSyncData_UpdateRecords(t *testing.T) {
 		Id:            "record-1",
 		Type:          "loginpass",
 		EncryptedData: []byte("new-data"),
+		Revision:      1,
 	}
 	resp, err := server.SyncData(ctx, &pb.SyncRequest{Records: []*pb.DataRecord{updatedRecord}})
 	require.NoError(t, err)
+	require.Empty(t, resp.Conflicts)
 	require.Len(t, resp.Records, 1)
 	assert.Equal(t, []byte("new-data"), resp.Records[0].EncryptedData)
+	assert.Equal(t, int64(2), resp.Records[0].Revision)
+}
+
+// устаревшая базовая ревизия
+func TestSyncData_StaleRevisionConflict(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerReq := &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	}
+	registerResp, err := server.Register(context.Background(), registerReq)
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	record := &pb.DataRecord{
+		Id:            "record-1",
+		Type:          "loginpass",
+		EncryptedData: []byte("device-1"),
+	}
+	_, err = server.SyncData(ctx, &pb.SyncRequest{Records: []*pb.DataRecord{record}})
+	require.NoError(t, err)
+
+	// первое устройство обновляет запись, зная ревизию 1
+	first := &pb.DataRecord{Id: "record-1", Type: "loginpass", EncryptedData: []byte("device-1-edit"), Revision: 1}
+	resp, err := server.SyncData(ctx, &pb.SyncRequest{Records: []*pb.DataRecord{first}})
+	require.NoError(t, err)
+	require.Empty(t, resp.Conflicts)
+
+	// второе устройство тоже знает только ревизию 1
+	second := &pb.DataRecord{Id: "record-1", Type: "loginpass", EncryptedData: []byte("device-2-edit"), Revision: 1}
+	resp, err = server.SyncData(ctx, &pb.SyncRequest{Records: []*pb.DataRecord{second}})
+	require.NoError(t, err)
+	require.Len(t, resp.Conflicts, 1)
+	assert.Equal(t, "record-1", resp.Conflicts[0].Id)
+	assert.Equal(t, int64(1), resp.Conflicts[0].BaseRevision)
+	assert.Equal(t, int64(2), resp.Conflicts[0].ServerRevision)
+
+	require.Len(t, resp.Records, 1)
+	assert.Equal(t, []byte("device-1-edit"), resp.Records[0].EncryptedData)
+}
+
+// новая запись с уже существующим ID
+func TestSyncData_CreateExistingConflict(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerReq := &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	}
+	registerResp, err := server.Register(context.Background(), registerReq)
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	record := &pb.DataRecord{Id: "record-1", Type: "text", EncryptedData: []byte("original")}
+	_, err = server.StoreData(ctx, &pb.StoreDataRequest{Record: record})
+	require.NoError(t, err)
+
+	duplicate := &pb.DataRecord{Id: "record-1", Type: "text", EncryptedData: []byte("duplicate")}
+	resp, err := server.SyncData(ctx, &pb.SyncRequest{Records: []*pb.DataRecord{duplicate}})
+	require.NoError(t, err)
+	require.Len(t, resp.Conflicts, 1)
+	assert.Equal(t, int64(0), resp.Conflicts[0].BaseRevision)
+	assert.Equal(t, int64(1), resp.Conflicts[0].ServerRevision)
+	assert.Equal(t, []byte("original"), resp.Records[0].EncryptedData)
+}
+
+// запись, которую не удалось сохранить, возвращается в списке отклонённых
+func TestSyncData_RejectedRecords(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerReq := &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	}
+	registerResp, err := server.Register(context.Background(), registerReq)
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	records := []*pb.DataRecord{
+		// PostgreSQL не хранит \u0000 в JSONB
+		{Id: "broken", Type: "text", EncryptedData: []byte("data"), Metadata: map[string]string{"note": "a\x00b"}},
+		{Id: "record-1", Type: "text", EncryptedData: []byte("data")},
+	}
+	deletions := []*pb.Tombstone{{Id: "missing", Revision: 1}}
+
+	resp, err := server.SyncData(ctx, &pb.SyncRequest{Records: records, Deletions: deletions})
+	require.NoError(t, err)
+	require.Len(t, resp.Rejected, 1)
+	assert.Equal(t, "broken", resp.Rejected[0].Id)
+	assert.NotEmpty(t, resp.Rejected[0].Reason)
+	assert.True(t, resp.Rejected[0].Retryable)
+	assert.Empty(t, resp.Conflicts)
+
+	require.Len(t, resp.Records, 1)
+	assert.Equal(t, "record-1", resp.Records[0].Id)
 }
 
-// невалидные записи
-func TestSyncData_SkipInvalidRecords(t *testing.T) {
+// невалидные записи не сохраняются и возвращаются в списке отклонённых
+func TestSyncData_RejectInvalidRecords(t *testing.T) {
 	server := setupTestServer(t)
 
 	registerReq := &pb.RegisterRequest{
@@ -514,6 +707,7 @@ func TestSyncData_SkipInvalidRecords(t *testing.T) {
 	records := []*pb.DataRecord{
 		nil,
 		{Id: "", Type: "loginpass"},
+		{Id: "bad-type", Type: "Bad Type", EncryptedData: []byte("data")},
 		{Id: "record-1", Type: "card", EncryptedData: []byte("valid-data")},
 	}
 
@@ -521,6 +715,134 @@ func TestSyncData_SkipInvalidRecords(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, resp.Records, 1)
 	assert.Equal(t, "record-1", resp.Records[0].Id)
+
+	require.Len(t, resp.Rejected, 2)
+	assert.Equal(t, "", resp.Rejected[0].Id)
+	assert.Equal(t, "bad-type", resp.Rejected[1].Id)
+	assert.Contains(t, resp.Rejected[1].Reason, "invalid record type")
+	assert.False(t, resp.Rejected[0].Retryable)
+	assert.False(t, resp.Rejected[1].Retryable)
+}
+
+// ID, занятый записью другого пользователя, отклоняется, а не возвращается конфликтом
+func TestSyncData_RejectForeignID(t *testing.T) {
+	server := setupTestServer(t)
+
+	userContext := func(login string) context.Context {
+		resp, err := server.Register(context.Background(), &pb.RegisterRequest{Login: login, EncryptedPassword: []byte("pass")})
+		require.NoError(t, err)
+		claims, err := auth.ParseToken(*server.srv.Cfg, resp.AccessToken)
+		require.NoError(t, err)
+		return auth.WithUserID(context.Background(), claims.UserID)
+	}
+	owner, other := userContext("owner"), userContext("other")
+
+	_, err := server.SyncData(owner, &pb.SyncRequest{
+		Records: []*pb.DataRecord{{Id: "shared-id", Type: "text", EncryptedData: []byte("owner")}},
+	})
+	require.NoError(t, err)
+
+	resp, err := server.SyncData(other, &pb.SyncRequest{
+		Records: []*pb.DataRecord{{Id: "shared-id", Type: "text", EncryptedData: []byte("other"), Revision: 1}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Conflicts)
+	require.Len(t, resp.Rejected, 1)
+	assert.Equal(t, "shared-id", resp.Rejected[0].Id)
+	assert.Equal(t, "record ID is not available", resp.Rejected[0].Reason)
+	assert.False(t, resp.Rejected[0].Retryable)
+	assert.Empty(t, resp.Records)
+}
+
+// синхронизация с курсором возвращает только новые изменения
+func TestSyncData_DeltaSinceCursor(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerReq := &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	}
+	registerResp, err := server.Register(context.Background(), registerReq)
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	records := []*pb.DataRecord{
+		{Id: "record-1", Type: "text", EncryptedData: []byte("data-1")},
+		{Id: "record-2", Type: "text", EncryptedData: []byte("data-2")},
+	}
+	resp, err := server.SyncData(ctx, &pb.SyncRequest{Records: records})
+	require.NoError(t, err)
+	require.Len(t, resp.Records, 2)
+	require.NotZero(t, resp.Cursor)
+	cursor := resp.Cursor
+
+	// без изменений — пустой ответ и тот же курсор
+	resp, err = server.SyncData(ctx, &pb.SyncRequest{Cursor: cursor})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Records)
+	assert.Empty(t, resp.Tombstones)
+	assert.Equal(t, cursor, resp.Cursor)
+
+	// изменение одной записи и удаление другой
+	_, err = server.StoreData(ctx, &pb.StoreDataRequest{Record: &pb.DataRecord{Id: "record-1", Type: "text", EncryptedData: []byte("data-1-v2")}})
+	require.NoError(t, err)
+	_, err = server.DeleteData(ctx, &pb.DeleteDataRequest{Id: "record-2"})
+	require.NoError(t, err)
+
+	resp, err = server.SyncData(ctx, &pb.SyncRequest{Cursor: cursor})
+	require.NoError(t, err)
+	require.Len(t, resp.Records, 1)
+	assert.Equal(t, "record-1", resp.Records[0].Id)
+	assert.Equal(t, []byte("data-1-v2"), resp.Records[0].EncryptedData)
+	require.Len(t, resp.Tombstones, 1)
+	assert.Equal(t, "record-2", resp.Tombstones[0].Id)
+	assert.Greater(t, resp.Cursor, cursor)
+}
+
+// удаление через синхронизацию с проверкой ревизии
+func TestSyncData_Deletions(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerReq := &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	}
+	registerResp, err := server.Register(context.Background(), registerReq)
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	records := []*pb.DataRecord{
+		{Id: "record-1", Type: "text", EncryptedData: []byte("data-1")},
+		{Id: "record-2", Type: "text", EncryptedData: []byte("data-2")},
+	}
+	resp, err := server.SyncData(ctx, &pb.SyncRequest{Records: records})
+	require.NoError(t, err)
+	cursor := resp.Cursor
+
+	deletions := []*pb.Tombstone{
+		{Id: "record-1", Revision: 1},
+		{Id: "record-2", Revision: 0}, // устаревшая ревизия
+	}
+	resp, err = server.SyncData(ctx, &pb.SyncRequest{Deletions: deletions, Cursor: cursor})
+	require.NoError(t, err)
+
+	require.Len(t, resp.Conflicts, 1)
+	assert.Equal(t, "record-2", resp.Conflicts[0].Id)
+	require.Len(t, resp.Tombstones, 1)
+	assert.Equal(t, "record-1", resp.Tombstones[0].Id)
+
+	getResp, err := server.GetData(ctx, &pb.GetDataRequest{})
+	require.NoError(t, err)
+	require.Len(t, getResp.Records, 1)
+	assert.Equal(t, "record-2", getResp.Records[0].Id)
 }
 
 // успешное удаление
@@ -736,3 +1058,440 @@ func TestLogout_Success(t *testing.T) {
 	assert.True(t, revoked, "refresh_token должен быть отозван после Logout")
 
 }
+
+// загрузка с продолжением после обрыва и скачивание с проверкой хэша
+func TestUploadBinary_ResumeAndDownload(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerResp, err := server.Register(context.Background(), &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	})
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	ciphertext := []byte("encrypted-binary-content")
+	sum := sha256.Sum256(ciphertext)
+	header := &pb.UploadHeader{
+		Record:   &pb.DataRecord{Id: "file-1", Type: "binary"},
+		UploadId: "0123456789abcdef",
+	}
+
+	// Первая попытка обрывается после первого чанка
+	stream := &uploadStream{ctx: ctx, reqs: []*pb.UploadBinaryRequest{
+		{Header: header, Chunk: ciphertext[:10]},
+	}}
+	require.NoError(t, server.UploadBinary(stream))
+	assert.False(t, stream.resp.Completed)
+
+	offsetResp, err := server.GetUploadOffset(ctx, &pb.UploadOffsetRequest{Id: "file-1", UploadId: header.UploadId})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), offsetResp.Offset)
+
+	header.Offset = offsetResp.Offset
+	stream = &uploadStream{ctx: ctx, reqs: []*pb.UploadBinaryRequest{
+		{Header: header, Chunk: ciphertext[10:], Sha256: sum[:]},
+	}}
+	require.NoError(t, server.UploadBinary(stream))
+	assert.True(t, stream.resp.Completed)
+	assert.Equal(t, int64(len(ciphertext)), stream.resp.Received)
+	assert.Equal(t, int64(1), stream.resp.Revision)
+
+	getResp, err := server.GetData(ctx, &pb.GetDataRequest{})
+	require.NoError(t, err)
+	require.Len(t, getResp.Records, 1)
+	assert.Equal(t, int64(len(ciphertext)), getResp.Records[0].BlobSize)
+	assert.Equal(t, sum[:], getResp.Records[0].BlobSha256)
+
+	download := &downloadStream{ctx: ctx}
+	require.NoError(t, server.DownloadBinary(&pb.DownloadBinaryRequest{Id: "file-1", Offset: 5}, download))
+	require.NotEmpty(t, download.chunks)
+	assert.Equal(t, int64(len(ciphertext)), download.chunks[0].TotalSize)
+	assert.Equal(t, sum[:], download.chunks[0].Sha256)
+
+	var received []byte
+	for _, chunk := range download.chunks {
+		received = append(received, chunk.Chunk...)
+	}
+	assert.Equal(t, ciphertext[5:], received)
+}
+
+// несовпадение хэша отбрасывает загрузку
+func TestUploadBinary_HashMismatch(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerResp, err := server.Register(context.Background(), &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	})
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	sum := sha256.Sum256([]byte("something else"))
+	stream := &uploadStream{ctx: ctx, reqs: []*pb.UploadBinaryRequest{{
+		Header: &pb.UploadHeader{
+			Record:   &pb.DataRecord{Id: "file-1", Type: "binary"},
+			UploadId: "0123456789abcdef",
+		},
+		Chunk:  []byte("content"),
+		Sha256: sum[:],
+	}}}
+	err = server.UploadBinary(stream)
+	require.Error(t, err)
+	assert.Equal(t, codes.DataLoss, status.Code(err))
+
+	err = server.DownloadBinary(&pb.DownloadBinaryRequest{Id: "file-1"}, &downloadStream{ctx: ctx})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// сохранение ключа хранилища и защита от перезаписи устаревшей версией
+func TestVaultKey_StoreAndGet(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerResp, err := server.Register(context.Background(), &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	})
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	_, err = server.GetVaultKey(ctx, &pb.GetVaultKeyRequest{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	key := &pb.VaultKey{
+		WrappedKey: []byte("wrapped"),
+		Salt:       []byte("salt"),
+		Kdf:        &pb.KDFParams{Algorithm: "argon2id", Iterations: 3, Memory: 65536, Parallelism: 4, KeyLength: 32},
+	}
+	stored, err := server.StoreVaultKey(ctx, &pb.StoreVaultKeyRequest{Key: key})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored.Version)
+
+	_, err = server.StoreVaultKey(ctx, &pb.StoreVaultKeyRequest{Key: key})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = server.StoreVaultKey(ctx, &pb.StoreVaultKeyRequest{Key: &pb.VaultKey{Salt: []byte("salt")}, ExpectedVersion: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	got, err := server.GetVaultKey(ctx, &pb.GetVaultKeyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("wrapped"), got.WrappedKey)
+	assert.Equal(t, int64(1), got.Version)
+}
+
+func TestChangeMasterPassword(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerResp, err := server.Register(context.Background(), &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("old-pass"),
+	})
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	kdf := &pb.KDFParams{Algorithm: "argon2id", Iterations: 3, Memory: 65536, Parallelism: 4, KeyLength: 32}
+	_, err = server.StoreVaultKey(ctx, &pb.StoreVaultKeyRequest{
+		Key: &pb.VaultKey{WrappedKey: []byte("wrapped-old"), Salt: []byte("salt-old"), Kdf: kdf},
+	})
+	require.NoError(t, err)
+
+	newKey := &pb.VaultKey{WrappedKey: []byte("wrapped-new"), Salt: []byte("salt-new"), Kdf: kdf}
+
+	_, err = server.ChangeMasterPassword(ctx, &pb.ChangeMasterPasswordRequest{
+		OldPassword: []byte("wrong"), NewPassword: []byte("new-pass"), Key: newKey, ExpectedVersion: 1,
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = server.ChangeMasterPassword(ctx, &pb.ChangeMasterPasswordRequest{
+		OldPassword: []byte("old-pass"), NewPassword: []byte("new-pass"), Key: newKey, ExpectedVersion: 0,
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	resp, err := server.ChangeMasterPassword(ctx, &pb.ChangeMasterPasswordRequest{
+		OldPassword: []byte("old-pass"), NewPassword: []byte("new-pass"), Key: newKey, ExpectedVersion: 1,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Auth.AccessToken)
+	assert.Equal(t, int64(2), resp.Key.Version)
+
+	// старые refresh-токены отозваны
+	_, err = server.Refresh(context.Background(), &pb.RefreshRequest{RefreshToken: registerResp.RefreshToken})
+	assert.Error(t, err)
+
+	// access-токен другой сессии сразу перестаёт приниматься, новый принимается
+	interceptor := auth.AuthInterceptor(*server.srv.Cfg, server.srv.Repo)
+	info := &grpc.UnaryServerInfo{FullMethod: "/keeper.KeeperService/GetData"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	bearer := func(token string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	}
+
+	_, err = interceptor(bearer(registerResp.AccessToken), nil, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = interceptor(bearer(resp.Auth.AccessToken), nil, info, handler)
+	require.NoError(t, err)
+
+	_, err = server.Login(context.Background(), &pb.LoginRequest{Login: "testuser", EncryptedPassword: []byte("old-pass")})
+	assert.Error(t, err)
+
+	_, err = server.Login(context.Background(), &pb.LoginRequest{Login: "testuser", EncryptedPassword: []byte("new-pass")})
+	require.NoError(t, err)
+
+	got, err := server.GetVaultKey(ctx, &pb.GetVaultKeyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("wrapped-new"), got.WrappedKey)
+}
+
+// поиск по слепым индексам
+func TestGetData_BlindIndexes(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerResp, err := server.Register(context.Background(), &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	})
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	for _, record := range []*pb.DataRecord{
+		{Id: "record-1", Type: "loginpass", EncryptedData: []byte("a"), BlindIndexes: [][]byte{[]byte("idx-1")}},
+		{Id: "record-2", Type: "loginpass", EncryptedData: []byte("b"), BlindIndexes: [][]byte{[]byte("idx-2")}},
+	} {
+		_, err = server.StoreData(ctx, &pb.StoreDataRequest{Record: record})
+		require.NoError(t, err)
+	}
+
+	resp, err := server.GetData(ctx, &pb.GetDataRequest{BlindIndexes: [][]byte{[]byte("idx-2")}})
+	require.NoError(t, err)
+	require.Len(t, resp.Records, 1)
+	assert.Equal(t, "record-2", resp.Records[0].Id)
+
+	resp, err = server.GetData(ctx, &pb.GetDataRequest{})
+	require.NoError(t, err)
+	assert.Len(t, resp.Records, 2)
+
+	_, err = server.GetData(ctx, &pb.GetDataRequest{BlindIndexes: make([][]byte, 17)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// одна запись по ID; чужая запись не возвращается
+func TestGetRecord(t *testing.T) {
+	server := setupTestServer(t)
+
+	ctxs := make([]context.Context, 2)
+	for i, login := range []string{"owner", "stranger"} {
+		registerResp, err := server.Register(context.Background(), &pb.RegisterRequest{
+			Login:             login,
+			EncryptedPassword: []byte("pass"),
+		})
+		require.NoError(t, err)
+
+		userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+		require.NoError(t, err)
+		ctxs[i] = auth.WithUserID(context.Background(), userID.UserID)
+	}
+	owner, stranger := ctxs[0], ctxs[1]
+
+	for _, id := range []string{"record-1", "record-2"} {
+		_, err := server.StoreData(owner, &pb.StoreDataRequest{Record: &pb.DataRecord{
+			Id:            id,
+			Type:          "loginpass",
+			EncryptedData: []byte("data-" + id),
+		}})
+		require.NoError(t, err)
+	}
+
+	resp, err := server.GetRecord(owner, &pb.GetRecordRequest{Id: "record-2"})
+	require.NoError(t, err)
+	assert.Equal(t, "record-2", resp.Record.Id)
+	assert.Equal(t, []byte("data-record-2"), resp.Record.EncryptedData)
+
+	_, err = server.GetRecord(stranger, &pb.GetRecordRequest{Id: "record-2"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.GetRecord(owner, &pb.GetRecordRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.DeleteData(owner, &pb.DeleteDataRequest{Id: "record-1"})
+	require.NoError(t, err)
+	_, err = server.GetRecord(owner, &pb.GetRecordRequest{Id: "record-1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.GetRecord(owner, &pb.GetRecordRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.GetRecord(context.Background(), &pb.GetRecordRequest{Id: "record-2"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// фильтр по типу и ID, постраничная выдача
+func TestGetData_Pages(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerResp, err := server.Register(context.Background(), &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	})
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	for _, record := range []*pb.DataRecord{
+		{Id: "login-1", Type: "loginpass", EncryptedData: []byte("a")},
+		{Id: "login-2", Type: "loginpass", EncryptedData: []byte("b")},
+		{Id: "login-3", Type: "loginpass", EncryptedData: []byte("c")},
+		{Id: "card-1", Type: "card", EncryptedData: []byte("d")},
+	} {
+		_, err = server.StoreData(ctx, &pb.StoreDataRequest{Record: record})
+		require.NoError(t, err)
+	}
+
+	resp, err := server.GetData(ctx, &pb.GetDataRequest{Id: "card-1"})
+	require.NoError(t, err)
+	require.Len(t, resp.Records, 1)
+	assert.Equal(t, "card-1", resp.Records[0].Id)
+	assert.Empty(t, resp.NextPageToken)
+
+	seen := make(map[string]bool)
+	req := &pb.GetDataRequest{Type: "loginpass", PageSize: 2}
+	resp, err = server.GetData(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, resp.Records, 2)
+	require.NotEmpty(t, resp.NextPageToken)
+	for _, record := range resp.Records {
+		seen[record.Id] = true
+	}
+
+	req.PageToken = resp.NextPageToken
+	resp, err = server.GetData(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, resp.Records, 1)
+	assert.Empty(t, resp.NextPageToken)
+	seen[resp.Records[0].Id] = true
+	assert.Equal(t, map[string]bool{"login-1": true, "login-2": true, "login-3": true}, seen)
+
+	_, err = server.GetData(ctx, &pb.GetDataRequest{PageToken: "%%%"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.GetData(ctx, &pb.GetDataRequest{Type: "Bad Type"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.GetData(ctx, &pb.GetDataRequest{PageSize: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// перезаписанная версия попадает в историю и восстанавливается
+func TestRestoreVersion(t *testing.T) {
+	server := setupTestServer(t)
+
+	registerResp, err := server.Register(context.Background(), &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	})
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	for _, data := range []string{"old-password", "new-password"} {
+		_, err = server.StoreData(ctx, &pb.StoreDataRequest{
+			Record: &pb.DataRecord{Id: "gmail", Type: "loginpass", EncryptedData: []byte(data)},
+		})
+		require.NoError(t, err)
+	}
+
+	listResp, err := server.ListVersions(ctx, &pb.ListVersionsRequest{Id: "gmail"})
+	require.NoError(t, err)
+	require.Len(t, listResp.Versions, 1)
+	assert.Equal(t, []byte("old-password"), listResp.Versions[0].Record.EncryptedData)
+
+	_, err = server.RestoreVersion(ctx, &pb.RestoreVersionRequest{Id: "gmail", Revision: 1, BaseRevision: 1})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = server.RestoreVersion(ctx, &pb.RestoreVersionRequest{Id: "gmail", Revision: 7})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	restoreResp, err := server.RestoreVersion(ctx, &pb.RestoreVersionRequest{Id: "gmail", Revision: 1, BaseRevision: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), restoreResp.Revision)
+
+	dataResp, err := server.GetData(ctx, &pb.GetDataRequest{})
+	require.NoError(t, err)
+	require.Len(t, dataResp.Records, 1)
+	assert.Equal(t, []byte("old-password"), dataResp.Records[0].EncryptedData)
+
+	_, err = server.ListVersions(context.Background(), &pb.ListVersionsRequest{Id: "gmail"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// удалённая запись видна в корзине и восстанавливается
+func TestRestoreData(t *testing.T) {
+	server := setupTestServer(t)
+	server.srv.Cfg.Data.TrashRetentionHours = 48
+	server.srv.Cfg.Data.TombstoneRetentionHours = 1
+
+	registerResp, err := server.Register(context.Background(), &pb.RegisterRequest{
+		Login:             "testuser",
+		EncryptedPassword: []byte("pass"),
+	})
+	require.NoError(t, err)
+
+	userID, err := auth.ParseToken(*server.srv.Cfg, registerResp.AccessToken)
+	require.NoError(t, err)
+
+	ctx := auth.WithUserID(context.Background(), userID.UserID)
+
+	_, err = server.StoreData(ctx, &pb.StoreDataRequest{
+		Record: &pb.DataRecord{Id: "note", Type: "text", EncryptedData: []byte("data")},
+	})
+	require.NoError(t, err)
+
+	_, err = server.RestoreData(ctx, &pb.RestoreDataRequest{Id: "note"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.DeleteData(ctx, &pb.DeleteDataRequest{Id: "note"})
+	require.NoError(t, err)
+
+	trash, err := server.ListDeleted(ctx, &pb.ListDeletedRequest{})
+	require.NoError(t, err)
+	require.Len(t, trash.Records, 1)
+	assert.Equal(t, "note", trash.Records[0].Record.Id)
+	// срок хранения корзины задаётся trash_retention_hours
+	assert.Equal(t, trash.Records[0].DeletedAt+48*3600, trash.Records[0].PurgeAt)
+
+	_, err = server.RestoreData(ctx, &pb.RestoreDataRequest{Id: "note"})
+	require.NoError(t, err)
+
+	dataResp, err := server.GetData(ctx, &pb.GetDataRequest{})
+	require.NoError(t, err)
+	require.Len(t, dataResp.Records, 1)
+
+	trash, err = server.ListDeleted(ctx, &pb.ListDeletedRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, trash.Records)
+}
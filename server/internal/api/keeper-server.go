@@ -18,6 +18,7 @@ import (
 	"github.com/dvkhr/gophkeeper/pkg/logger"
 	"github.com/dvkhr/gophkeeper/server/internal/auth"
 	"github.com/dvkhr/gophkeeper/server/internal/service"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -85,47 +86,54 @@ func (s *KeeperServer) StoreData(ctx context.Context, req *pb.StoreDataRequest)
 	}, nil
 }
 
-// GetData возвращает все неудалённые данные пользователя.
+// GetData возвращает неудалённые данные пользователя.
 // Проверяет, что пользователь авторизован (userID в контексте).
-// Загружает все записи из БД через сервис.
+// ID, тип и слепые индексы из запроса ограничивают выборку; page_size и page_token задают страницу.
 func (s *KeeperServer) GetData(ctx context.Context, req *pb.GetDataRequest) (*pb.DataResponse, error) {
 	userID, ok := auth.GetUserID(ctx)
 	if !ok {
 		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
 	}
 
-	logger.Logg.Info("Getting all data", "user", userID)
+	logger.Logg.Info("Getting data", "user", userID, "type", req.Type, "page_size", req.PageSize)
 
-	records, err := s.srv.GetData(ctx, userID)
+	return s.srv.GetData(ctx, userID, req)
+}
+
+// GetRecord возвращает одну запись пользователя.
+// Проверяет, что пользователь авторизован (userID в контексте); запись другого пользователя не возвращается.
+func (s *KeeperServer) GetRecord(ctx context.Context, req *pb.GetRecordRequest) (*pb.GetRecordResponse, error) {
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	record, err := s.srv.GetRecord(ctx, userID, req.Id)
 	if err != nil {
 		return nil, err
 	}
 
-	return &pb.DataResponse{
-		Records: records,
-	}, nil
+	return &pb.GetRecordResponse{Record: record}, nil
 }
 
 // SyncData синхронизирует клиентские данные с сервером.
 // Проверяет, что пользователь авторизован (userID в контексте).
-// Сохраняет все записи.
-// Возвращает все неудаленные данные с сервера.
+// Сохраняет записи, базовая ревизия которых совпадает с серверной.
+// Возвращает изменения после курсора клиента и список конфликтов.
 func (s *KeeperServer) SyncData(ctx context.Context, req *pb.SyncRequest) (*pb.SyncResponse, error) {
 	userID, ok := auth.GetUserID(ctx)
 	if !ok {
 		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
 	}
 
-	logger.Logg.Info("Syncing data", "count", len(req.Records), "user", userID)
+	logger.Logg.Info("Syncing data", "count", len(req.Records), "deletions", len(req.Deletions), "cursor", req.Cursor, "user", userID)
 
-	records, err := s.srv.SyncData(ctx, userID, req.Records)
+	resp, err := s.srv.SyncData(ctx, userID, req.Records, req.Deletions, req.Cursor)
 	if err != nil {
 		return nil, err
 	}
 
-	return &pb.SyncResponse{
-		Records: records,
-	}, nil
+	return resp, nil
 }
 
 // DeleteData помечает запись как удалённую.
@@ -151,6 +159,142 @@ func (s *KeeperServer) DeleteData(ctx context.Context, req *pb.DeleteDataRequest
 	}, nil
 }
 
+// UploadBinary принимает содержимое бинарной записи потоком чанков шифротекста.
+// Проверяет, что пользователь авторизован (userID в контексте потока).
+func (s *KeeperServer) UploadBinary(stream grpc.ClientStreamingServer[pb.UploadBinaryRequest, pb.UploadBinaryResponse]) error {
+	ctx := stream.Context()
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	logger.Logg.Info("Uploading binary data", "user", userID)
+
+	return s.srv.UploadBinary(ctx, userID, stream)
+}
+
+// DownloadBinary отдаёт содержимое бинарной записи потоком чанков шифротекста.
+func (s *KeeperServer) DownloadBinary(req *pb.DownloadBinaryRequest, stream grpc.ServerStreamingServer[pb.BinaryChunk]) error {
+	ctx := stream.Context()
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	logger.Logg.Info("Downloading binary data", "record_id", req.Id, "offset", req.Offset, "user", userID)
+
+	return s.srv.DownloadBinary(ctx, userID, req.Id, req.Offset, stream)
+}
+
+// GetUploadOffset возвращает смещение, с которого можно продолжить прерванную загрузку.
+func (s *KeeperServer) GetUploadOffset(ctx context.Context, req *pb.UploadOffsetRequest) (*pb.UploadOffsetResponse, error) {
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	offset, err := s.srv.GetUploadOffset(ctx, userID, req.Id, req.UploadId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UploadOffsetResponse{Offset: offset}, nil
+}
+
+// GetVaultKey возвращает ключ хранилища пользователя, зашифрованный мастер-ключом.
+func (s *KeeperServer) GetVaultKey(ctx context.Context, req *pb.GetVaultKeyRequest) (*pb.VaultKey, error) {
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	return s.srv.GetVaultKey(ctx, userID)
+}
+
+// StoreVaultKey сохраняет ключ хранилища пользователя, зашифрованный мастер-ключом.
+func (s *KeeperServer) StoreVaultKey(ctx context.Context, req *pb.StoreVaultKeyRequest) (*pb.VaultKey, error) {
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	logger.Logg.Info("Storing vault key", "expected_version", req.ExpectedVersion, "user", userID)
+
+	return s.srv.StoreVaultKey(ctx, userID, req.Key, req.ExpectedVersion)
+}
+
+// ChangeMasterPassword меняет мастер-пароль пользователя и завершает его остальные сессии.
+func (s *KeeperServer) ChangeMasterPassword(ctx context.Context, req *pb.ChangeMasterPasswordRequest) (*pb.ChangeMasterPasswordResponse, error) {
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	logger.Logg.Info("Changing master password", "user", userID)
+
+	return s.srv.ChangeMasterPassword(ctx, userID, string(req.OldPassword), string(req.NewPassword), req.Key, req.ExpectedVersion)
+}
+
+// ListVersions возвращает прежние версии записи.
+func (s *KeeperServer) ListVersions(ctx context.Context, req *pb.ListVersionsRequest) (*pb.ListVersionsResponse, error) {
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	versions, err := s.srv.ListVersions(ctx, userID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListVersionsResponse{Versions: versions}, nil
+}
+
+// RestoreVersion делает прежнюю версию записи текущей.
+func (s *KeeperServer) RestoreVersion(ctx context.Context, req *pb.RestoreVersionRequest) (*pb.RestoreVersionResponse, error) {
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	revision, err := s.srv.RestoreVersion(ctx, userID, req.Id, req.Revision, req.BaseRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RestoreVersionResponse{Revision: revision}, nil
+}
+
+// ListDeleted возвращает записи корзины пользователя.
+func (s *KeeperServer) ListDeleted(ctx context.Context, req *pb.ListDeletedRequest) (*pb.ListDeletedResponse, error) {
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	records, err := s.srv.ListDeleted(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListDeletedResponse{Records: records}, nil
+}
+
+// RestoreData восстанавливает удалённую запись из корзины.
+func (s *KeeperServer) RestoreData(ctx context.Context, req *pb.RestoreDataRequest) (*pb.RestoreDataResponse, error) {
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing user ID in context")
+	}
+
+	revision, err := s.srv.RestoreData(ctx, userID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RestoreDataResponse{Revision: revision}, nil
+}
+
 // Refresh обновляет пару токенов (access и refresh) по старому refresh-токену.
 func (s *KeeperServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.AuthResponse, error) {
 	if req.RefreshToken == "" {
@@ -15,14 +15,16 @@ import (
 )
 
 // Claims — структура полезной нагрузки (payload) JWT-токена.
-// Включает идентификатор пользователя и стандартные claims (ExpiresAt, IssuedAt, Issuer и др.).
+// Включает идентификатор пользователя, версию его сессий и стандартные claims (ExpiresAt, IssuedAt, Issuer и др.).
 type Claims struct {
-	UserID string `json:"user_id"`
+	UserID         string `json:"user_id"`
+	SessionVersion int64  `json:"session_version"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken — создаёт новый JWT-токен для пользователя
-func GenerateToken(cfg config.Config, userID string) (string, error) {
+// GenerateToken — создаёт новый JWT-токен для пользователя.
+// sessionVersion — текущая версия сессий пользователя, её проверяет AuthInterceptor.
+func GenerateToken(cfg config.Config, userID string, sessionVersion int64) (string, error) {
 	ttl := time.Duration(cfg.Auth.JWTTTLHours)*time.Hour +
 		time.Duration(cfg.Auth.JWTTTLMinutes)*time.Minute
 
@@ -34,7 +36,8 @@ func GenerateToken(cfg config.Config, userID string) (string, error) {
 	expiresAt := now.Add(ttl)
 
 	claims := &Claims{
-		UserID: userID,
+		UserID:         userID,
+		SessionVersion: sessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
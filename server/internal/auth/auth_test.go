@@ -3,13 +3,20 @@
 package auth
 
 import (
+	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/dvkhr/gophkeeper/pkg/logger"
 	"github.com/dvkhr/gophkeeper/server/internal/config"
+	"github.com/dvkhr/gophkeeper/server/internal/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type AuthTestSuite struct {
@@ -38,7 +45,7 @@ func (suite *AuthTestSuite) TestGenerateAndParseToken() {
 	userID := "user123"
 
 	// Генерируем токен
-	tokenStr, err := GenerateToken(suite.cfg, userID)
+	tokenStr, err := GenerateToken(suite.cfg, userID, 3)
 	require.NoError(suite.T(), err)
 	assert.NotEmpty(suite.T(), tokenStr)
 
@@ -48,6 +55,7 @@ func (suite *AuthTestSuite) TestGenerateAndParseToken() {
 
 	// Проверяем userID
 	assert.Equal(suite.T(), userID, claims.UserID)
+	assert.Equal(suite.T(), int64(3), claims.SessionVersion)
 
 	// Проверяем Issuer
 	assert.Equal(suite.T(), "GophKeeper", claims.Issuer)
@@ -70,3 +78,55 @@ func (suite *AuthTestSuite) TestHashPassword_ShouldHashAndPasswordCheck() {
 func (suite *AuthTestSuite) TestCheckPasswordHash_InvalidHash() {
 	assert.False(suite.T(), CheckPasswordHash("password", "invalid-hash"))
 }
+
+// sessionRepo — репозиторий токенов, в котором известна только версия сессий пользователей
+type sessionRepo struct {
+	repository.TokenRepository
+	versions map[string]int64
+}
+
+func (r *sessionRepo) GetSessionVersion(userID string) (int64, error) {
+	version, ok := r.versions[userID]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return version, nil
+}
+
+// access-токен перестаёт приниматься, когда версия сессий пользователя меняется
+func (suite *AuthTestSuite) TestAuthInterceptor_SessionVersion() {
+	repo := &sessionRepo{versions: map[string]int64{"user123": 0}}
+	interceptor := AuthInterceptor(suite.cfg, repo)
+	info := &grpc.UnaryServerInfo{FullMethod: "/keeper.KeeperService/GetData"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		userID, _ := GetUserID(ctx)
+		return userID, nil
+	}
+	call := func(token string) (interface{}, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+		return interceptor(ctx, nil, info, handler)
+	}
+
+	oldToken, err := GenerateToken(suite.cfg, "user123", 0)
+	require.NoError(suite.T(), err)
+
+	userID, err := call(oldToken)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "user123", userID)
+
+	// смена мастер-пароля
+	repo.versions["user123"] = 1
+
+	_, err = call(oldToken)
+	assert.Equal(suite.T(), codes.Unauthenticated, status.Code(err))
+
+	newToken, err := GenerateToken(suite.cfg, "user123", 1)
+	require.NoError(suite.T(), err)
+	_, err = call(newToken)
+	require.NoError(suite.T(), err)
+
+	unknown, err := GenerateToken(suite.cfg, "deleted-user", 0)
+	require.NoError(suite.T(), err)
+	_, err = call(unknown)
+	assert.Equal(suite.T(), codes.Unauthenticated, status.Code(err))
+}
@@ -4,6 +4,8 @@ package auth
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"strings"
 
 	"github.com/dvkhr/gophkeeper/pkg/logger"
@@ -20,7 +22,7 @@ import (
 // Для остальных методов:
 // - извлекает Bearer-токен,
 // - проверяет его валидность,
-// - проверяет, не отозван ли он,
+// - проверяет, что версия сессий пользователя не изменилась после выдачи токена (смена мастер-пароля),
 // - добавляет userID в контекст.
 func AuthInterceptor(cfg config.Config, repo repository.TokenRepository) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
@@ -31,37 +33,85 @@ func AuthInterceptor(cfg config.Config, repo repository.TokenRepository) grpc.Un
 			return handler(ctx, req)
 		}
 
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			logger.Logg.Warn("Metadata not provided")
-
-			return nil, status.Errorf(codes.Unauthenticated, "metadata not provided")
+		ctx, err := authenticate(ctx, cfg, repo)
+		if err != nil {
+			return nil, err
 		}
 
-		values := md["authorization"]
-		if len(values) == 0 {
-			logger.Logg.Warn("Authorization header not provided")
+		return handler(ctx, req)
+	}
+}
 
-			return nil, status.Errorf(codes.Unauthenticated, "authorization not provided")
+// AuthStreamInterceptor — аналог AuthInterceptor для потоковых методов.
+// Проверяет токен при открытии потока и передаёт обработчику поток, контекст которого содержит userID.
+func AuthStreamInterceptor(cfg config.Config, repo repository.TokenRepository) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), cfg, repo)
+		if err != nil {
+			return err
 		}
 
-		tokenStr := strings.TrimPrefix(values[0], "Bearer ")
-		if tokenStr == "" {
-			logger.Logg.Warn("Empty token")
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
 
-			return nil, status.Errorf(codes.Unauthenticated, "empty token")
-		}
+// authenticate проверяет Bearer-токен из метаданных запроса и возвращает контекст с userID.
+func authenticate(ctx context.Context, cfg config.Config, repo repository.TokenRepository) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		logger.Logg.Warn("Metadata not provided")
 
-		claims, err := ParseToken(cfg, tokenStr)
-		if err != nil {
-			logger.Logg.Warn("Invalid token", "error", err)
+		return nil, status.Errorf(codes.Unauthenticated, "metadata not provided")
+	}
 
-			return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
-		}
+	values := md["authorization"]
+	if len(values) == 0 {
+		logger.Logg.Warn("Authorization header not provided")
 
-		ctx = WithUserID(ctx, claims.UserID)
-		logger.Logg.Debug("User ID установлен в контекст", "user_id", claims.UserID)
+		return nil, status.Errorf(codes.Unauthenticated, "authorization not provided")
+	}
 
-		return handler(ctx, req)
+	tokenStr := strings.TrimPrefix(values[0], "Bearer ")
+	if tokenStr == "" {
+		logger.Logg.Warn("Empty token")
+
+		return nil, status.Errorf(codes.Unauthenticated, "empty token")
 	}
+
+	claims, err := ParseToken(cfg, tokenStr)
+	if err != nil {
+		logger.Logg.Warn("Invalid token", "error", err)
+
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+
+	version, err := repo.GetSessionVersion(claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Logg.Warn("Token for unknown user", "user_id", claims.UserID)
+
+		return nil, status.Errorf(codes.Unauthenticated, "user not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check session")
+	}
+	if version != claims.SessionVersion {
+		logger.Logg.Info("Stale session", "user_id", claims.UserID, "token_version", claims.SessionVersion, "version", version)
+
+		return nil, status.Errorf(codes.Unauthenticated, "session expired, please log in again")
+	}
+
+	ctx = WithUserID(ctx, claims.UserID)
+	logger.Logg.Debug("User ID установлен в контекст", "user_id", claims.UserID)
+
+	return ctx, nil
+}
+
+// authStream подменяет контекст потока контекстом с userID.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
 }
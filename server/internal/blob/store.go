@@ -0,0 +1,264 @@
+// Package blob хранит зашифрованное содержимое бинарных записей в файловой системе.
+//
+// Содержимое записи лежит в файле <dir>/<sha256(userID)>/<sha256(recordID)>,
+// незавершённые загрузки — рядом, с суффиксом .<uploadID>.part, а прежнее содержимое
+// на время сохранения новой версии в базе — с суффиксом .prev.
+// Имена файлов строятся из хэшей, поэтому произвольные ID записей
+// не могут выйти за пределы каталога хранилища.
+//
+// Commit, Rollback, Release и Delete одной записи вызываются под Lock, чтобы
+// параллельные загрузки не переставили друг другу текущее и прежнее содержимое.
+package blob
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+var (
+	// ErrOffsetMismatch возвращается, если смещение загрузки не совпадает с размером принятой части.
+	ErrOffsetMismatch = errors.New("upload offset does not match received size")
+
+	// ErrHashMismatch возвращается, если SHA-256 принятого шифротекста не совпал с заявленным клиентом.
+	ErrHashMismatch = errors.New("sha256 mismatch")
+
+	// ErrInvalidUploadID возвращается для идентификатора загрузки недопустимого формата.
+	ErrInvalidUploadID = errors.New("invalid upload id")
+
+	// ErrNotFound возвращается, если содержимое записи отсутствует.
+	ErrNotFound = errors.New("blob not found")
+)
+
+var uploadIDPattern = regexp.MustCompile(`^[0-9a-f]{16,64}$`)
+
+// Store — файловое хранилище содержимого бинарных записей.
+type Store struct {
+	dir string
+
+	mu    sync.Mutex
+	locks map[string]*recordLock
+}
+
+// recordLock — блокировка содержимого одной записи со счётчиком ожидающих её запросов.
+type recordLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewStore создаёт хранилище в каталоге dir, создавая его при необходимости.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create blob dir: %w", err)
+	}
+	return &Store{dir: dir, locks: make(map[string]*recordLock)}, nil
+}
+
+// Lock блокирует содержимое записи recordID пользователя userID и возвращает функцию снятия блокировки.
+// Блокировка удерживается от Commit до Rollback или Release, включая сохранение записи в базе.
+func (s *Store) Lock(userID, recordID string) func() {
+	key := s.recordPath(userID, recordID)
+
+	s.mu.Lock()
+	l, ok := s.locks[key]
+	if !ok {
+		l = &recordLock{}
+		s.locks[key] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		s.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(s.locks, key)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// PartialSize возвращает размер принятой части загрузки uploadID или 0, если её нет.
+func (s *Store) PartialSize(userID, recordID, uploadID string) (int64, error) {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return 0, ErrInvalidUploadID
+	}
+
+	info, err := os.Stat(s.partPath(userID, recordID, uploadID))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat partial upload: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// OpenPartial открывает незавершённую загрузку для дозаписи с позиции offset.
+// Смещение должно совпадать с размером уже принятой части.
+// Незавершённые загрузки той же записи с другими идентификаторами удаляются.
+func (s *Store) OpenPartial(userID, recordID, uploadID string, offset int64) (*os.File, error) {
+	size, err := s.PartialSize(userID, recordID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if size != offset {
+		return nil, fmt.Errorf("%w: offset %d, received %d", ErrOffsetMismatch, offset, size)
+	}
+
+	if err := os.MkdirAll(s.userDir(userID), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create user blob dir: %w", err)
+	}
+
+	partPath := s.partPath(userID, recordID, uploadID)
+	others, _ := filepath.Glob(s.recordPath(userID, recordID) + ".*.part")
+	for _, other := range others {
+		if other != partPath {
+			_ = os.Remove(other)
+		}
+	}
+
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open partial upload: %w", err)
+	}
+	return f, nil
+}
+
+// Verify проверяет, что SHA-256 принятой части загрузки равен expected.
+// При несовпадении принятая часть удаляется, чтобы клиент начал загрузку заново.
+func (s *Store) Verify(userID, recordID, uploadID string, expected []byte) (int64, error) {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return 0, ErrInvalidUploadID
+	}
+
+	partPath := s.partPath(userID, recordID, uploadID)
+
+	f, err := os.Open(partPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open partial upload: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hash partial upload: %w", err)
+	}
+
+	if !bytes.Equal(hash.Sum(nil), expected) {
+		_ = os.Remove(partPath)
+		return 0, ErrHashMismatch
+	}
+	return size, nil
+}
+
+// Commit делает проверенную загрузку текущим содержимым записи.
+// Прежнее содержимое сохраняется рядом с суффиксом .prev, пока изменение не будет
+// подтверждено Release или отменено Rollback.
+func (s *Store) Commit(userID, recordID, uploadID string) error {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return ErrInvalidUploadID
+	}
+
+	path := s.recordPath(userID, recordID)
+	_ = os.Remove(s.prevPath(userID, recordID))
+	if err := os.Rename(path, s.prevPath(userID, recordID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to keep previous blob: %w", err)
+	}
+
+	if err := os.Rename(s.partPath(userID, recordID, uploadID), path); err != nil {
+		_ = os.Rename(s.prevPath(userID, recordID), path)
+		return fmt.Errorf("failed to commit upload: %w", err)
+	}
+	return nil
+}
+
+// Rollback отменяет Commit: содержимое снова становится незавершённой загрузкой uploadID,
+// а прежнее содержимое записи восстанавливается.
+func (s *Store) Rollback(userID, recordID, uploadID string) error {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return ErrInvalidUploadID
+	}
+
+	path := s.recordPath(userID, recordID)
+	if err := os.Rename(path, s.partPath(userID, recordID, uploadID)); err != nil {
+		return fmt.Errorf("failed to roll back upload: %w", err)
+	}
+	if err := os.Rename(s.prevPath(userID, recordID), path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to restore previous blob: %w", err)
+	}
+	return nil
+}
+
+// Release удаляет прежнее содержимое записи, сохранённое Commit.
+func (s *Store) Release(userID, recordID string) error {
+	if err := os.Remove(s.prevPath(userID, recordID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove previous blob: %w", err)
+	}
+	return nil
+}
+
+// Open открывает содержимое записи для чтения.
+func (s *Store) Open(userID, recordID string) (*os.File, error) {
+	f, err := os.Open(s.recordPath(userID, recordID))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return f, nil
+}
+
+// Delete удаляет содержимое записи и все её незавершённые загрузки.
+func (s *Store) Delete(userID, recordID string) error {
+	path := s.recordPath(userID, recordID)
+
+	parts, _ := filepath.Glob(path + ".*.part")
+	for _, part := range parts {
+		_ = os.Remove(part)
+	}
+	_ = os.Remove(s.prevPath(userID, recordID))
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+// userDir возвращает каталог содержимого записей пользователя.
+func (s *Store) userDir(userID string) string {
+	return filepath.Join(s.dir, hashName(userID))
+}
+
+// recordPath возвращает путь к содержимому записи.
+func (s *Store) recordPath(userID, recordID string) string {
+	return filepath.Join(s.userDir(userID), hashName(recordID))
+}
+
+// partPath возвращает путь к незавершённой загрузке.
+func (s *Store) partPath(userID, recordID, uploadID string) string {
+	return s.recordPath(userID, recordID) + "." + uploadID + ".part"
+}
+
+// prevPath возвращает путь к прежнему содержимому записи, сохранённому Commit.
+func (s *Store) prevPath(userID, recordID string) string {
+	return s.recordPath(userID, recordID) + ".prev"
+}
+
+// hashName возвращает безопасное имя файла для произвольной строки.
+func hashName(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
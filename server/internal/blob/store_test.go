@@ -0,0 +1,189 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUploadID = "0123456789abcdef"
+
+func writePart(t *testing.T, s *Store, offset int64, data string) {
+	t.Helper()
+
+	f, err := s.OpenPartial("user", "record", testUploadID, offset)
+	require.NoError(t, err)
+	_, err = f.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestStore_ResumeAndCommit(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	size, err := s.PartialSize("user", "record", testUploadID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), size)
+
+	writePart(t, s, 0, "hello, ")
+
+	_, err = s.OpenPartial("user", "record", testUploadID, 3)
+	assert.ErrorIs(t, err, ErrOffsetMismatch)
+
+	writePart(t, s, 7, "world")
+
+	sum := sha256.Sum256([]byte("hello, world"))
+	size, err = s.Verify("user", "record", testUploadID, sum[:])
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), size)
+
+	require.NoError(t, s.Commit("user", "record", testUploadID))
+
+	f, err := s.Open("user", "record")
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "hello, world", string(data))
+
+	// Другой пользователь не видит содержимое записи с тем же ID
+	_, err = s.Open("other", "record")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func readBlob(t *testing.T, s *Store) string {
+	t.Helper()
+
+	f, err := s.Open("user", "record")
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestStore_CommitRollbackRelease(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	writePart(t, s, 0, "v1")
+	require.NoError(t, s.Commit("user", "record", testUploadID))
+	require.NoError(t, s.Release("user", "record"))
+
+	// Откат возвращает прежнее содержимое и оставляет загрузку для повтора
+	writePart(t, s, 0, "v2")
+	require.NoError(t, s.Commit("user", "record", testUploadID))
+	assert.Equal(t, "v2", readBlob(t, s))
+
+	require.NoError(t, s.Rollback("user", "record", testUploadID))
+	assert.Equal(t, "v1", readBlob(t, s))
+	size, err := s.PartialSize("user", "record", testUploadID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), size)
+
+	// Подтверждённое изменение удаляет прежнее содержимое
+	require.NoError(t, s.Commit("user", "record", testUploadID))
+	require.NoError(t, s.Release("user", "record"))
+	assert.Equal(t, "v2", readBlob(t, s))
+	assert.NoFileExists(t, s.prevPath("user", "record"))
+
+	// Откат первой загрузки записи удаляет её содержимое
+	f, err := s.OpenPartial("user", "fresh", testUploadID, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, s.Commit("user", "fresh", testUploadID))
+	require.NoError(t, s.Rollback("user", "fresh", testUploadID))
+	_, err = s.Open("user", "fresh")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// Параллельные загрузки одной записи: одна не сохраняется в базе и откатывается,
+// другая подтверждается. Текущим содержимым должна остаться подтверждённая версия.
+func TestStore_ConcurrentUploads(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	writePart(t, s, 0, "v0")
+	require.NoError(t, s.Commit("user", "record", testUploadID))
+	require.NoError(t, s.Release("user", "record"))
+
+	for i := 0; i < 20; i++ {
+		var saved string
+		upload := func(uploadID, content string, ok bool) {
+			unlock := s.Lock("user", "record")
+			defer unlock()
+
+			if !assert.NoError(t, os.WriteFile(s.partPath("user", "record", uploadID), []byte(content), 0600)) ||
+				!assert.NoError(t, s.Commit("user", "record", uploadID)) {
+				return
+			}
+			time.Sleep(time.Millisecond) // сохранение записи в базе
+			if !ok {
+				assert.NoError(t, s.Rollback("user", "record", uploadID))
+				return
+			}
+			saved = content
+			assert.NoError(t, s.Release("user", "record"))
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() { defer wg.Done(); upload("aaaaaaaaaaaaaaaa", "failed", false) }()
+		go func() { defer wg.Done(); upload("bbbbbbbbbbbbbbbb", "saved", true) }()
+		wg.Wait()
+
+		assert.Equal(t, saved, readBlob(t, s))
+		assert.NoFileExists(t, s.prevPath("user", "record"))
+	}
+	assert.Empty(t, s.locks)
+}
+
+func TestStore_HashMismatchDiscardsUpload(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	writePart(t, s, 0, "payload")
+
+	sum := sha256.Sum256([]byte("other payload"))
+	_, err = s.Verify("user", "record", testUploadID, sum[:])
+	assert.ErrorIs(t, err, ErrHashMismatch)
+
+	size, err := s.PartialSize("user", "record", testUploadID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), size)
+}
+
+func TestStore_InvalidUploadID(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = s.OpenPartial("user", "record", "../../etc/passwd", 0)
+	assert.ErrorIs(t, err, ErrInvalidUploadID)
+
+	_, err = s.PartialSize("user", "record", "short")
+	assert.ErrorIs(t, err, ErrInvalidUploadID)
+}
+
+func TestStore_Delete(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	writePart(t, s, 0, "data")
+	sum := sha256.Sum256([]byte("data"))
+	_, err = s.Verify("user", "record", testUploadID, sum[:])
+	require.NoError(t, err)
+	require.NoError(t, s.Commit("user", "record", testUploadID))
+
+	require.NoError(t, s.Delete("user", "record"))
+	require.NoError(t, s.Delete("user", "record"))
+
+	_, err = s.Open("user", "record")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -16,6 +16,24 @@ type AuthConfig struct {
 	RefreshTokenTTLDays int    `yaml:"refresh_token_ttl_days"`
 }
 
+// DataConfig — конфигурация хранения пользовательских данных
+//
+// Удалённая запись хранится в корзине trash_retention_hours часов и затем удаляется окончательно.
+// Запись в корзине одновременно служит tombstone для синхронизации, поэтому tombstones живут
+// столько же; клиент, не синхронизировавшийся дольше этого срока, получает полный снимок данных.
+type DataConfig struct {
+	TrashRetentionHours     int `yaml:"trash_retention_hours"`     // Сколько часов хранить удалённые записи в корзине
+	TombstoneRetentionHours int `yaml:"tombstone_retention_hours"` // Прежнее имя trash_retention_hours, используется, если оно не задано
+	PurgeIntervalMinutes    int `yaml:"purge_interval_minutes"`
+	HistoryVersions         int `yaml:"history_versions"`       // Сколько прежних версий хранить у каждой записи
+	HistoryRetentionDays    int `yaml:"history_retention_days"` // Сколько дней хранить прежние версии
+}
+
+// StorageConfig — конфигурация файлового хранилища содержимого бинарных записей
+type StorageConfig struct {
+	BlobDir string `yaml:"blob_dir"`
+}
+
 // Config — основная структура конфигурации приложения
 type Config struct {
 	Server struct {
@@ -28,6 +46,10 @@ type Config struct {
 	} `yaml:"database"`
 
 	Auth AuthConfig `yaml:"auth"`
+
+	Data DataConfig `yaml:"data"`
+
+	Storage StorageConfig `yaml:"storage"`
 }
 
 // Load загружает конфигурацию из указанного YAML-файла
@@ -5,6 +5,7 @@ import (
 	"embed"
 	"fmt"
 	"io/fs"
+	"strings"
 
 	"github.com/dvkhr/gophkeeper/pkg/logger"
 )
@@ -13,6 +14,7 @@ import (
 var migrationFiles embed.FS
 
 // ApplyMigrations применяет SQL-миграции из embed.FS.
+// Применяются только файлы *.up.sql в порядке их имён; *.down.sql предназначены для ручного отката.
 //
 // Принимает:
 //   - db *sql.DB — открытое соединение с базой данных.
@@ -34,6 +36,9 @@ func ApplyMigrations(db *sql.DB) error {
 		}
 
 		migrationName := file.Name()
+		if !strings.HasSuffix(migrationName, ".up.sql") {
+			continue
+		}
 		logger.Logg.Info("Applying migration", "name", migrationName)
 
 		content, err := fs.ReadFile(migrationFiles, "migrations/"+migrationName)
@@ -40,3 +40,21 @@ func TestApplyMigrations_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, exists)
 }
+
+// TestApplyMigrations_Idempotent — повторное применение миграций не удаляет данные
+func TestApplyMigrations_Idempotent(t *testing.T) {
+	dbConn := setupTestDB(t)
+	defer dbConn.Close()
+
+	require.NoError(t, db.ApplyMigrations(dbConn))
+
+	_, err := dbConn.Exec(`INSERT INTO users (login, password_hash) VALUES ('keep', 'hash')`)
+	require.NoError(t, err)
+
+	require.NoError(t, db.ApplyMigrations(dbConn))
+
+	var count int
+	err = dbConn.QueryRow(`SELECT COUNT(*) FROM users WHERE login = 'keep'`).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,3 @@
+-- migrations/0002_revisions.down.sql
+
+ALTER TABLE user_data DROP COLUMN IF EXISTS revision;
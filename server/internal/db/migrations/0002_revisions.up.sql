@@ -0,0 +1,3 @@
+-- 0002_revisions.up.sql
+
+ALTER TABLE user_data ADD COLUMN IF NOT EXISTS revision BIGINT NOT NULL DEFAULT 1;
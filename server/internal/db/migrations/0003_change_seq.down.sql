@@ -0,0 +1,5 @@
+-- migrations/0003_change_seq.down.sql
+
+DROP INDEX IF EXISTS idx_user_data_user_change_seq;
+ALTER TABLE user_data DROP COLUMN IF EXISTS change_seq;
+DROP SEQUENCE IF EXISTS user_data_change_seq;
@@ -0,0 +1,7 @@
+-- 0003_change_seq.up.sql
+
+CREATE SEQUENCE IF NOT EXISTS user_data_change_seq;
+
+ALTER TABLE user_data ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT nextval('user_data_change_seq');
+
+CREATE INDEX IF NOT EXISTS idx_user_data_user_change_seq ON user_data(user_id, change_seq);
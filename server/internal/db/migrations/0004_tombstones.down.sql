@@ -0,0 +1,5 @@
+-- migrations/0004_tombstones.down.sql
+
+ALTER TABLE users DROP COLUMN IF EXISTS purged_seq;
+DROP INDEX IF EXISTS idx_user_data_deleted_at;
+ALTER TABLE user_data DROP COLUMN IF EXISTS deleted_at;
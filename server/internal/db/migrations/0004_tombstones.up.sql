@@ -0,0 +1,11 @@
+-- 0004_tombstones.up.sql
+
+ALTER TABLE user_data ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+UPDATE user_data SET deleted_at = updated_at WHERE deleted AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_user_data_deleted_at ON user_data(deleted_at) WHERE deleted;
+
+-- Максимальный change_seq удалённых навсегда записей пользователя.
+-- Клиент с курсором меньше этого значения мог пропустить удаление и должен получить полный снимок.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purged_seq BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- migrations/0005_blobs.down.sql
+
+ALTER TABLE user_data DROP COLUMN IF EXISTS blob_sha256;
+ALTER TABLE user_data DROP COLUMN IF EXISTS blob_size;
@@ -0,0 +1,4 @@
+-- 0005_blobs.up.sql
+
+ALTER TABLE user_data ADD COLUMN IF NOT EXISTS blob_size BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE user_data ADD COLUMN IF NOT EXISTS blob_sha256 BYTEA;
@@ -0,0 +1,6 @@
+-- migrations/0006_vault_keys.down.sql
+
+ALTER TABLE users DROP COLUMN IF EXISTS vault_key_version;
+ALTER TABLE users DROP COLUMN IF EXISTS vault_key_kdf;
+ALTER TABLE users DROP COLUMN IF EXISTS vault_key_salt;
+ALTER TABLE users DROP COLUMN IF EXISTS vault_key;
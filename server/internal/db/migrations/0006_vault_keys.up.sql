@@ -0,0 +1,7 @@
+-- 0006_vault_keys.up.sql
+
+-- Ключ хранилища пользователя, зашифрованный мастер-ключом, и параметры получения мастер-ключа.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS vault_key BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS vault_key_salt BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS vault_key_kdf JSONB;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS vault_key_version BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- migrations/0007_encrypted_metadata.down.sql
+
+DROP INDEX IF EXISTS idx_user_data_blind_indexes;
+ALTER TABLE user_data DROP COLUMN IF EXISTS blind_indexes;
+ALTER TABLE user_data DROP COLUMN IF EXISTS encrypted_metadata;
@@ -0,0 +1,8 @@
+-- 0007_encrypted_metadata.up.sql
+
+-- Метаданные, зашифрованные на клиенте, и слепые индексы для поиска по ним.
+-- Колонка metadata остаётся для записей, сохранённых до шифрования метаданных.
+ALTER TABLE user_data ADD COLUMN IF NOT EXISTS encrypted_metadata BYTEA;
+ALTER TABLE user_data ADD COLUMN IF NOT EXISTS blind_indexes BYTEA[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_user_data_blind_indexes ON user_data USING GIN (blind_indexes);
@@ -0,0 +1,12 @@
+-- migrations/0008_data_type_text.down.sql
+
+-- Записи типов, которых нет в перечислении, нужно удалить до отката.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'data_type') THEN
+        CREATE TYPE data_type AS ENUM ('loginpass', 'text', 'binary', 'card');
+    END IF;
+END
+$$;
+
+ALTER TABLE user_data ALTER COLUMN type TYPE data_type USING type::data_type;
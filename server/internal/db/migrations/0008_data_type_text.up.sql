@@ -0,0 +1,7 @@
+-- 0008_data_type_text.up.sql
+
+-- Тип записи хранится как строка: набор типов задаёт реестр на клиенте,
+-- сервер проверяет только формат имени. Новые типы не требуют изменения схемы.
+ALTER TABLE user_data ALTER COLUMN type TYPE TEXT USING type::text;
+
+DROP TYPE IF EXISTS data_type;
@@ -0,0 +1,5 @@
+-- migrations/0009_data_history.down.sql
+
+DROP TRIGGER IF EXISTS user_data_archive_version ON user_data;
+DROP FUNCTION IF EXISTS archive_user_data_version();
+DROP TABLE IF EXISTS user_data_history;
@@ -0,0 +1,44 @@
+-- 0009_data_history.up.sql
+
+-- Прежние версии записей. Версия попадает в историю триггером при любом изменении содержимого записи,
+-- поэтому её сохраняют все пути записи: StoreData, SyncData, UploadBinary и RestoreVersion.
+-- Содержимое бинарных записей хранится только в последней версии, в истории — лишь его размер и хэш.
+CREATE TABLE IF NOT EXISTS user_data_history (
+    id TEXT NOT NULL REFERENCES user_data(id) ON DELETE CASCADE,
+    revision BIGINT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    encrypted_data BYTEA NOT NULL,
+    metadata JSONB,
+    encrypted_metadata BYTEA,
+    blind_indexes BYTEA[] NOT NULL DEFAULT '{}',
+    blob_size BIGINT NOT NULL DEFAULT 0,
+    blob_sha256 BYTEA,
+    updated_at TIMESTAMP,
+    replaced_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id, revision)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_data_history_replaced_at ON user_data_history(replaced_at);
+
+CREATE OR REPLACE FUNCTION archive_user_data_version() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO user_data_history (id, revision, user_id, type, encrypted_data, metadata, encrypted_metadata,
+                                   blind_indexes, blob_size, blob_sha256, updated_at)
+    VALUES (OLD.id, OLD.revision, OLD.user_id, OLD.type, OLD.encrypted_data, OLD.metadata, OLD.encrypted_metadata,
+            OLD.blind_indexes, OLD.blob_size, OLD.blob_sha256, OLD.updated_at)
+    ON CONFLICT (id, revision) DO NOTHING;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS user_data_archive_version ON user_data;
+CREATE TRIGGER user_data_archive_version
+    BEFORE UPDATE ON user_data
+    FOR EACH ROW
+    WHEN (OLD.type IS DISTINCT FROM NEW.type
+       OR OLD.encrypted_data IS DISTINCT FROM NEW.encrypted_data
+       OR OLD.metadata IS DISTINCT FROM NEW.metadata
+       OR OLD.encrypted_metadata IS DISTINCT FROM NEW.encrypted_metadata
+       OR OLD.blob_sha256 IS DISTINCT FROM NEW.blob_sha256)
+    EXECUTE FUNCTION archive_user_data_version();
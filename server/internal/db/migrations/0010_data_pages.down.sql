@@ -0,0 +1,4 @@
+-- migrations/0010_data_pages.down.sql
+
+DROP INDEX IF EXISTS idx_user_data_user_type_page;
+DROP INDEX IF EXISTS idx_user_data_user_page;
@@ -0,0 +1,9 @@
+-- 0010_data_pages.up.sql
+
+-- Индексы для постраничной выдачи GetData по ключу (updated_at, id):
+-- всех записей пользователя и записей одного типа.
+CREATE INDEX IF NOT EXISTS idx_user_data_user_page
+    ON user_data (user_id, updated_at DESC, id DESC) WHERE deleted = FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_user_data_user_type_page
+    ON user_data (user_id, type, updated_at DESC, id DESC) WHERE deleted = FALSE;
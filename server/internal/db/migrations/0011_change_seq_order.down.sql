@@ -0,0 +1,5 @@
+-- migrations/0011_change_seq_order.down.sql
+
+DROP TRIGGER IF EXISTS user_data_change_seq_update ON user_data;
+DROP TRIGGER IF EXISTS user_data_change_seq_insert ON user_data;
+DROP FUNCTION IF EXISTS assign_user_data_change_seq();
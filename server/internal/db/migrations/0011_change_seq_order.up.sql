@@ -0,0 +1,26 @@
+-- 0011_change_seq_order.up.sql
+
+-- change_seq выдаётся под блокировкой пользователя, которая держится до конца транзакции.
+-- Без неё транзакция с меньшим change_seq могла зафиксироваться позже транзакции с большим,
+-- и клиент, уже сдвинувший курсор за больший номер, навсегда пропускал бы это изменение.
+-- Запросы по-прежнему пишут change_seq = nextval(...): триггер заменяет номер на выданный после блокировки.
+CREATE OR REPLACE FUNCTION assign_user_data_change_seq() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtextextended('user_data_change_seq:' || NEW.user_id, 0));
+    NEW.change_seq := nextval('user_data_change_seq');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS user_data_change_seq_insert ON user_data;
+CREATE TRIGGER user_data_change_seq_insert
+    BEFORE INSERT ON user_data
+    FOR EACH ROW
+    EXECUTE FUNCTION assign_user_data_change_seq();
+
+DROP TRIGGER IF EXISTS user_data_change_seq_update ON user_data;
+CREATE TRIGGER user_data_change_seq_update
+    BEFORE UPDATE ON user_data
+    FOR EACH ROW
+    WHEN (OLD.change_seq IS DISTINCT FROM NEW.change_seq)
+    EXECUTE FUNCTION assign_user_data_change_seq();
@@ -0,0 +1,3 @@
+-- migrations/0012_session_version.down.sql
+
+ALTER TABLE users DROP COLUMN IF EXISTS session_version;
@@ -0,0 +1,5 @@
+-- 0012_session_version.up.sql
+
+-- Версия сессий пользователя. Она записывается в access-токен и увеличивается при смене
+-- мастер-пароля, после чего ранее выданные access-токены перестают приниматься.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS session_version BIGINT NOT NULL DEFAULT 0;
@@ -4,27 +4,124 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/dvkhr/gophkeeper/pb"
 )
 
 var _ DataRepository = (*PostgresDataRepository)(nil)
 
+// ErrRevisionConflict возвращается, когда базовая ревизия клиента не совпадает с ревизией записи на сервере.
+var ErrRevisionConflict = errors.New("revision conflict")
+
+var (
+	// ErrIDNotAvailable возвращается, если ID записи уже занят записью другого пользователя.
+	ErrIDNotAvailable = errors.New("record ID is not available")
+	// ErrNotDeleted возвращается, если восстанавливаемой записи нет в корзине.
+	ErrNotDeleted = errors.New("record is not deleted")
+	// ErrVersionNotFound возвращается, если у записи нет версии с указанной ревизией.
+	ErrVersionNotFound = errors.New("version not found")
+	// ErrVersionUnavailable возвращается при восстановлении версии бинарной записи,
+	// содержимое которой уже заменено более новым.
+	ErrVersionUnavailable = errors.New("binary content of this version is no longer stored")
+)
+
 // DataRepository — интерфейс для работы с данными пользователя в базе данных.
 type DataRepository interface {
 	// SaveData сохраняет или обновляет запись пользователя в базе данных.
 	SaveData(userID string, data *pb.DataRecord) error
 
+	// SaveDataWithRevision сохраняет запись, только если её ревизия на сервере равна baseRevision.
+	// baseRevision == 0 означает создание новой (или повторное создание удалённой) записи.
+	// Возвращает новую ревизию записи; при конфликте — ErrRevisionConflict и текущую ревизию сервера.
+	// Если ID занят записью другого пользователя, возвращает ErrIDNotAvailable.
+	SaveDataWithRevision(userID string, data *pb.DataRecord, baseRevision int64) (int64, error)
+
 	// GetAllData возвращает все неудалённые данные пользователя.
 	// Данные возвращаются в порядке убывания времени обновления.
 	GetAllData(userID string) ([]*pb.DataRecord, error)
 
+	// GetDataByBlindIndexes возвращает неудалённые записи пользователя, у которых есть все указанные слепые индексы.
+	// Данные возвращаются в порядке убывания времени обновления.
+	GetDataByBlindIndexes(userID string, indexes [][]byte) ([]*pb.DataRecord, error)
+
+	// GetByID возвращает неудалённую запись id, принадлежащую пользователю.
+	// Возвращает sql.ErrNoRows, если записи нет, она удалена или принадлежит другому пользователю.
+	GetByID(userID, id string) (*pb.DataRecord, error)
+
+	// GetDataPage возвращает страницу неудалённых записей пользователя, подходящих под фильтр.
+	// Данные возвращаются в порядке убывания времени обновления, при равном времени — убывания ID.
+	GetDataPage(userID string, filter DataFilter) (*DataPage, error)
+
+	// GetDataChangedSince возвращает записи пользователя, изменённые или удалённые после курсора.
+	// Курсор — значение последовательности изменений; 0 означает получение всех неудалённых записей.
+	// Если tombstones после курсора уже удалены очисткой, возвращается полный снимок с признаком Reset.
+	GetDataChangedSince(userID string, cursor int64) (*DataChanges, error)
+
+	// PurgeDeletedData окончательно удаляет записи, помеченные удалёнными раньше olderThan.
+	// Возвращает ключи удалённых записей, чтобы вызывающий мог освободить связанные с ними ресурсы.
+	PurgeDeletedData(olderThan time.Time) ([]RecordKey, error)
+
+	// SaveBinaryData сохраняет запись, содержимое которой загружено через UploadBinary,
+	// вместе с размером и SHA-256 шифротекста. Ревизия проверяется так же, как в SaveDataWithRevision.
+	SaveBinaryData(userID string, data *pb.DataRecord, baseRevision int64) (int64, error)
+
+	// GetBlobInfo возвращает размер и SHA-256 загруженного содержимого неудалённой записи пользователя.
+	// Возвращает sql.ErrNoRows, если записи нет или у неё нет загруженного содержимого.
+	GetBlobInfo(userID, id string) (int64, []byte, error)
+
 	// DataExistsForUser проверяет, принадлежит ли запись пользователю
 	DataExistsForUser(id, userID string) (bool, error)
 
 	// MarkDataAsDeleted помечает запись как удаленную
 	MarkDataAsDeleted(id string) error
+
+	// MarkDataAsDeletedWithRevision помечает запись пользователя удалённой, если её ревизия равна baseRevision.
+	// Удаление уже удалённой или несуществующей записи не считается ошибкой.
+	// При несовпадении ревизии возвращает ErrRevisionConflict и текущую ревизию сервера.
+	MarkDataAsDeletedWithRevision(userID, id string, baseRevision int64) (int64, error)
+
+	// GetDeletedData возвращает удалённые, но ещё не удалённые окончательно записи пользователя
+	// в порядке убывания времени удаления.
+	GetDeletedData(userID string) ([]*DeletedRecord, error)
+
+	// UndeleteData снимает с записи пользователя отметку об удалении.
+	// Возвращает новую ревизию; ErrNotDeleted, если записи нет или она не удалена.
+	UndeleteData(userID, id string) (int64, error)
+
+	// ListDataVersions возвращает прежние версии записи пользователя, начиная с самой новой.
+	ListDataVersions(userID, id string) ([]*pb.RecordVersion, error)
+
+	// RestoreDataVersion делает версию revision текущей версией записи, если ревизия записи
+	// равна baseRevision (0 — без проверки). Удалённая запись восстанавливается.
+	// Возвращает новую ревизию; при конфликте — ErrRevisionConflict и текущую ревизию сервера.
+	RestoreDataVersion(userID, id string, revision, baseRevision int64) (int64, error)
+
+	// PurgeDataHistory удаляет версии, заменённые раньше olderThan, и версии сверх keep последних у каждой записи.
+	// Возвращает число удалённых версий.
+	PurgeDataHistory(keep int, olderThan time.Time) (int64, error)
+}
+
+// DataChanges — изменения данных пользователя после курсора синхронизации.
+type DataChanges struct {
+	Records    []*pb.DataRecord // Созданные или изменённые записи
+	Tombstones []*pb.Tombstone  // Удалённые записи
+	Cursor     int64            // Курсор для следующей синхронизации
+	Reset      bool             // Курсор устарел, возвращён полный снимок данных
+}
+
+// DeletedRecord — запись, помеченная удалённой.
+type DeletedRecord struct {
+	Record    *pb.DataRecord
+	DeletedAt time.Time
+}
+
+// RecordKey идентифицирует запись пользователя.
+type RecordKey struct {
+	UserID string
+	ID     string
 }
 
 // PostgresDataRepository — реализация DataRepository для PostgreSQL.
@@ -40,16 +137,21 @@ func NewDataRepository(db *sql.DB) DataRepository {
 // SaveData сохраняет или обновляет запись пользователя в базе данных.
 func (r *PostgresDataRepository) SaveData(userID string, data *pb.DataRecord) error {
 	_, err := r.db.ExecContext(context.Background(),
-		`INSERT INTO user_data (id, user_id, type, encrypted_data, metadata)
-         VALUES ($1, $2, $3, $4, $5)
+		`INSERT INTO user_data (id, user_id, type, encrypted_data, metadata, encrypted_metadata, blind_indexes)
+         VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7::bytea[], '{}'))
          ON CONFLICT (id) DO UPDATE SET
     		 user_id = EXCLUDED.user_id,
              type = EXCLUDED.type,
              encrypted_data = EXCLUDED.encrypted_data,
              metadata = EXCLUDED.metadata,
+             encrypted_metadata = EXCLUDED.encrypted_metadata,
+             blind_indexes = EXCLUDED.blind_indexes,
              deleted = FALSE,
+             deleted_at = NULL,
+             revision = user_data.revision + 1,
+             change_seq = nextval('user_data_change_seq'),
              updated_at = NOW()`,
-		data.Id, userID, data.Type, data.EncryptedData, data.Metadata)
+		data.Id, userID, data.Type, data.EncryptedData, data.Metadata, data.EncryptedMetadata, data.BlindIndexes)
 
 	if err != nil {
 		return fmt.Errorf("failed to save data: %w", err)
@@ -57,24 +159,133 @@ func (r *PostgresDataRepository) SaveData(userID string, data *pb.DataRecord) er
 	return nil
 }
 
+// SaveDataWithRevision сохраняет запись с проверкой базовой ревизии клиента.
+// Обновление выполняется одним запросом: строка меняется, только если она принадлежит
+// пользователю и её ревизия равна baseRevision (или она удалена, а клиент создаёт запись заново).
+func (r *PostgresDataRepository) SaveDataWithRevision(userID string, data *pb.DataRecord, baseRevision int64) (int64, error) {
+	var revision int64
+	err := r.db.QueryRowContext(context.Background(),
+		`INSERT INTO user_data (id, user_id, type, encrypted_data, metadata, encrypted_metadata, blind_indexes)
+         VALUES ($1, $2, $3, $4, $5, $7, COALESCE($8::bytea[], '{}'))
+         ON CONFLICT (id) DO UPDATE SET
+             type = EXCLUDED.type,
+             encrypted_data = EXCLUDED.encrypted_data,
+             metadata = EXCLUDED.metadata,
+             encrypted_metadata = EXCLUDED.encrypted_metadata,
+             blind_indexes = EXCLUDED.blind_indexes,
+             deleted = FALSE,
+             deleted_at = NULL,
+             revision = user_data.revision + 1,
+             change_seq = nextval('user_data_change_seq'),
+             updated_at = NOW()
+         WHERE user_data.user_id = EXCLUDED.user_id
+           AND (user_data.revision = $6 OR (user_data.deleted AND $6 = 0))
+         RETURNING revision`,
+		data.Id, userID, data.Type, data.EncryptedData, data.Metadata, baseRevision,
+		data.EncryptedMetadata, data.BlindIndexes).Scan(&revision)
+	if err == nil {
+		return revision, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to save data: %w", err)
+	}
+
+	return r.conflictRevision(userID, data.Id)
+}
+
+// conflictRevision объясняет, почему условное сохранение записи id не изменило строку:
+// возвращает текущую ревизию и ErrRevisionConflict или ErrIDNotAvailable, если ID занят
+// записью другого пользователя. Ревизия чужой записи не раскрывается.
+func (r *PostgresDataRepository) conflictRevision(userID, id string) (int64, error) {
+	var (
+		owner    string
+		revision int64
+	)
+	err := r.db.QueryRowContext(context.Background(),
+		`SELECT user_id, revision FROM user_data WHERE id = $1`,
+		id).Scan(&owner, &revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Запись окончательно удалена после неудачного сохранения — клиент повторит его
+		return 0, ErrRevisionConflict
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current revision: %w", err)
+	}
+	if owner != userID {
+		return 0, ErrIDNotAvailable
+	}
+	return revision, ErrRevisionConflict
+}
+
 // GetAllData возвращает все не удалённые данные пользователя из базы данных.
 func (r *PostgresDataRepository) GetAllData(userID string) ([]*pb.DataRecord, error) {
+	page, err := r.GetDataPage(userID, DataFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return page.Records, nil
+}
+
+// GetByID возвращает неудалённую запись пользователя по первичному ключу.
+func (r *PostgresDataRepository) GetByID(userID, id string) (*pb.DataRecord, error) {
+	page, err := r.GetDataPage(userID, DataFilter{ID: id})
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Records) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return page.Records[0], nil
+}
+
+// GetDataByBlindIndexes возвращает неудалённые записи пользователя, у которых есть все слепые индексы indexes.
+func (r *PostgresDataRepository) GetDataByBlindIndexes(userID string, indexes [][]byte) ([]*pb.DataRecord, error) {
+	page, err := r.GetDataPage(userID, DataFilter{BlindIndexes: indexes})
+	if err != nil {
+		return nil, err
+	}
+	return page.Records, nil
+}
+
+// GetDataChangedSince возвращает записи пользователя, у которых change_seq больше курсора.
+// Удалённые записи возвращаются как tombstones; при нулевом курсоре они пропускаются,
+// так как клиенту без локальных данных нечего удалять.
+// Изменения одного пользователя фиксируются в порядке change_seq (номер выдаёт триггер под блокировкой
+// пользователя), поэтому в выборку не попадает больший номер раньше меньшего и курсор ничего не пропускает.
+func (r *PostgresDataRepository) GetDataChangedSince(userID string, cursor int64) (*DataChanges, error) {
+	var purgedSeq int64
+	err := r.db.QueryRowContext(context.Background(),
+		`SELECT purged_seq FROM users WHERE id = $1`, userID).Scan(&purgedSeq)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get purge horizon: %w", err)
+	}
+
+	reset := cursor > 0 && cursor < purgedSeq
+	if reset {
+		cursor = 0
+	}
+
 	rows, err := r.db.QueryContext(context.Background(),
-		`SELECT id, type, encrypted_data, metadata, EXTRACT(EPOCH FROM updated_at)::int
+		`SELECT id, type, encrypted_data, metadata, EXTRACT(EPOCH FROM updated_at)::int, revision,
+                blob_size, COALESCE(blob_sha256, ''::bytea), COALESCE(encrypted_metadata, ''::bytea),
+                deleted, COALESCE(EXTRACT(EPOCH FROM deleted_at)::int, 0), change_seq
          FROM user_data
-         WHERE user_id = $1 AND deleted = false
-         ORDER BY updated_at DESC`,
-		userID)
+         WHERE user_id = $1 AND change_seq > $2 AND (deleted = false OR $2 > 0)
+         ORDER BY change_seq`,
+		userID, cursor)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all data: %w", err)
+		return nil, fmt.Errorf("failed to get changed data: %w", err)
 	}
 	defer rows.Close()
 
-	var records []*pb.DataRecord
+	changes := &DataChanges{Cursor: cursor, Reset: reset}
 	for rows.Next() {
 		var (
 			record      pb.DataRecord
 			metadataRaw []byte
+			deleted     bool
+			deletedAt   int64
+			changeSeq   int64
 		)
 
 		if err := rows.Scan(
@@ -83,22 +294,132 @@ func (r *PostgresDataRepository) GetAllData(userID string) ([]*pb.DataRecord, er
 			&record.EncryptedData,
 			&metadataRaw,
 			&record.Timestamp,
+			&record.Revision,
+			&record.BlobSize,
+			&record.BlobSha256,
+			&record.EncryptedMetadata,
+			&deleted,
+			&deletedAt,
+			&changeSeq,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
-		// Теперь конвертируем JSON в map[string]string
+		if changeSeq > changes.Cursor {
+			changes.Cursor = changeSeq
+		}
+
+		if deleted {
+			changes.Tombstones = append(changes.Tombstones, &pb.Tombstone{
+				Id:        record.Id,
+				Revision:  record.Revision,
+				DeletedAt: deletedAt,
+			})
+			continue
+		}
+
 		if len(metadataRaw) > 0 && string(metadataRaw) != "null" {
-			err = json.Unmarshal(metadataRaw, &record.Metadata)
-			if err != nil {
+			if err := json.Unmarshal(metadataRaw, &record.Metadata); err != nil {
 				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
 			}
 		}
 
-		records = append(records, &record)
+		changes.Records = append(changes.Records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return changes, nil
+}
+
+// PurgeDeletedData окончательно удаляет tombstones старше olderThan.
+// Для каждого затронутого пользователя сдвигается purged_seq, чтобы клиенты
+// с более старым курсором получили полный снимок вместо пропущенных удалений.
+func (r *PostgresDataRepository) PurgeDeletedData(olderThan time.Time) ([]RecordKey, error) {
+	rows, err := r.db.QueryContext(context.Background(),
+		`WITH purged AS (
+             DELETE FROM user_data
+             WHERE deleted AND deleted_at < $1
+             RETURNING user_id, id, change_seq
+         ), horizon AS (
+             UPDATE users SET purged_seq = GREATEST(users.purged_seq, p.max_seq)
+             FROM (SELECT user_id, MAX(change_seq) AS max_seq FROM purged GROUP BY user_id) p
+             WHERE users.id = p.user_id
+         )
+         SELECT user_id, id FROM purged`,
+		olderThan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted data: %w", err)
+	}
+	defer rows.Close()
+
+	var purged []RecordKey
+	for rows.Next() {
+		var key RecordKey
+		if err := rows.Scan(&key.UserID, &key.ID); err != nil {
+			return nil, fmt.Errorf("failed to scan purged row: %w", err)
+		}
+		purged = append(purged, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate purged rows: %w", err)
+	}
+
+	return purged, nil
+}
+
+// SaveBinaryData сохраняет запись с загруженным содержимым.
+// Само содержимое хранится в файловом хранилище; в encrypted_data клиент может передать
+// зашифрованное описание содержимого (например, имя файла).
+func (r *PostgresDataRepository) SaveBinaryData(userID string, data *pb.DataRecord, baseRevision int64) (int64, error) {
+	var revision int64
+	err := r.db.QueryRowContext(context.Background(),
+		`INSERT INTO user_data (id, user_id, type, encrypted_data, metadata, blob_size, blob_sha256,
+                                encrypted_metadata, blind_indexes)
+         VALUES ($1, $2, $3, COALESCE($4, ''::bytea), $5, $6, $7, $8, COALESCE($9::bytea[], '{}'))
+         ON CONFLICT (id) DO UPDATE SET
+             type = EXCLUDED.type,
+             encrypted_data = EXCLUDED.encrypted_data,
+             metadata = EXCLUDED.metadata,
+             encrypted_metadata = EXCLUDED.encrypted_metadata,
+             blind_indexes = EXCLUDED.blind_indexes,
+             blob_size = EXCLUDED.blob_size,
+             blob_sha256 = EXCLUDED.blob_sha256,
+             deleted = FALSE,
+             deleted_at = NULL,
+             revision = user_data.revision + 1,
+             change_seq = nextval('user_data_change_seq'),
+             updated_at = NOW()
+         WHERE user_data.user_id = EXCLUDED.user_id
+           AND (user_data.revision = $10 OR (user_data.deleted AND $10 = 0))
+         RETURNING revision`,
+		data.Id, userID, data.Type, data.EncryptedData, data.Metadata, data.BlobSize, data.BlobSha256,
+		data.EncryptedMetadata, data.BlindIndexes, baseRevision).Scan(&revision)
+	if err == nil {
+		return revision, nil
 	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to save binary data: %w", err)
+	}
+
+	return r.conflictRevision(userID, data.Id)
+}
 
-	return records, nil
+// GetBlobInfo возвращает размер и SHA-256 загруженного содержимого записи.
+func (r *PostgresDataRepository) GetBlobInfo(userID, id string) (int64, []byte, error) {
+	var (
+		size int64
+		hash []byte
+	)
+	err := r.db.QueryRowContext(context.Background(),
+		`SELECT blob_size, blob_sha256 FROM user_data
+         WHERE id = $1 AND user_id = $2 AND NOT deleted AND blob_size > 0`,
+		id, userID).Scan(&size, &hash)
+	if err != nil {
+		return 0, nil, err
+	}
+	return size, hash, nil
 }
 
 func (r *PostgresDataRepository) DataExistsForUser(id, userID string) (bool, error) {
@@ -114,9 +435,119 @@ func (r *PostgresDataRepository) DataExistsForUser(id, userID string) (bool, err
 
 func (r *PostgresDataRepository) MarkDataAsDeleted(id string) error {
 	_, err := r.db.ExecContext(context.Background(),
-		`UPDATE user_data SET deleted = TRUE WHERE id = $1`, id)
+		`UPDATE user_data
+         SET deleted = TRUE, deleted_at = NOW(), revision = revision + 1,
+             change_seq = nextval('user_data_change_seq'), updated_at = NOW()
+         WHERE id = $1`, id)
 	if err != nil {
 		return fmt.Errorf("failed to mark data as deleted: %w", err)
 	}
 	return nil
 }
+
+// MarkDataAsDeletedWithRevision помечает запись удалённой с проверкой базовой ревизии клиента.
+func (r *PostgresDataRepository) MarkDataAsDeletedWithRevision(userID, id string, baseRevision int64) (int64, error) {
+	var revision int64
+	err := r.db.QueryRowContext(context.Background(),
+		`UPDATE user_data
+         SET deleted = TRUE, deleted_at = NOW(), revision = revision + 1,
+             change_seq = nextval('user_data_change_seq'), updated_at = NOW()
+         WHERE id = $1 AND user_id = $2 AND revision = $3 AND NOT deleted
+         RETURNING revision`,
+		id, userID, baseRevision).Scan(&revision)
+	if err == nil {
+		return revision, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to mark data as deleted: %w", err)
+	}
+
+	var deleted bool
+	err = r.db.QueryRowContext(context.Background(),
+		`SELECT revision, deleted FROM user_data WHERE id = $1 AND user_id = $2`,
+		id, userID).Scan(&revision, &deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current revision: %w", err)
+	}
+	if deleted {
+		return revision, nil
+	}
+
+	return revision, ErrRevisionConflict
+}
+
+// GetDeletedData возвращает записи пользователя из корзины.
+func (r *PostgresDataRepository) GetDeletedData(userID string) ([]*DeletedRecord, error) {
+	rows, err := r.db.QueryContext(context.Background(),
+		`SELECT id, type, encrypted_data, metadata, EXTRACT(EPOCH FROM updated_at)::int, revision,
+                blob_size, COALESCE(blob_sha256, ''::bytea), COALESCE(encrypted_metadata, ''::bytea),
+                COALESCE(deleted_at, updated_at)
+         FROM user_data
+         WHERE user_id = $1 AND deleted
+         ORDER BY deleted_at DESC`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted data: %w", err)
+	}
+	defer rows.Close()
+
+	var deleted []*DeletedRecord
+	for rows.Next() {
+		var (
+			record      pb.DataRecord
+			metadataRaw []byte
+			deletedAt   time.Time
+		)
+
+		if err := rows.Scan(
+			&record.Id,
+			&record.Type,
+			&record.EncryptedData,
+			&metadataRaw,
+			&record.Timestamp,
+			&record.Revision,
+			&record.BlobSize,
+			&record.BlobSha256,
+			&record.EncryptedMetadata,
+			&deletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if len(metadataRaw) > 0 && string(metadataRaw) != "null" {
+			if err := json.Unmarshal(metadataRaw, &record.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		deleted = append(deleted, &DeletedRecord{Record: &record, DeletedAt: deletedAt})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// UndeleteData восстанавливает запись из корзины. Изменение получает новый change_seq,
+// поэтому остальные устройства получат запись при следующей синхронизации.
+func (r *PostgresDataRepository) UndeleteData(userID, id string) (int64, error) {
+	var revision int64
+	err := r.db.QueryRowContext(context.Background(),
+		`UPDATE user_data
+         SET deleted = FALSE, deleted_at = NULL, revision = revision + 1,
+             change_seq = nextval('user_data_change_seq'), updated_at = NOW()
+         WHERE id = $1 AND user_id = $2 AND deleted
+         RETURNING revision`,
+		id, userID).Scan(&revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotDeleted
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to undelete data: %w", err)
+	}
+	return revision, nil
+}
@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/dvkhr/gophkeeper/pb"
 	"github.com/stretchr/testify/assert"
@@ -141,3 +143,305 @@ func TestDataRepository_GetAllData_Multiple(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, records, 2)
 }
+
+func TestDataRepository_SaveDataWithRevision(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("testuser", "hashedpass")
+	require.NoError(t, err)
+
+	record := &pb.DataRecord{
+		Id:            "rev-1",
+		Type:          "text",
+		EncryptedData: []byte("v1"),
+	}
+
+	// 1. Создание новой записи
+	revision, err := dataRepo.SaveDataWithRevision(userID, record, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revision)
+
+	// 2. Обновление с актуальной базовой ревизией
+	record.EncryptedData = []byte("v2")
+	revision, err = dataRepo.SaveDataWithRevision(userID, record, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), revision)
+
+	// 3. Обновление с устаревшей базовой ревизией
+	record.EncryptedData = []byte("stale")
+	revision, err = dataRepo.SaveDataWithRevision(userID, record, 1)
+	require.ErrorIs(t, err, ErrRevisionConflict)
+	assert.Equal(t, int64(2), revision)
+
+	records, err := dataRepo.GetAllData(userID)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, []byte("v2"), records[0].EncryptedData)
+	assert.Equal(t, int64(2), records[0].Revision)
+}
+
+func TestDataRepository_SaveDataWithRevision_OtherUser(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	owner, err := userRepo.CreateUser("owner", "hashedpass")
+	require.NoError(t, err)
+	other, err := userRepo.CreateUser("other", "hashedpass")
+	require.NoError(t, err)
+
+	record := &pb.DataRecord{Id: "shared-id", Type: "text", EncryptedData: []byte("owner")}
+	_, err = dataRepo.SaveDataWithRevision(owner, record, 0)
+	require.NoError(t, err)
+
+	// Чужая запись не перезаписывается даже при совпадении ревизии, и это не конфликт ревизий
+	hijack := &pb.DataRecord{Id: "shared-id", Type: "text", EncryptedData: []byte("other")}
+	revision, err := dataRepo.SaveDataWithRevision(other, hijack, 1)
+	require.ErrorIs(t, err, ErrIDNotAvailable)
+	assert.Equal(t, int64(0), revision)
+
+	_, err = dataRepo.SaveBinaryData(other, hijack, 0)
+	require.ErrorIs(t, err, ErrIDNotAvailable)
+
+	records, err := dataRepo.GetAllData(owner)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, []byte("owner"), records[0].EncryptedData)
+}
+
+func TestDataRepository_GetDataChangedSince(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("testuser", "hashedpass")
+	require.NoError(t, err)
+
+	require.NoError(t, dataRepo.SaveData(userID, &pb.DataRecord{Id: "1", Type: "text", EncryptedData: []byte("a")}))
+	require.NoError(t, dataRepo.SaveData(userID, &pb.DataRecord{Id: "2", Type: "text", EncryptedData: []byte("b")}))
+
+	// 1. Полная выборка
+	changes, err := dataRepo.GetDataChangedSince(userID, 0)
+	require.NoError(t, err)
+	assert.Len(t, changes.Records, 2)
+	assert.Empty(t, changes.Tombstones)
+
+	cursor := changes.Cursor
+
+	// 2. Удаление попадает в выборку после курсора как tombstone
+	require.NoError(t, dataRepo.MarkDataAsDeleted("2"))
+
+	changes, err = dataRepo.GetDataChangedSince(userID, cursor)
+	require.NoError(t, err)
+	assert.Empty(t, changes.Records)
+	require.Len(t, changes.Tombstones, 1)
+	assert.Equal(t, "2", changes.Tombstones[0].Id)
+	assert.Greater(t, changes.Cursor, cursor)
+
+	// 3. При полной выборке удалённые записи не возвращаются
+	changes, err = dataRepo.GetDataChangedSince(userID, 0)
+	require.NoError(t, err)
+	require.Len(t, changes.Records, 1)
+	assert.Equal(t, "1", changes.Records[0].Id)
+	assert.Empty(t, changes.Tombstones)
+}
+
+// Изменения пользователя фиксируются в порядке change_seq: пока транзакция с меньшим номером
+// не завершена, более поздняя запись ждёт, и курсор не может перескочить незафиксированное изменение.
+func TestDataRepository_ChangeSeqCommitOrder(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("sequser", "hashedpass")
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec(`INSERT INTO user_data (id, user_id, type, encrypted_data) VALUES ('slow', $1, 'text', 'a')`, userID)
+	require.NoError(t, err)
+
+	saved := make(chan error, 1)
+	go func() {
+		saved <- dataRepo.SaveData(userID, &pb.DataRecord{Id: "fast", Type: "text", EncryptedData: []byte("b")})
+	}()
+
+	select {
+	case err := <-saved:
+		t.Fatalf("запись зафиксирована раньше незавершённой транзакции: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	changes, err := dataRepo.GetDataChangedSince(userID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, changes.Records)
+
+	require.NoError(t, tx.Commit())
+	require.NoError(t, <-saved)
+
+	changes, err = dataRepo.GetDataChangedSince(userID, 0)
+	require.NoError(t, err)
+	require.Len(t, changes.Records, 2)
+	assert.Equal(t, "slow", changes.Records[0].Id)
+	assert.Equal(t, "fast", changes.Records[1].Id)
+}
+
+func TestDataRepository_PurgeDeletedData(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("testuser", "hashedpass")
+	require.NoError(t, err)
+
+	require.NoError(t, dataRepo.SaveData(userID, &pb.DataRecord{Id: "keep", Type: "text", EncryptedData: []byte("a")}))
+	require.NoError(t, dataRepo.SaveData(userID, &pb.DataRecord{Id: "gone", Type: "text", EncryptedData: []byte("b")}))
+
+	changes, err := dataRepo.GetDataChangedSince(userID, 0)
+	require.NoError(t, err)
+	staleCursor := changes.Cursor
+
+	require.NoError(t, dataRepo.MarkDataAsDeleted("gone"))
+
+	// 1. Tombstone моложе срока хранения не удаляется
+	purged, err := dataRepo.PurgeDeletedData(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+
+	changes, err = dataRepo.GetDataChangedSince(userID, staleCursor)
+	require.NoError(t, err)
+	require.Len(t, changes.Tombstones, 1)
+	assert.NotZero(t, changes.Tombstones[0].DeletedAt)
+	assert.False(t, changes.Reset)
+
+	// 2. Tombstone старше срока хранения удаляется окончательно
+	purged, err = dataRepo.PurgeDeletedData(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, purged, 1)
+	assert.Equal(t, RecordKey{UserID: userID, ID: "gone"}, purged[0])
+
+	// 3. Клиент с устаревшим курсором получает полный снимок
+	changes, err = dataRepo.GetDataChangedSince(userID, staleCursor)
+	require.NoError(t, err)
+	assert.True(t, changes.Reset)
+	assert.Empty(t, changes.Tombstones)
+	require.Len(t, changes.Records, 1)
+	assert.Equal(t, "keep", changes.Records[0].Id)
+}
+
+func TestDataRepository_SaveBinaryData(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("binuser", "hashedpass")
+	require.NoError(t, err)
+
+	_, _, err = dataRepo.GetBlobInfo(userID, "file-1")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	record := &pb.DataRecord{Id: "file-1", Type: "binary", BlobSize: 42, BlobSha256: []byte("hash")}
+	revision, err := dataRepo.SaveBinaryData(userID, record, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revision)
+
+	size, hash, err := dataRepo.GetBlobInfo(userID, "file-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), size)
+	assert.Equal(t, []byte("hash"), hash)
+
+	_, err = dataRepo.SaveBinaryData(userID, record, 0)
+	require.ErrorIs(t, err, ErrRevisionConflict)
+
+	require.NoError(t, dataRepo.MarkDataAsDeleted("file-1"))
+	_, _, err = dataRepo.GetBlobInfo(userID, "file-1")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestDataRepository_GetDataByBlindIndexes(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("testuser", "hashedpass")
+	require.NoError(t, err)
+
+	bank := &pb.DataRecord{
+		Id:                "card-1",
+		Type:              "card",
+		EncryptedData:     []byte("card"),
+		EncryptedMetadata: []byte("sealed-metadata"),
+		BlindIndexes:      [][]byte{[]byte("idx-bank"), []byte("idx-owner")},
+	}
+	site := &pb.DataRecord{
+		Id:            "login-1",
+		Type:          "loginpass",
+		EncryptedData: []byte("login"),
+		BlindIndexes:  [][]byte{[]byte("idx-site")},
+	}
+	require.NoError(t, dataRepo.SaveData(userID, bank))
+	_, err = dataRepo.SaveDataWithRevision(userID, site, 0)
+	require.NoError(t, err)
+
+	records, err := dataRepo.GetDataByBlindIndexes(userID, [][]byte{[]byte("idx-bank")})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "card-1", records[0].Id)
+	assert.Equal(t, []byte("sealed-metadata"), records[0].EncryptedMetadata)
+	assert.Empty(t, records[0].Metadata)
+
+	records, err = dataRepo.GetDataByBlindIndexes(userID, [][]byte{[]byte("idx-bank"), []byte("idx-site")})
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	// запись без индексов сохраняется с пустым массивом
+	require.NoError(t, dataRepo.SaveData(userID, &pb.DataRecord{Id: "plain", Type: "text", EncryptedData: []byte("x")}))
+	all, err := dataRepo.GetAllData(userID)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func TestDataRepository_UndeleteData(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("trashuser", "hashedpass")
+	require.NoError(t, err)
+
+	require.NoError(t, dataRepo.SaveData(userID, &pb.DataRecord{Id: "note", Type: "text", EncryptedData: []byte("a")}))
+
+	_, err = dataRepo.UndeleteData(userID, "note")
+	require.ErrorIs(t, err, ErrNotDeleted)
+
+	require.NoError(t, dataRepo.MarkDataAsDeleted("note"))
+
+	deleted, err := dataRepo.GetDeletedData(userID)
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, "note", deleted[0].Record.Id)
+	assert.Equal(t, []byte("a"), deleted[0].Record.EncryptedData)
+	assert.WithinDuration(t, time.Now(), deleted[0].DeletedAt, time.Hour)
+
+	// чужой пользователь не видит и не восстанавливает запись
+	otherID, err := userRepo.CreateUser("otheruser", "hashedpass")
+	require.NoError(t, err)
+	deleted, err = dataRepo.GetDeletedData(otherID)
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	_, err = dataRepo.UndeleteData(otherID, "note")
+	require.ErrorIs(t, err, ErrNotDeleted)
+
+	revision, err := dataRepo.UndeleteData(userID, "note")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), revision)
+
+	records, err := dataRepo.GetAllData(userID)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	deleted, err = dataRepo.GetDeletedData(userID)
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/pb"
+)
+
+// ListDataVersions возвращает версии записи из user_data_history.
+// Версии сохраняет триггер на user_data при каждом изменении содержимого записи.
+func (r *PostgresDataRepository) ListDataVersions(userID, id string) ([]*pb.RecordVersion, error) {
+	rows, err := r.db.QueryContext(context.Background(),
+		`SELECT id, type, encrypted_data, metadata, COALESCE(EXTRACT(EPOCH FROM updated_at)::int, 0), revision,
+                blob_size, COALESCE(blob_sha256, ''::bytea), COALESCE(encrypted_metadata, ''::bytea),
+                EXTRACT(EPOCH FROM replaced_at)::int
+         FROM user_data_history
+         WHERE id = $1 AND user_id = $2
+         ORDER BY revision DESC`,
+		id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*pb.RecordVersion
+	for rows.Next() {
+		var (
+			record      pb.DataRecord
+			metadataRaw []byte
+			replacedAt  int64
+		)
+
+		if err := rows.Scan(
+			&record.Id,
+			&record.Type,
+			&record.EncryptedData,
+			&metadataRaw,
+			&record.Timestamp,
+			&record.Revision,
+			&record.BlobSize,
+			&record.BlobSha256,
+			&record.EncryptedMetadata,
+			&replacedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan version: %w", err)
+		}
+
+		if len(metadataRaw) > 0 && string(metadataRaw) != "null" {
+			if err := json.Unmarshal(metadataRaw, &record.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		versions = append(versions, &pb.RecordVersion{Record: &record, ReplacedAt: replacedAt})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// RestoreDataVersion копирует версию из истории в user_data одним запросом.
+// Текущая версия при этом сама попадает в историю, поэтому восстановление можно отменить.
+// Версию бинарной записи можно восстановить, только пока её содержимое не заменено.
+func (r *PostgresDataRepository) RestoreDataVersion(userID, id string, revision, baseRevision int64) (int64, error) {
+	var newRevision int64
+	err := r.db.QueryRowContext(context.Background(),
+		`UPDATE user_data d SET
+             type = h.type,
+             encrypted_data = h.encrypted_data,
+             metadata = h.metadata,
+             encrypted_metadata = h.encrypted_metadata,
+             blind_indexes = h.blind_indexes,
+             deleted = FALSE,
+             deleted_at = NULL,
+             revision = d.revision + 1,
+             change_seq = nextval('user_data_change_seq'),
+             updated_at = NOW()
+         FROM user_data_history h
+         WHERE d.id = $1 AND d.user_id = $2
+           AND h.id = d.id AND h.user_id = d.user_id AND h.revision = $3
+           AND (d.revision = $4 OR $4 = 0)
+           AND h.blob_size = d.blob_size AND h.blob_sha256 IS NOT DISTINCT FROM d.blob_sha256
+         RETURNING d.revision`,
+		id, userID, revision, baseRevision).Scan(&newRevision)
+	if err == nil {
+		return newRevision, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to restore version: %w", err)
+	}
+
+	var (
+		current     int64
+		sameContent bool
+	)
+	err = r.db.QueryRowContext(context.Background(),
+		`SELECT d.revision, h.blob_size = d.blob_size AND h.blob_sha256 IS NOT DISTINCT FROM d.blob_sha256
+         FROM user_data d JOIN user_data_history h ON h.id = d.id AND h.user_id = d.user_id
+         WHERE d.id = $1 AND d.user_id = $2 AND h.revision = $3`,
+		id, userID, revision).Scan(&current, &sameContent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrVersionNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current revision: %w", err)
+	}
+	if !sameContent {
+		return current, ErrVersionUnavailable
+	}
+
+	return current, ErrRevisionConflict
+}
+
+// PurgeDataHistory удаляет устаревшие версии: заменённые раньше olderThan
+// и все, кроме keep самых новых, у каждой записи.
+func (r *PostgresDataRepository) PurgeDataHistory(keep int, olderThan time.Time) (int64, error) {
+	res, err := r.db.ExecContext(context.Background(),
+		`DELETE FROM user_data_history h
+         USING (
+             SELECT id, revision,
+                    ROW_NUMBER() OVER (PARTITION BY id ORDER BY revision DESC) AS position
+             FROM user_data_history
+         ) ranked
+         WHERE h.id = ranked.id AND h.revision = ranked.revision
+           AND (h.replaced_at < $1 OR ranked.position > $2)`,
+		olderThan, keep)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge data history: %w", err)
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count purged versions: %w", err)
+	}
+	return purged, nil
+}
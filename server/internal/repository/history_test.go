@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataRepository_History(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("historyuser", "hashedpass")
+	require.NoError(t, err)
+
+	record := &pb.DataRecord{Id: "pw", Type: "loginpass", EncryptedData: []byte("v1"), EncryptedMetadata: []byte("m1")}
+	_, err = dataRepo.SaveDataWithRevision(userID, record, 0)
+	require.NoError(t, err)
+
+	// 1. Перезапись сохраняет прежнюю версию
+	record.EncryptedData = []byte("v2")
+	revision, err := dataRepo.SaveDataWithRevision(userID, record, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), revision)
+
+	versions, err := dataRepo.ListDataVersions(userID, "pw")
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, int64(1), versions[0].Record.Revision)
+	assert.Equal(t, []byte("v1"), versions[0].Record.EncryptedData)
+	assert.Equal(t, []byte("m1"), versions[0].Record.EncryptedMetadata)
+	assert.NotZero(t, versions[0].ReplacedAt)
+
+	// 2. Чужой пользователь историю не видит
+	otherID, err := userRepo.CreateUser("otheruser", "hashedpass")
+	require.NoError(t, err)
+	versions, err = dataRepo.ListDataVersions(otherID, "pw")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	// 3. Восстановление с устаревшей базовой ревизией — конфликт
+	current, err := dataRepo.RestoreDataVersion(userID, "pw", 1, 1)
+	require.ErrorIs(t, err, ErrRevisionConflict)
+	assert.Equal(t, int64(2), current)
+
+	_, err = dataRepo.RestoreDataVersion(userID, "pw", 42, 2)
+	require.ErrorIs(t, err, ErrVersionNotFound)
+
+	// 4. Восстановление делает версию текущей, а заменённая попадает в историю
+	revision, err = dataRepo.RestoreDataVersion(userID, "pw", 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), revision)
+
+	records, err := dataRepo.GetAllData(userID)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, []byte("v1"), records[0].EncryptedData)
+
+	versions, err = dataRepo.ListDataVersions(userID, "pw")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, int64(2), versions[0].Record.Revision)
+
+	// 5. Удалённая запись восстанавливается из истории
+	require.NoError(t, dataRepo.MarkDataAsDeleted("pw"))
+	_, err = dataRepo.RestoreDataVersion(userID, "pw", 2, 0)
+	require.NoError(t, err)
+	records, err = dataRepo.GetAllData(userID)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, []byte("v2"), records[0].EncryptedData)
+}
+
+func TestDataRepository_RestoreDataVersion_Binary(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("binuser", "hashedpass")
+	require.NoError(t, err)
+
+	record := &pb.DataRecord{Id: "file", Type: "binary", EncryptedData: []byte("ref1"), BlobSize: 10, BlobSha256: []byte("h1")}
+	_, err = dataRepo.SaveBinaryData(userID, record, 0)
+	require.NoError(t, err)
+
+	record.EncryptedData, record.BlobSize, record.BlobSha256 = []byte("ref2"), 20, []byte("h2")
+	_, err = dataRepo.SaveBinaryData(userID, record, 1)
+	require.NoError(t, err)
+
+	// содержимое первой версии уже заменено в файловом хранилище
+	_, err = dataRepo.RestoreDataVersion(userID, "file", 1, 2)
+	require.ErrorIs(t, err, ErrVersionUnavailable)
+}
+
+func TestDataRepository_PurgeDataHistory(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("purgeuser", "hashedpass")
+	require.NoError(t, err)
+
+	record := &pb.DataRecord{Id: "note", Type: "text"}
+	for i := int64(0); i < 5; i++ {
+		record.EncryptedData = []byte{byte(i)}
+		_, err := dataRepo.SaveDataWithRevision(userID, record, i)
+		require.NoError(t, err)
+	}
+
+	versions, err := dataRepo.ListDataVersions(userID, "note")
+	require.NoError(t, err)
+	require.Len(t, versions, 4)
+
+	// 1. Лимит по количеству оставляет самые новые версии
+	purged, err := dataRepo.PurgeDataHistory(2, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	versions, err = dataRepo.ListDataVersions(userID, "note")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, int64(4), versions[0].Record.Revision)
+	assert.Equal(t, int64(3), versions[1].Record.Revision)
+
+	// 2. Лимит по возрасту удаляет все версии старше срока
+	purged, err = dataRepo.PurgeDataHistory(10, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/pb"
+)
+
+// DataFilter — условия выборки записей для GetDataPage. Пустые условия не ограничивают выборку.
+type DataFilter struct {
+	ID           string      // Только запись с этим ID
+	Type         string      // Только записи этого типа
+	BlindIndexes [][]byte    // Только записи, у которых есть все эти слепые индексы
+	Limit        int         // Максимум записей на странице; 0 — без ограничения
+	After        *PageCursor // Страница начинается после этой позиции
+}
+
+// PageCursor — позиция записи в порядке выдачи GetDataPage.
+type PageCursor struct {
+	UpdatedAt time.Time
+	ID        string
+}
+
+// DataPage — страница записей.
+type DataPage struct {
+	Records []*pb.DataRecord
+	Next    *PageCursor // Позиция последней записи страницы; nil — записей больше нет
+}
+
+// GetDataPage выбирает записи по ключу (updated_at, id) без OFFSET: следующая страница
+// начинается строго после последней записи предыдущей и использует индексы
+// idx_user_data_user_page и idx_user_data_user_type_page.
+// Записи, обновлённые между запросами страниц, переходят в начало выдачи;
+// для полного согласованного списка используется синхронизация.
+func (r *PostgresDataRepository) GetDataPage(userID string, filter DataFilter) (*DataPage, error) {
+	conds := []string{"user_id = $1", "deleted = false"}
+	args := []any{userID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.ID != "" {
+		conds = append(conds, "id = "+arg(filter.ID))
+	}
+	if filter.Type != "" {
+		conds = append(conds, "type = "+arg(filter.Type))
+	}
+	if len(filter.BlindIndexes) > 0 {
+		// Поиск использует GIN-индекс по колонке blind_indexes
+		conds = append(conds, "blind_indexes @> "+arg(filter.BlindIndexes)+"::bytea[]")
+	}
+	if filter.After != nil {
+		conds = append(conds, fmt.Sprintf("(updated_at, id) < (%s::timestamp, %s)",
+			arg(filter.After.UpdatedAt), arg(filter.After.ID)))
+	}
+
+	query := `SELECT id, type, encrypted_data, metadata, EXTRACT(EPOCH FROM updated_at)::int, revision,
+                blob_size, COALESCE(blob_sha256, ''::bytea), COALESCE(encrypted_metadata, ''::bytea), updated_at
+         FROM user_data
+         WHERE ` + strings.Join(conds, " AND ") + `
+         ORDER BY updated_at DESC, id DESC`
+	if filter.Limit > 0 {
+		// Лишняя запись показывает, есть ли следующая страница
+		query += " LIMIT " + arg(filter.Limit+1)
+	}
+
+	rows, err := r.db.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data: %w", err)
+	}
+	defer rows.Close()
+
+	page := &DataPage{}
+	var last PageCursor
+	for rows.Next() {
+		if filter.Limit > 0 && len(page.Records) == filter.Limit {
+			page.Next = &last
+			break
+		}
+
+		var (
+			record      pb.DataRecord
+			metadataRaw []byte
+		)
+
+		if err := rows.Scan(
+			&record.Id,
+			&record.Type,
+			&record.EncryptedData,
+			&metadataRaw,
+			&record.Timestamp,
+			&record.Revision,
+			&record.BlobSize,
+			&record.BlobSha256,
+			&record.EncryptedMetadata,
+			&last.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		last.ID = record.Id
+
+		// Теперь конвертируем JSON в map[string]string
+		if len(metadataRaw) > 0 && string(metadataRaw) != "null" {
+			if err := json.Unmarshal(metadataRaw, &record.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		page.Records = append(page.Records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataRepository_GetDataPage(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("pageuser", "hashedpass")
+	require.NoError(t, err)
+	otherID, err := userRepo.CreateUser("otheruser", "hashedpass")
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		typ := "loginpass"
+		if i%2 == 1 {
+			typ = "card"
+		}
+		record := &pb.DataRecord{Id: fmt.Sprintf("rec-%d", i), Type: typ, EncryptedData: []byte("x")}
+		_, err = dataRepo.SaveDataWithRevision(userID, record, 0)
+		require.NoError(t, err)
+	}
+	_, err = dataRepo.SaveDataWithRevision(otherID, &pb.DataRecord{Id: "foreign", Type: "card", EncryptedData: []byte("x")}, 0)
+	require.NoError(t, err)
+	_, err = dataRepo.MarkDataAsDeletedWithRevision(userID, "rec-4", 1)
+	require.NoError(t, err)
+
+	all, err := dataRepo.GetAllData(userID)
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+
+	// 1. Страницы по 3 записи покрывают все записи в том же порядке
+	var ids []string
+	filter := DataFilter{Limit: 3}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		page, err := dataRepo.GetDataPage(userID, filter)
+		require.NoError(t, err)
+		for _, record := range page.Records {
+			ids = append(ids, record.Id)
+		}
+		if page.Next == nil {
+			break
+		}
+		filter.After = page.Next
+	}
+	var allIDs []string
+	for _, record := range all {
+		allIDs = append(allIDs, record.Id)
+	}
+	assert.Equal(t, allIDs, ids)
+
+	// 2. Страница ровно по числу записей не возвращает следующую позицию
+	page, err := dataRepo.GetDataPage(userID, DataFilter{Limit: 4})
+	require.NoError(t, err)
+	assert.Len(t, page.Records, 4)
+	assert.Nil(t, page.Next)
+
+	// 3. Фильтр по типу
+	page, err = dataRepo.GetDataPage(userID, DataFilter{Type: "card"})
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+	for _, record := range page.Records {
+		assert.Equal(t, "card", record.Type)
+	}
+
+	// 4. Одна запись по ID; чужие и удалённые записи не возвращаются
+	page, err = dataRepo.GetDataPage(userID, DataFilter{ID: "rec-2"})
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	assert.Equal(t, "rec-2", page.Records[0].Id)
+
+	page, err = dataRepo.GetDataPage(userID, DataFilter{ID: "foreign"})
+	require.NoError(t, err)
+	assert.Empty(t, page.Records)
+
+	page, err = dataRepo.GetDataPage(userID, DataFilter{ID: "rec-4"})
+	require.NoError(t, err)
+	assert.Empty(t, page.Records)
+}
+
+func TestDataRepository_GetByID(t *testing.T) {
+	db := setupTestDB()
+	userRepo := NewUserRepository(db)
+	dataRepo := NewDataRepository(db)
+
+	userID, err := userRepo.CreateUser("owner", "hashedpass")
+	require.NoError(t, err)
+	otherID, err := userRepo.CreateUser("stranger", "hashedpass")
+	require.NoError(t, err)
+
+	record := &pb.DataRecord{Id: "pw", Type: "loginpass", EncryptedData: []byte("secret"), EncryptedMetadata: []byte("meta")}
+	_, err = dataRepo.SaveDataWithRevision(userID, record, 0)
+	require.NoError(t, err)
+
+	got, err := dataRepo.GetByID(userID, "pw")
+	require.NoError(t, err)
+	assert.Equal(t, "loginpass", got.Type)
+	assert.Equal(t, []byte("secret"), got.EncryptedData)
+	assert.Equal(t, []byte("meta"), got.EncryptedMetadata)
+	assert.Equal(t, int64(1), got.Revision)
+
+	_, err = dataRepo.GetByID(otherID, "pw")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = dataRepo.GetByID(userID, "missing")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, dataRepo.MarkDataAsDeleted("pw"))
+	_, err = dataRepo.GetByID(userID, "pw")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
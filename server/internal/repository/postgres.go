@@ -33,14 +33,82 @@ func (r *PostgresRepository) GetUserByLogin(login string) (*User, error) {
 	return r.userRepo.GetUserByLogin(login)
 }
 
+func (r *PostgresRepository) GetUserByID(userID string) (*User, error) {
+	return r.userRepo.GetUserByID(userID)
+}
+
+func (r *PostgresRepository) ChangeMasterPassword(userID, passwordHash string, key *pb.VaultKey, expectedVersion int64) (int64, error) {
+	return r.userRepo.ChangeMasterPassword(userID, passwordHash, key, expectedVersion)
+}
+
+func (r *PostgresRepository) GetVaultKey(userID string) (*pb.VaultKey, error) {
+	return r.userRepo.GetVaultKey(userID)
+}
+
+func (r *PostgresRepository) StoreVaultKey(userID string, key *pb.VaultKey, expectedVersion int64) (int64, error) {
+	return r.userRepo.StoreVaultKey(userID, key, expectedVersion)
+}
+
 func (r *PostgresRepository) SaveData(userID string, data *pb.DataRecord) error {
 	return r.dataRepo.SaveData(userID, data)
 }
 
+func (r *PostgresRepository) SaveDataWithRevision(userID string, data *pb.DataRecord, baseRevision int64) (int64, error) {
+	return r.dataRepo.SaveDataWithRevision(userID, data, baseRevision)
+}
+
 func (r *PostgresRepository) GetAllData(userID string) ([]*pb.DataRecord, error) {
 	return r.dataRepo.GetAllData(userID)
 }
 
+func (r *PostgresRepository) GetDataByBlindIndexes(userID string, indexes [][]byte) ([]*pb.DataRecord, error) {
+	return r.dataRepo.GetDataByBlindIndexes(userID, indexes)
+}
+
+func (r *PostgresRepository) GetByID(userID, id string) (*pb.DataRecord, error) {
+	return r.dataRepo.GetByID(userID, id)
+}
+
+func (r *PostgresRepository) GetDataPage(userID string, filter DataFilter) (*DataPage, error) {
+	return r.dataRepo.GetDataPage(userID, filter)
+}
+
+func (r *PostgresRepository) GetDataChangedSince(userID string, cursor int64) (*DataChanges, error) {
+	return r.dataRepo.GetDataChangedSince(userID, cursor)
+}
+
+func (r *PostgresRepository) PurgeDeletedData(olderThan time.Time) ([]RecordKey, error) {
+	return r.dataRepo.PurgeDeletedData(olderThan)
+}
+
+func (r *PostgresRepository) SaveBinaryData(userID string, data *pb.DataRecord, baseRevision int64) (int64, error) {
+	return r.dataRepo.SaveBinaryData(userID, data, baseRevision)
+}
+
+func (r *PostgresRepository) GetBlobInfo(userID, id string) (int64, []byte, error) {
+	return r.dataRepo.GetBlobInfo(userID, id)
+}
+
+func (r *PostgresRepository) GetDeletedData(userID string) ([]*DeletedRecord, error) {
+	return r.dataRepo.GetDeletedData(userID)
+}
+
+func (r *PostgresRepository) UndeleteData(userID, id string) (int64, error) {
+	return r.dataRepo.UndeleteData(userID, id)
+}
+
+func (r *PostgresRepository) ListDataVersions(userID, id string) ([]*pb.RecordVersion, error) {
+	return r.dataRepo.ListDataVersions(userID, id)
+}
+
+func (r *PostgresRepository) RestoreDataVersion(userID, id string, revision, baseRevision int64) (int64, error) {
+	return r.dataRepo.RestoreDataVersion(userID, id, revision, baseRevision)
+}
+
+func (r *PostgresRepository) PurgeDataHistory(keep int, olderThan time.Time) (int64, error) {
+	return r.dataRepo.PurgeDataHistory(keep, olderThan)
+}
+
 func (r *PostgresRepository) SaveRefreshToken(token, userID string, expiresAt time.Time) error {
 	return r.tokenRepo.SaveRefreshToken(token, userID, expiresAt)
 }
@@ -61,6 +129,14 @@ func (r *PostgresRepository) MarkDataAsDeleted(id string) error {
 	return r.dataRepo.MarkDataAsDeleted(id)
 }
 
+func (r *PostgresRepository) MarkDataAsDeletedWithRevision(userID, id string, baseRevision int64) (int64, error) {
+	return r.dataRepo.MarkDataAsDeletedWithRevision(userID, id, baseRevision)
+}
+
 func (r *PostgresRepository) GetUserIDByRefreshToken(token string) (string, error) {
 	return r.tokenRepo.GetUserIDByRefreshToken(token)
 }
+
+func (r *PostgresRepository) GetSessionVersion(userID string) (int64, error) {
+	return r.tokenRepo.GetSessionVersion(userID)
+}
@@ -13,6 +13,9 @@ type User struct {
 	Status       string
 	CreatedAt    int64
 	UpdatedAt    int64
+
+	// SessionVersion увеличивается при смене мастер-пароля; access-токены с прежней версией не принимаются
+	SessionVersion int64
 }
 
 // DataRecord — модель данных пользователя, соответствует pb.DataRecord.
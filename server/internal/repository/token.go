@@ -26,6 +26,10 @@ type TokenRepository interface {
 	// GetUserIDByRefreshToken находит и возвращает идентификатор пользователя по значению refresh-токена.
 	// Возвращает ошибку sql.ErrNoRows, если токен не найден или отозван.
 	GetUserIDByRefreshToken(token string) (string, error)
+
+	// GetSessionVersion возвращает текущую версию сессий активного пользователя.
+	// Возвращает ошибку sql.ErrNoRows, если пользователь не найден или неактивен.
+	GetSessionVersion(userID string) (int64, error)
 }
 
 // PostgresTokenRepository — реализация TokenRepository для PostgreSQL.
@@ -85,3 +89,12 @@ func (r *PostgresTokenRepository) GetUserIDByRefreshToken(token string) (string,
     `, token).Scan(&userID)
 	return userID, err
 }
+
+// GetSessionVersion возвращает текущую версию сессий активного пользователя.
+func (r *PostgresTokenRepository) GetSessionVersion(userID string) (int64, error) {
+	var version int64
+	err := r.db.QueryRowContext(context.Background(),
+		`SELECT session_version FROM users WHERE id = $1 AND status = 'active'`,
+		userID).Scan(&version)
+	return version, err
+}
@@ -3,11 +3,19 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 var _ UserRepository = (*PostgresUserRepository)(nil)
 
+// ErrVaultKeyConflict возвращается, если ключ хранилища на сервере изменился после того,
+// как клиент его прочитал.
+var ErrVaultKeyConflict = errors.New("vault key version conflict")
+
 // UserRepository — интерфейс для работы с пользователями в базе данных.
 type UserRepository interface {
 	// CreateUser создаёт нового пользователя с указанным логином и хэшем пароля.
@@ -17,6 +25,24 @@ type UserRepository interface {
 	// GetUserByLogin возвращает пользователя по его логину, если он существует и активен.
 	// Возвращает nil, если пользователь не найден.
 	GetUserByLogin(login string) (*User, error)
+
+	// GetUserByID возвращает активного пользователя по идентификатору.
+	// Возвращает nil, если пользователь не найден.
+	GetUserByID(userID string) (*User, error)
+
+	// GetVaultKey возвращает зашифрованный ключ хранилища пользователя.
+	// Возвращает nil, если ключ ещё не сохранён.
+	GetVaultKey(userID string) (*pb.VaultKey, error)
+
+	// StoreVaultKey сохраняет зашифрованный ключ хранилища, если его текущая версия равна expectedVersion.
+	// Возвращает новую версию ключа; при несовпадении — текущую версию и ErrVaultKeyConflict.
+	StoreVaultKey(userID string, key *pb.VaultKey, expectedVersion int64) (int64, error)
+
+	// ChangeMasterPassword в одной транзакции меняет хэш пароля, сохраняет ключ хранилища,
+	// зашифрованный новым мастер-ключом, отзывает все refresh-токены пользователя
+	// и увеличивает версию сессий, чтобы выданные ранее access-токены перестали приниматься.
+	// Версия ключа проверяется так же, как в StoreVaultKey.
+	ChangeMasterPassword(userID, passwordHash string, key *pb.VaultKey, expectedVersion int64) (int64, error)
 }
 
 // PostgresUserRepository — реализация UserRepository для PostgreSQL.
@@ -48,9 +74,9 @@ func (r *PostgresUserRepository) CreateUser(login, passwordHash string) (string,
 func (r *PostgresUserRepository) GetUserByLogin(login string) (*User, error) {
 	var u User
 	err := r.db.QueryRowContext(context.Background(),
-		`SELECT id, login, password_hash, status, EXTRACT(EPOCH FROM created_at)::int, EXTRACT(EPOCH FROM updated_at)::int 
+		`SELECT id, login, password_hash, status, EXTRACT(EPOCH FROM created_at)::int, EXTRACT(EPOCH FROM updated_at)::int, session_version
          FROM users WHERE login = $1 AND status = 'active'`,
-		login).Scan(&u.ID, &u.Login, &u.PasswordHash, &u.Status, &u.CreatedAt, &u.UpdatedAt)
+		login).Scan(&u.ID, &u.Login, &u.PasswordHash, &u.Status, &u.CreatedAt, &u.UpdatedAt, &u.SessionVersion)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -60,3 +86,132 @@ func (r *PostgresUserRepository) GetUserByLogin(login string) (*User, error) {
 	}
 	return &u, nil
 }
+
+// GetUserByID ищет активного пользователя по идентификатору.
+func (r *PostgresUserRepository) GetUserByID(userID string) (*User, error) {
+	var u User
+	err := r.db.QueryRowContext(context.Background(),
+		`SELECT id, login, password_hash, status, EXTRACT(EPOCH FROM created_at)::int, EXTRACT(EPOCH FROM updated_at)::int, session_version
+         FROM users WHERE id = $1 AND status = 'active'`,
+		userID).Scan(&u.ID, &u.Login, &u.PasswordHash, &u.Status, &u.CreatedAt, &u.UpdatedAt, &u.SessionVersion)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+	return &u, nil
+}
+
+// GetVaultKey читает зашифрованный ключ хранилища пользователя.
+func (r *PostgresUserRepository) GetVaultKey(userID string) (*pb.VaultKey, error) {
+	var (
+		key = &pb.VaultKey{}
+		kdf []byte
+	)
+	err := r.db.QueryRowContext(context.Background(),
+		`SELECT vault_key, vault_key_salt, vault_key_kdf, vault_key_version
+         FROM users WHERE id = $1 AND vault_key IS NOT NULL`,
+		userID).Scan(&key.WrappedKey, &key.Salt, &kdf, &key.Version)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault key: %w", err)
+	}
+
+	key.Kdf = &pb.KDFParams{}
+	if err := protojson.Unmarshal(kdf, key.Kdf); err != nil {
+		return nil, fmt.Errorf("failed to decode vault key kdf: %w", err)
+	}
+	return key, nil
+}
+
+// StoreVaultKey сохраняет зашифрованный ключ хранилища с проверкой версии.
+func (r *PostgresUserRepository) StoreVaultKey(userID string, key *pb.VaultKey, expectedVersion int64) (int64, error) {
+	kdf, err := protojson.Marshal(key.Kdf)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode vault key kdf: %w", err)
+	}
+
+	var version int64
+	err = r.db.QueryRowContext(context.Background(),
+		`UPDATE users SET
+             vault_key = $2,
+             vault_key_salt = $3,
+             vault_key_kdf = $4,
+             vault_key_version = vault_key_version + 1,
+             updated_at = NOW()
+         WHERE id = $1 AND vault_key_version = $5
+         RETURNING vault_key_version`,
+		userID, key.WrappedKey, key.Salt, kdf, expectedVersion).Scan(&version)
+	if err == nil {
+		return version, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to store vault key: %w", err)
+	}
+
+	err = r.db.QueryRowContext(context.Background(),
+		`SELECT vault_key_version FROM users WHERE id = $1`,
+		userID).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get vault key version: %w", err)
+	}
+
+	return version, ErrVaultKeyConflict
+}
+
+// ChangeMasterPassword меняет пароль и ключ хранилища и завершает все сессии пользователя.
+func (r *PostgresUserRepository) ChangeMasterPassword(userID, passwordHash string, key *pb.VaultKey, expectedVersion int64) (int64, error) {
+	kdf, err := protojson.Marshal(key.Kdf)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode vault key kdf: %w", err)
+	}
+
+	ctx := context.Background()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var version int64
+	err = tx.QueryRowContext(ctx,
+		`UPDATE users SET
+             password_hash = $2,
+             vault_key = $3,
+             vault_key_salt = $4,
+             vault_key_kdf = $5,
+             vault_key_version = vault_key_version + 1,
+             session_version = session_version + 1,
+             updated_at = NOW()
+         WHERE id = $1 AND vault_key_version = $6
+         RETURNING vault_key_version`,
+		userID, passwordHash, key.WrappedKey, key.Salt, kdf, expectedVersion).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRowContext(ctx,
+			`SELECT vault_key_version FROM users WHERE id = $1`,
+			userID).Scan(&version)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get vault key version: %w", err)
+		}
+		return version, ErrVaultKeyConflict
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to change password: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked = TRUE WHERE user_id = $1 AND NOT revoked`,
+		userID); err != nil {
+		return 0, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit password change: %w", err)
+	}
+	return version, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/dvkhr/gophkeeper/pb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -71,3 +72,38 @@ func TestUserRepository_GetUser_NotActive(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, user)
 }
+
+func TestUserRepository_VaultKey(t *testing.T) {
+	db := setupTestDB()
+	repo := NewUserRepository(db)
+
+	userID, err := repo.CreateUser("vaultuser", "hashedpass")
+	require.NoError(t, err)
+
+	key, err := repo.GetVaultKey(userID)
+	require.NoError(t, err)
+	assert.Nil(t, key)
+
+	stored := &pb.VaultKey{
+		WrappedKey: []byte("wrapped"),
+		Salt:       []byte("salt"),
+		Kdf:        &pb.KDFParams{Algorithm: "argon2id", Iterations: 3, Memory: 65536, Parallelism: 4, KeyLength: 32},
+	}
+	version, err := repo.StoreVaultKey(userID, stored, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+
+	key, err = repo.GetVaultKey(userID)
+	require.NoError(t, err)
+	require.NotNil(t, key)
+	assert.Equal(t, []byte("wrapped"), key.WrappedKey)
+	assert.Equal(t, []byte("salt"), key.Salt)
+	assert.Equal(t, "argon2id", key.Kdf.Algorithm)
+	assert.Equal(t, uint32(65536), key.Kdf.Memory)
+	assert.Equal(t, int64(1), key.Version)
+
+	// Устаревшая версия не перезаписывает ключ
+	version, err = repo.StoreVaultKey(userID, stored, 0)
+	require.ErrorIs(t, err, ErrVaultKeyConflict)
+	assert.Equal(t, int64(1), version)
+}
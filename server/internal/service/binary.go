@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/logger"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
+	"github.com/dvkhr/gophkeeper/server/internal/blob"
+	"github.com/dvkhr/gophkeeper/server/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// blobChunkSize — размер чанка шифротекста при скачивании.
+const blobChunkSize = 1 << 20
+
+// UploadBinary принимает поток чанков шифротекста бинарной записи.
+// Чанки дописываются к незавершённой загрузке upload_id, начиная со смещения из заголовка.
+// Если поток закрыт без SHA-256, принятая часть сохраняется для продолжения.
+// Получив SHA-256, сервер проверяет шифротекст и сохраняет запись с проверкой базовой ревизии.
+func (s *Service) UploadBinary(ctx context.Context, userID string, stream grpc.ClientStreamingServer[pb.UploadBinaryRequest, pb.UploadBinaryResponse]) error {
+	if s.Blobs == nil {
+		return status.Errorf(codes.Unimplemented, "binary storage is not configured")
+	}
+
+	req, err := stream.Recv()
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "upload header is required")
+	}
+
+	header := req.Header
+	if header == nil || header.Record == nil {
+		return status.Errorf(codes.InvalidArgument, "upload header is required")
+	}
+	record := header.Record
+	if record.Id == "" {
+		return status.Errorf(codes.InvalidArgument, "Record ID is required")
+	}
+	if !payload.ValidTypeName(record.Type) {
+		return status.Errorf(codes.InvalidArgument, "invalid record type %q", record.Type)
+	}
+
+	part, err := s.Blobs.OpenPartial(userID, record.Id, header.UploadId, header.Offset)
+	if errors.Is(err, blob.ErrInvalidUploadID) {
+		return status.Errorf(codes.InvalidArgument, "invalid upload id")
+	}
+	if errors.Is(err, blob.ErrOffsetMismatch) {
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to open upload: %v", err)
+	}
+
+	received := header.Offset
+	for {
+		if len(req.Chunk) > 0 {
+			n, err := part.Write(req.Chunk)
+			received += int64(n)
+			if err != nil {
+				part.Close()
+				return status.Errorf(codes.Internal, "failed to write chunk: %v", err)
+			}
+		}
+		if len(req.Sha256) > 0 {
+			break
+		}
+
+		req, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Принятая часть остаётся на диске, клиент продолжит загрузку с GetUploadOffset
+			part.Close()
+			return err
+		}
+	}
+
+	if err := part.Close(); err != nil {
+		return status.Errorf(codes.Internal, "failed to save upload: %v", err)
+	}
+
+	if len(req.Sha256) == 0 {
+		return stream.SendAndClose(&pb.UploadBinaryResponse{Received: received})
+	}
+
+	size, err := s.Blobs.Verify(userID, record.Id, header.UploadId, req.Sha256)
+	if errors.Is(err, blob.ErrHashMismatch) {
+		return status.Errorf(codes.DataLoss, "sha256 mismatch, upload discarded")
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to verify upload: %v", err)
+	}
+
+	record.BlobSize = size
+	record.BlobSha256 = req.Sha256
+
+	// Содержимое подменяется до записи в базу: если сохранить запись не удастся,
+	// прежнее содержимое возвращается на место, и база не ссылается на недостающий файл.
+	// Блокировка не даёт параллельной загрузке той же записи вклиниться между подменой и сохранением
+	unlock := s.Blobs.Lock(userID, record.Id)
+	defer unlock()
+
+	if err := s.Blobs.Commit(userID, record.Id, header.UploadId); err != nil {
+		return status.Errorf(codes.Internal, "failed to commit upload: %v", err)
+	}
+
+	revision, err := s.Repo.SaveBinaryData(userID, record, record.Revision)
+	if err != nil {
+		if rbErr := s.Blobs.Rollback(userID, record.Id, header.UploadId); rbErr != nil {
+			logger.Logg.Error("Failed to roll back upload", "id", record.Id, "user", userID, "error", rbErr)
+		}
+	}
+	if errors.Is(err, repository.ErrIDNotAvailable) {
+		return status.Errorf(codes.FailedPrecondition, "record ID is not available")
+	}
+	if errors.Is(err, repository.ErrRevisionConflict) {
+		logger.Logg.Info("Upload conflict", "id", record.Id, "user", userID)
+		return status.Errorf(codes.Aborted, "revision conflict: base revision %d, server revision %d", record.Revision, revision)
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to save data: %v", err)
+	}
+
+	if err := s.Blobs.Release(userID, record.Id); err != nil {
+		logger.Logg.Warn("Failed to remove previous blob", "id", record.Id, "user", userID, "error", err)
+	}
+
+	return stream.SendAndClose(&pb.UploadBinaryResponse{
+		Received:  size,
+		Completed: true,
+		Revision:  revision,
+	})
+}
+
+// DownloadBinary отправляет шифротекст бинарной записи чанками, начиная со смещения offset.
+// Первое сообщение содержит полный размер и SHA-256 шифротекста для проверки на клиенте.
+func (s *Service) DownloadBinary(ctx context.Context, userID, recordID string, offset int64, stream grpc.ServerStreamingServer[pb.BinaryChunk]) error {
+	if s.Blobs == nil {
+		return status.Errorf(codes.Unimplemented, "binary storage is not configured")
+	}
+	if recordID == "" {
+		return status.Errorf(codes.InvalidArgument, "record ID is required")
+	}
+
+	size, hash, err := s.Repo.GetBlobInfo(userID, recordID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status.Errorf(codes.NotFound, "Data not found or access denied")
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get data: %v", err)
+	}
+	if offset < 0 || offset > size {
+		return status.Errorf(codes.OutOfRange, "offset %d is out of range [0, %d]", offset, size)
+	}
+
+	f, err := s.Blobs.Open(userID, recordID)
+	if errors.Is(err, blob.ErrNotFound) {
+		return status.Errorf(codes.NotFound, "binary content not found")
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to open binary content: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return status.Errorf(codes.Internal, "failed to seek binary content: %v", err)
+	}
+
+	buf := make([]byte, blobChunkSize)
+	msg := &pb.BinaryChunk{TotalSize: size, Sha256: hash}
+	for sent := offset; ; {
+		n, err := io.ReadFull(f, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return status.Errorf(codes.Internal, "failed to read binary content: %v", err)
+		}
+
+		msg.Chunk = buf[:n]
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+
+		sent += int64(n)
+		if n < len(buf) || sent >= size {
+			return nil
+		}
+		msg = &pb.BinaryChunk{}
+	}
+}
+
+// GetUploadOffset возвращает размер уже принятой части загрузки uploadID.
+func (s *Service) GetUploadOffset(ctx context.Context, userID, recordID, uploadID string) (int64, error) {
+	if s.Blobs == nil {
+		return 0, status.Errorf(codes.Unimplemented, "binary storage is not configured")
+	}
+	if recordID == "" {
+		return 0, status.Errorf(codes.InvalidArgument, "record ID is required")
+	}
+
+	offset, err := s.Blobs.PartialSize(userID, recordID, uploadID)
+	if errors.Is(err, blob.ErrInvalidUploadID) {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid upload id")
+	}
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "failed to get upload offset: %v", err)
+	}
+	return offset, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/logger"
+	"github.com/dvkhr/gophkeeper/server/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListVersions возвращает прежние версии записи пользователя, начиная с самой новой.
+func (s *Service) ListVersions(ctx context.Context, userID, recordID string) ([]*pb.RecordVersion, error) {
+	if recordID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "record ID is required")
+	}
+
+	versions, err := s.Repo.ListDataVersions(userID, recordID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list versions: %v", err)
+	}
+	return versions, nil
+}
+
+// RestoreVersion делает прежнюю версию записи текущей.
+// Если baseRevision не 0, запись восстанавливается, только пока её ревизия на сервере равна baseRevision.
+func (s *Service) RestoreVersion(ctx context.Context, userID, recordID string, revision, baseRevision int64) (int64, error) {
+	if recordID == "" {
+		return 0, status.Errorf(codes.InvalidArgument, "record ID is required")
+	}
+	if revision <= 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "version revision is required")
+	}
+
+	newRevision, err := s.Repo.RestoreDataVersion(userID, recordID, revision, baseRevision)
+	switch {
+	case errors.Is(err, repository.ErrVersionNotFound):
+		return 0, status.Errorf(codes.NotFound, "version %d of record %s not found", revision, recordID)
+	case errors.Is(err, repository.ErrVersionUnavailable):
+		return 0, status.Errorf(codes.FailedPrecondition, "version %d of record %s: %v", revision, recordID, err)
+	case errors.Is(err, repository.ErrRevisionConflict):
+		logger.Logg.Info("Restore conflict", "id", recordID, "user", userID, "base", baseRevision, "current", newRevision)
+		return 0, status.Errorf(codes.Aborted, "revision conflict: expected %d, current %d", baseRevision, newRevision)
+	case err != nil:
+		return 0, status.Errorf(codes.Internal, "failed to restore version: %v", err)
+	}
+
+	logger.Logg.Info("Record version restored", "id", recordID, "user", userID, "version", revision, "revision", newRevision)
+	return newRevision, nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/server/internal/repository"
+)
+
+// errInvalidPageToken возвращается, если токен страницы выдан не сервером.
+var errInvalidPageToken = errors.New("invalid page token")
+
+// encodePageToken кодирует позицию последней записи страницы в непрозрачный токен.
+// Время хранится в микросекундах — с точностью колонки updated_at.
+func encodePageToken(cursor *repository.PageCursor) string {
+	raw := strconv.FormatInt(cursor.UpdatedAt.UnixMicro(), 10) + ":" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodePageToken разбирает токен, выданный encodePageToken.
+func decodePageToken(token string) (*repository.PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidPageToken
+	}
+
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return nil, errInvalidPageToken
+	}
+	usec, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return nil, errInvalidPageToken
+	}
+
+	return &repository.PageCursor{UpdatedAt: time.UnixMicro(usec).UTC(), ID: id}, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/dvkhr/gophkeeper/pkg/logger"
+)
+
+const (
+	defaultTrashRetention   = 30 * 24 * time.Hour
+	defaultPurgeInterval    = time.Hour
+	defaultHistoryVersions  = 20
+	defaultHistoryRetention = 90 * 24 * time.Hour
+)
+
+// PurgeDeletedData окончательно удаляет записи, пролежавшие в корзине дольше срока её хранения,
+// вместе с загруженным содержимым бинарных записей.
+func (s *Service) PurgeDeletedData(ctx context.Context) (int64, error) {
+	olderThan := time.Now().Add(-s.trashRetention())
+
+	purged, err := s.Repo.PurgeDeletedData(olderThan)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.Blobs != nil {
+		for _, key := range purged {
+			unlock := s.Blobs.Lock(key.UserID, key.ID)
+			if err := s.Blobs.Delete(key.UserID, key.ID); err != nil {
+				logger.Logg.Error("Failed to delete blob", "id", key.ID, "user", key.UserID, "error", err)
+			}
+			unlock()
+		}
+	}
+
+	if len(purged) > 0 {
+		logger.Logg.Info("Purged deleted records", "count", len(purged), "older_than", olderThan)
+	}
+	return int64(len(purged)), nil
+}
+
+// PurgeDataHistory удаляет прежние версии записей сверх лимита количества и старше срока хранения истории.
+func (s *Service) PurgeDataHistory(ctx context.Context) (int64, error) {
+	keep, retention := s.historyLimits()
+	olderThan := time.Now().Add(-retention)
+
+	purged, err := s.Repo.PurgeDataHistory(keep, olderThan)
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		logger.Logg.Info("Purged record versions", "count", purged, "keep", keep, "older_than", olderThan)
+	}
+	return purged, nil
+}
+
+// RunPurger периодически вызывает PurgeDeletedData и PurgeDataHistory до отмены контекста.
+func (s *Service) RunPurger(ctx context.Context) {
+	interval := defaultPurgeInterval
+	if s.Cfg.Data.PurgeIntervalMinutes > 0 {
+		interval = time.Duration(s.Cfg.Data.PurgeIntervalMinutes) * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeDeletedData(ctx); err != nil {
+			logger.Logg.Error("Failed to purge deleted records", "error", err)
+		}
+		if _, err := s.PurgeDataHistory(ctx); err != nil {
+			logger.Logg.Error("Failed to purge record history", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// trashRetention возвращает срок хранения удалённых записей из конфигурации.
+// Если trash_retention_hours не задан, используется прежний tombstone_retention_hours.
+func (s *Service) trashRetention() time.Duration {
+	if s.Cfg.Data.TrashRetentionHours > 0 {
+		return time.Duration(s.Cfg.Data.TrashRetentionHours) * time.Hour
+	}
+	if s.Cfg.Data.TombstoneRetentionHours > 0 {
+		return time.Duration(s.Cfg.Data.TombstoneRetentionHours) * time.Hour
+	}
+	return defaultTrashRetention
+}
+
+// historyLimits возвращает лимит количества версий записи и срок хранения истории из конфигурации.
+func (s *Service) historyLimits() (int, time.Duration) {
+	keep, retention := defaultHistoryVersions, defaultHistoryRetention
+	if s.Cfg.Data.HistoryVersions > 0 {
+		keep = s.Cfg.Data.HistoryVersions
+	}
+	if s.Cfg.Data.HistoryRetentionDays > 0 {
+		retention = time.Duration(s.Cfg.Data.HistoryRetentionDays) * 24 * time.Hour
+	}
+	return keep, retention
+}
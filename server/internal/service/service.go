@@ -4,20 +4,33 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/dvkhr/gophkeeper/pb"
 	"github.com/dvkhr/gophkeeper/pkg/logger"
+	"github.com/dvkhr/gophkeeper/pkg/payload"
 	"github.com/dvkhr/gophkeeper/server/internal/auth"
+	"github.com/dvkhr/gophkeeper/server/internal/blob"
 	"github.com/dvkhr/gophkeeper/server/internal/config"
 	"github.com/dvkhr/gophkeeper/server/internal/repository"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	// maxBlindIndexes ограничивает число слепых индексов в одном запросе GetData.
+	maxBlindIndexes = 16
+
+	// maxPageSize ограничивает размер страницы GetData; больший page_size уменьшается до него.
+	maxPageSize = 1000
+)
+
 type Service struct {
-	Repo repository.Repository
-	Cfg  *config.Config
+	Repo  repository.Repository
+	Cfg   *config.Config
+	Blobs *blob.Store
 }
 
 func New(repo repository.Repository, cfg *config.Config) *Service {
@@ -51,7 +64,7 @@ func (s *Service) Register(ctx context.Context, login, password string) (*pb.Aut
 		return nil, status.Errorf(codes.Internal, "failed to generate refresh token")
 	}
 
-	accessToken, err := auth.GenerateToken(*s.Cfg, userID)
+	accessToken, err := auth.GenerateToken(*s.Cfg, userID, 0)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to generate access token")
 	}
@@ -89,7 +102,7 @@ func (s *Service) Login(ctx context.Context, login, password string) (*pb.AuthRe
 		return nil, status.Errorf(codes.Internal, "failed to generate refresh token")
 	}
 
-	accessToken, err := auth.GenerateToken(*s.Cfg, user.ID)
+	accessToken, err := auth.GenerateToken(*s.Cfg, user.ID, user.SessionVersion)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to generate access token")
 	}
@@ -97,6 +110,7 @@ func (s *Service) Login(ctx context.Context, login, password string) (*pb.AuthRe
 	return &pb.AuthResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+		UserId:       user.ID,
 	}, nil
 }
 
@@ -108,6 +122,9 @@ func (s *Service) StoreData(ctx context.Context, userID string, record *pb.DataR
 	if record.Id == "" {
 		return status.Errorf(codes.InvalidArgument, "Record ID is required")
 	}
+	if !payload.ValidTypeName(record.Type) {
+		return status.Errorf(codes.InvalidArgument, "invalid record type %q", record.Type)
+	}
 
 	if err := s.Repo.SaveData(userID, record); err != nil {
 		return status.Errorf(codes.Internal, "failed to save data: %v", err)
@@ -116,32 +133,143 @@ func (s *Service) StoreData(ctx context.Context, userID string, record *pb.DataR
 	return nil
 }
 
-// GetData возвращает все неудалённые записи пользователя.
-func (s *Service) GetData(ctx context.Context, userID string) ([]*pb.DataRecord, error) {
-	records, err := s.Repo.GetAllData(userID)
+// GetData возвращает неудалённые записи пользователя, подходящие под условия запроса:
+// ID, тип и слепые индексы. При ненулевом page_size записи выдаются страницами,
+// следующая страница запрашивается с next_page_token предыдущего ответа.
+func (s *Service) GetData(ctx context.Context, userID string, req *pb.GetDataRequest) (*pb.DataResponse, error) {
+	if len(req.BlindIndexes) > maxBlindIndexes {
+		return nil, status.Errorf(codes.InvalidArgument, "too many blind indexes: %d (max %d)", len(req.BlindIndexes), maxBlindIndexes)
+	}
+	if req.Type != "" && !payload.ValidTypeName(req.Type) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid record type %q", req.Type)
+	}
+	if req.PageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page size must not be negative")
+	}
+
+	filter := repository.DataFilter{
+		ID:           req.Id,
+		Type:         req.Type,
+		BlindIndexes: req.BlindIndexes,
+		Limit:        min(int(req.PageSize), maxPageSize),
+	}
+	if req.PageToken != "" {
+		after, err := decodePageToken(req.PageToken)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		}
+		filter.After = after
+	}
+
+	page, err := s.Repo.GetDataPage(userID, filter)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to retrieve data: %v", err)
 	}
-	return records, nil
+
+	resp := &pb.DataResponse{Records: page.Records}
+	if page.Next != nil {
+		resp.NextPageToken = encodePageToken(page.Next)
+	}
+	return resp, nil
+}
+
+// GetRecord возвращает неудалённую запись пользователя по ID.
+// Чужая запись неотличима от несуществующей: в обоих случаях возвращается NotFound.
+func (s *Service) GetRecord(ctx context.Context, userID, recordID string) (*pb.DataRecord, error) {
+	if recordID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "record ID is required")
+	}
+
+	record, err := s.Repo.GetByID(userID, recordID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "Data not found or access denied")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get data: %v", err)
+	}
+	return record, nil
 }
 
 // SyncData синхронизирует клиентские данные с сервером.
-func (s *Service) SyncData(ctx context.Context, userID string, records []*pb.DataRecord) ([]*pb.DataRecord, error) {
+// Каждая запись сохраняется, только если её базовая ревизия (поле Revision) совпадает с серверной.
+// Устаревшие записи не перезаписывают серверные данные и возвращаются в списке конфликтов.
+// Удаления клиента применяются с той же проверкой ревизии.
+// Записи и удаления, которые не удалось сохранить, возвращаются в списке отклонённых;
+// временные сбои помечаются retryable, остальные отказы повторная отправка не исправит.
+// В ответ попадают только записи, изменённые или удалённые после курсора клиента.
+func (s *Service) SyncData(ctx context.Context, userID string, records []*pb.DataRecord, deletions []*pb.Tombstone, cursor int64) (*pb.SyncResponse, error) {
+	var (
+		conflicts []*pb.SyncConflict
+		rejected  []*pb.SyncRejected
+	)
 	for _, record := range records {
-		if record == nil || record.Id == "" {
+		if record == nil {
 			continue
 		}
-		if err := s.Repo.SaveData(userID, record); err != nil {
+		if record.Id == "" {
+			rejected = append(rejected, &pb.SyncRejected{Reason: "record ID is required"})
+			continue
+		}
+		if !payload.ValidTypeName(record.Type) {
+			rejected = append(rejected, &pb.SyncRejected{Id: record.Id, Reason: fmt.Sprintf("invalid record type %q", record.Type)})
+			continue
+		}
+
+		revision, err := s.Repo.SaveDataWithRevision(userID, record, record.Revision)
+		if errors.Is(err, repository.ErrRevisionConflict) {
+			logger.Logg.Info("Sync conflict", "id", record.Id, "user", userID)
+			conflicts = append(conflicts, &pb.SyncConflict{
+				Id:             record.Id,
+				BaseRevision:   record.Revision,
+				ServerRevision: revision,
+			})
+			continue
+		}
+		if errors.Is(err, repository.ErrIDNotAvailable) {
+			logger.Logg.Info("Sync record ID taken by another user", "id", record.Id, "user", userID)
+			rejected = append(rejected, &pb.SyncRejected{Id: record.Id, Reason: "record ID is not available"})
+			continue
+		}
+		if err != nil {
 			logger.Logg.Error("Failed to sync record", "id", record.Id, "error", err)
+			rejected = append(rejected, &pb.SyncRejected{Id: record.Id, Reason: "failed to save record", Retryable: true})
+		}
+	}
+
+	for _, deletion := range deletions {
+		if deletion == nil || deletion.Id == "" {
+			continue
+		}
+
+		revision, err := s.Repo.MarkDataAsDeletedWithRevision(userID, deletion.Id, deletion.Revision)
+		if errors.Is(err, repository.ErrRevisionConflict) {
+			logger.Logg.Info("Sync delete conflict", "id", deletion.Id, "user", userID)
+			conflicts = append(conflicts, &pb.SyncConflict{
+				Id:             deletion.Id,
+				BaseRevision:   deletion.Revision,
+				ServerRevision: revision,
+			})
+			continue
+		}
+		if err != nil {
+			logger.Logg.Error("Failed to sync deletion", "id", deletion.Id, "error", err)
+			rejected = append(rejected, &pb.SyncRejected{Id: deletion.Id, Reason: "failed to delete record", Retryable: true})
 		}
 	}
 
-	remoteRecords, err := s.Repo.GetAllData(userID)
+	changes, err := s.Repo.GetDataChangedSince(userID, cursor)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to retrieve remote data: %v", err)
 	}
 
-	return remoteRecords, nil
+	return &pb.SyncResponse{
+		Records:    changes.Records,
+		Conflicts:  conflicts,
+		Tombstones: changes.Tombstones,
+		Cursor:     changes.Cursor,
+		FullResync: changes.Reset,
+		Rejected:   rejected,
+	}, nil
 }
 
 // DeleteData помечает запись как удалённую.
@@ -187,7 +315,15 @@ func (s *Service) Refresh(ctx context.Context, refreshToken string) (*pb.AuthRes
 		return nil, status.Error(codes.Internal, "failed to get user ID")
 	}
 
-	newAccessToken, err := auth.GenerateToken(*s.Cfg, userID)
+	sessionVersion, err := s.Repo.GetSessionVersion(userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.Unauthenticated, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to get session version")
+	}
+
+	newAccessToken, err := auth.GenerateToken(*s.Cfg, userID, sessionVersion)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to generate access token")
 	}
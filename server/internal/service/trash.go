@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/logger"
+	"github.com/dvkhr/gophkeeper/server/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListDeleted возвращает записи корзины пользователя со временем их окончательного удаления.
+// Записи хранятся в корзине trash_retention_hours, после чего их удаляет RunPurger.
+func (s *Service) ListDeleted(ctx context.Context, userID string) ([]*pb.DeletedRecord, error) {
+	deleted, err := s.Repo.GetDeletedData(userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list deleted data: %v", err)
+	}
+
+	retention := s.trashRetention()
+	records := make([]*pb.DeletedRecord, 0, len(deleted))
+	for _, d := range deleted {
+		records = append(records, &pb.DeletedRecord{
+			Record:    d.Record,
+			DeletedAt: d.DeletedAt.Unix(),
+			PurgeAt:   d.DeletedAt.Add(retention).Unix(),
+		})
+	}
+	return records, nil
+}
+
+// RestoreData восстанавливает запись из корзины и возвращает её новую ревизию.
+func (s *Service) RestoreData(ctx context.Context, userID, recordID string) (int64, error) {
+	if recordID == "" {
+		return 0, status.Errorf(codes.InvalidArgument, "record ID is required")
+	}
+
+	revision, err := s.Repo.UndeleteData(userID, recordID)
+	if errors.Is(err, repository.ErrNotDeleted) {
+		return 0, status.Errorf(codes.NotFound, "deleted record %s not found", recordID)
+	}
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "failed to restore data: %v", err)
+	}
+
+	logger.Logg.Info("Record restored from trash", "id", recordID, "user", userID, "revision", revision)
+	return revision, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dvkhr/gophkeeper/pb"
+	"github.com/dvkhr/gophkeeper/pkg/logger"
+	"github.com/dvkhr/gophkeeper/server/internal/auth"
+	"github.com/dvkhr/gophkeeper/server/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetVaultKey возвращает зашифрованный ключ хранилища пользователя.
+func (s *Service) GetVaultKey(ctx context.Context, userID string) (*pb.VaultKey, error) {
+	key, err := s.Repo.GetVaultKey(userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get vault key: %v", err)
+	}
+	if key == nil {
+		return nil, status.Errorf(codes.NotFound, "vault key not found")
+	}
+	return key, nil
+}
+
+// StoreVaultKey сохраняет зашифрованный ключ хранилища.
+// Ключ перезаписывается, только если клиент знает его текущую версию:
+// так два устройства не затрут ключи друг друга.
+func (s *Service) StoreVaultKey(ctx context.Context, userID string, key *pb.VaultKey, expectedVersion int64) (*pb.VaultKey, error) {
+	if err := validateVaultKey(key); err != nil {
+		return nil, err
+	}
+
+	version, err := s.Repo.StoreVaultKey(userID, key, expectedVersion)
+	if errors.Is(err, repository.ErrVaultKeyConflict) {
+		logger.Logg.Info("Vault key conflict", "user", userID, "expected", expectedVersion, "current", version)
+		return nil, status.Errorf(codes.Aborted, "vault key version conflict: expected %d, current %d", expectedVersion, version)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to store vault key: %v", err)
+	}
+
+	return &pb.VaultKey{
+		WrappedKey: key.WrappedKey,
+		Salt:       key.Salt,
+		Kdf:        key.Kdf,
+		Version:    version,
+	}, nil
+}
+
+// ChangeMasterPassword проверяет текущий пароль, сохраняет новый хэш пароля и ключ хранилища,
+// зашифрованный новым мастер-ключом. Все refresh-токены пользователя отзываются, а версия
+// сессий увеличивается: access-токены других устройств сразу перестают приниматься,
+// и им нужно заново войти и получить новый ключ хранилища.
+// Записи не перешифровываются: меняется только обёртка ключа хранилища.
+func (s *Service) ChangeMasterPassword(ctx context.Context, userID, oldPassword, newPassword string, key *pb.VaultKey, expectedVersion int64) (*pb.ChangeMasterPasswordResponse, error) {
+	if oldPassword == "" || newPassword == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "old and new passwords are required")
+	}
+	if err := validateVaultKey(key); err != nil {
+		return nil, err
+	}
+
+	user, err := s.Repo.GetUserByID(userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get user")
+	}
+	if user == nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	if !auth.CheckPasswordHash(oldPassword, user.PasswordHash) {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+	}
+
+	hashedPassword, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash password")
+	}
+
+	version, err := s.Repo.ChangeMasterPassword(userID, hashedPassword, key, expectedVersion)
+	if errors.Is(err, repository.ErrVaultKeyConflict) {
+		return nil, status.Errorf(codes.Aborted, "vault key version conflict: expected %d, current %d", expectedVersion, version)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to change password: %v", err)
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(s.Repo, userID, *s.Cfg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate refresh token")
+	}
+
+	sessionVersion, err := s.Repo.GetSessionVersion(userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get session version")
+	}
+
+	accessToken, err := auth.GenerateToken(*s.Cfg, userID, sessionVersion)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate access token")
+	}
+
+	return &pb.ChangeMasterPasswordResponse{
+		Auth: &pb.AuthResponse{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			UserId:       userID,
+		},
+		Key: &pb.VaultKey{
+			WrappedKey: key.WrappedKey,
+			Salt:       key.Salt,
+			Kdf:        key.Kdf,
+			Version:    version,
+		},
+	}, nil
+}
+
+// validateVaultKey проверяет, что ключ хранилища содержит всё необходимое для его расшифровки.
+func validateVaultKey(key *pb.VaultKey) error {
+	if key == nil || len(key.WrappedKey) == 0 {
+		return status.Errorf(codes.InvalidArgument, "wrapped key is required")
+	}
+	if len(key.Salt) == 0 {
+		return status.Errorf(codes.InvalidArgument, "salt is required")
+	}
+	if key.Kdf == nil || key.Kdf.Algorithm == "" {
+		return status.Errorf(codes.InvalidArgument, "kdf is required")
+	}
+	return nil
+}